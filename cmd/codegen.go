@@ -0,0 +1,134 @@
+// Copyright © 2019 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"invtools/common"
+	"invtools/pkg/codegen"
+	"invtools/pkg/util"
+
+	"invtools/utils"
+
+	"github.com/logrusorgru/aurora"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	codegenCmdExample = fmt.Sprintf("%s\n%s\n%s\n",
+		fmt.Sprintf(`%s codegen /input/codes.csv /output/directory -t=qrcode --width=600 --height=600 --error_correction=H`, appName),
+		fmt.Sprintf(`%s codegen /input/codes.csv /output/directory -t=barcode128 --width=600 --height=150 --show_text`, appName),
+		fmt.Sprintf(`%s codegen /input/codes.csv -t=datamatrix --format=pdf --margin=2`, appName),
+	)
+)
+
+// codegenCmd represents the codegen command
+var codegenCmd = &cobra.Command{
+	Use:   "codegen input.csv [output]",
+	Short: "Generate QRCode/Barcode images or PDFs from a csv file.",
+	Long: `Generate QRCode/Barcode images or single-page PDFs from a csv file.
+support QRCode、Barcode128、DataMatrix、EAN-13、EAN-8、PDF417
+
+The csv needs a "content" column. Optional columns:
+  name  output file name without extension, defaults to the content
+  type  code type of the row, defaults to --type
+  text  text printed below the code, defaults to the content with --show_text`,
+	Example: codegenCmdExample,
+	Run: func(cmd *cobra.Command, args []string) {
+		var (
+			inputPath, outputPath string
+		)
+
+		if len(args) < 1 {
+			fmt.Println(aurora.Magenta("至少输入一个参数，比如csv文件路径"))
+			os.Exit(1)
+		}
+		inputPath = args[0]
+
+		if len(args) == 2 {
+			outputPath = args[1]
+		} else {
+			outputPath = path.Join(viper.GetString(common.CurrentDir), fmt.Sprintf("codes_%s", time.Now().In(utils.LocationCST).Format("2006_01_02_15_04_05")))
+		}
+
+		if !path.IsAbs(inputPath) {
+			if p, err := filepath.Abs(inputPath); err != nil {
+				fmt.Println(aurora.Magenta("convert input path to abs path failed, please contact Rick~"))
+				os.Exit(1)
+			} else {
+				inputPath = p
+			}
+		}
+
+		if !path.IsAbs(outputPath) {
+			if p, err := filepath.Abs(outputPath); err != nil {
+				fmt.Println(aurora.Magenta("convert output directory to abs directory failed, please contact Rick~"))
+				os.Exit(1)
+			} else {
+				outputPath = p
+			}
+		}
+
+		opts := codegen.Options{
+			Type:            codegenType,
+			Format:          codegenFormat,
+			Width:           codegenWidth,
+			Height:          codegenHeight,
+			Margin:          codegenMargin,
+			ErrorCorrection: codegenErrorCorrection,
+			ShowText:        codegenShowText,
+		}
+		err := codegen.NewCodeGenerator(inputPath, outputPath, opts).Do()
+		if err != nil {
+			fmt.Println(aurora.Magenta("生成二维码/条形码出现错误，err:"), err)
+			os.Exit(1)
+		}
+	},
+}
+
+var (
+	codegenType                string
+	codegenTypeFlag            = "type"
+	codegenFormat              string
+	codegenFormatFlag          = "format"
+	codegenWidth               int
+	codegenWidthFlag           = "width"
+	codegenHeight              int
+	codegenHeightFlag          = "height"
+	codegenMargin              int
+	codegenMarginFlag          = "margin"
+	codegenErrorCorrection     string
+	codegenErrorCorrectionFlag = "error_correction"
+	codegenShowText            bool
+	codegenShowTextFlag        = "show_text"
+)
+
+func init() {
+	rootCmd.AddCommand(codegenCmd)
+
+	codegenCmd.Flags().StringVarP(&codegenType, codegenTypeFlag, "t", common.CodeTypeQRCode, "code类型: qrcode/barcode128/datamatrix/ean13/ean8/pdf417, csv中的type列优先")
+	codegenCmd.Flags().StringVar(&codegenFormat, codegenFormatFlag, codegen.FormatPng, "输出格式: png/pdf, pdf为码大小的单页pdf")
+	codegenCmd.Flags().IntVar(&codegenWidth, codegenWidthFlag, 300, "码的宽度, 单位像素; 二维码/DataMatrix为正方形, 取宽高中较小的")
+	codegenCmd.Flags().IntVar(&codegenHeight, codegenHeightFlag, 300, "码的高度, 单位像素, 不包括下方的文字")
+	codegenCmd.Flags().IntVar(&codegenMargin, codegenMarginFlag, util.DefaultCodeMargin, "码四周的空白, 单位为模块宽度; -1为默认值: 二维码4, DataMatrix 1, PDF417 2, 条形码10")
+	codegenCmd.Flags().StringVar(&codegenErrorCorrection, codegenErrorCorrectionFlag, "M", "二维码纠错等级: L/M/Q/H, PDF417对应安全等级2/3/4/5")
+	codegenCmd.Flags().BoolVar(&codegenShowText, codegenShowTextFlag, false, "在码的下方显示内容, csv中的text列优先")
+}
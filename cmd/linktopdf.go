@@ -27,11 +27,17 @@ import (
 	"github.com/spf13/viper"
 )
 
-var linktopdfCmdExample = fmt.Sprintf("%s\n%s\n%s\n%s\n",
+var linktopdfCmdExample = fmt.Sprintf("%s\n%s\n%s\n%s\n%s\n%s\n%s\n%s\n%s\n%s\n",
 	fmt.Sprintf("%s linktopdf -i=input.csv -o=output.zip", appName),
 	fmt.Sprintf("%s linktopdf -i=input.csv -o=output.zip -c=2 -z=true -t=chromedp", appName),
 	fmt.Sprintf("%s linktopdf input.csv output.zip", appName),
 	fmt.Sprintf("%s linktopdf input.csv output.zip -c=2 -z=true -t=wkhtmltopdf", appName),
+	fmt.Sprintf("%s linktopdf input.csv output.zip --paper_size=Letter --landscape --margin_top=10 --footer_template='<span class=\"pageNumber\"></span>'", appName),
+	fmt.Sprintf("%s linktopdf orders.csv output.zip --name_template='{{.order_id}}_{{.guest}}.pdf'", appName),
+	fmt.Sprintf("cat links.txt | %s linktopdf - output.zip -c=2", appName),
+	fmt.Sprintf("%s linktopdf orders.json output.zip --json_path='$.data.orders[*].voucher_url'", appName),
+	fmt.Sprintf("%s linktopdf booking.eml output.zip --link_pattern='voucher'", appName),
+	fmt.Sprintf("%s linktopdf input.csv output.tar.gz -z --archive_structure=supplier --volume_size=20 --remove_source", appName),
 )
 
 // linktopdfCmd represents the linktopdf command
@@ -39,8 +45,42 @@ var linktopdfCmd = &cobra.Command{
 	Use:   "linktopdf",
 	Short: "Print PDF from link(url).",
 	Long: `
-This command accept .csv/.xlsx/.txt file which contains links and print to pdf file.
+This command accept .csv/.xlsx/.txt/.json/.html/.eml file which contains links and print to pdf file,
+use "-" as input file to read links (one per line) from stdin.
+--json_path selects links in a .json file, e.g. $.data.orders[*].voucher_url, other fields of the object
+holding the link can be used in --name_template; without it every http string is taken.
+For .html files the <a href> links are taken, for .eml files the links in the body are taken and PDF
+attachments are saved directly. --link_pattern (regexp) keeps only the matched html/eml links.
 Support concurrency print, compress to zip, choose print tools such as chromedp or wkhtmltopdf.
+With chromedp, a pool of long-lived chrome processes is shared and --concurrency is the number of tabs,
+each chrome is restarted after --pages_per_browser pages or when it crashed.
+Print options (paper size, margins, header/footer...) can also be set in the config file $HOME/.invtools.yaml.
+For JS-heavy pages, wait for a selector, a javascript predicate, network idle or a fixed delay before printing,
+--wait_rules accepts a yaml file to choose the wait strategy by url pattern:
+  rules:
+    - pattern: "puroland\\.jp"
+      selector: "#qrcode canvas"
+      timeout: 90s
+Cookies (netscape cookie file), headers, basic auth and proxy apply to both direct downloads and printing.
+Every link is requested first, a PDF response (by Content-Type or file header) is saved directly and
+validated, other responses are printed. Failed requests are retried with exponential backoff.
+For .csv/.xlsx input with a header row, every column is available in --name_template by its header name
+(lower case, spaces replaced by "_"), plus {{.url}}, {{.row}} and {{.default}} (the name generated from url).
+File names and whether to download or render are decided by url rules, matched by host/path regexp,
+--url_rules accepts a yaml file whose rules are tried before the built-in ones (puroland, skybus, pdf, klook):
+  rules:
+    - name: example
+      host: 'tickets\.example\.com'
+      path: '^/voucher/(?P<id>\d+)'
+      file_name: 'example_{{.Group "id"}}_{{.Query "lang"}}'
+      action: render    # auto (default), download or render
+Duplicate links are printed only once. File names are assigned in input order before printing, a name
+already used is renamed by --on_collision so files never overwrite each other.
+A results csv mapping every input row to its pdf file and status is written next to the output, and
+manifest.csv (url, file, md5, size of every pdf) is written into the pdf directory (and the zip).
+With -z, pdfs are streamed into a zip or tar.gz archive, optionally in folders by supplier or date, and
+split into volumes of --volume_size MB (estimated by pdf size, each volume has its own manifest.csv).
+The pdf directory is kept unless --remove_source is set.
 `,
 	Example: linktopdfCmdExample,
 	Run: func(cmd *cobra.Command, args []string) {
@@ -51,6 +91,11 @@ Support concurrency print, compress to zip, choose print tools such as chromedp
 			needCompress = viper.GetBool(common.LinkToPdfFlagZip)
 		)
 
+		if cmd.Flags().Changed(common.LinkToPdfFlagHeader) {
+			headers, _ := cmd.Flags().GetStringArray(common.LinkToPdfFlagHeader)
+			viper.Set(common.LinkToPdfFlagHeader, headers)
+		}
+
 		//fmt.Printf("-->>args:%v\n", args)
 		//fmt.Printf("-->>flags:[%s],[%s],[%d]\n", inputFile, outputFile, concurrency)
 
@@ -108,7 +153,7 @@ func init() {
 	// is called directly, e.g.:
 	// linktopdfCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 
-	linktopdfCmd.Flags().StringP(common.LinkToPdfFlagInput, "i", "", "input file, support .csv/.xlsx/.txt file")
+	linktopdfCmd.Flags().StringP(common.LinkToPdfFlagInput, "i", "", "input file, support .csv/.xlsx/.txt/.json/.html/.eml file, - for stdin")
 	viper.BindPFlag(common.LinkToPdfFlagInput, linktopdfCmd.Flags().Lookup(common.LinkToPdfFlagInput))
 
 	linktopdfCmd.Flags().StringP(common.LinkToPdfFlagOutput, "o", common.HomeDir, "output directory, (default current directory)")
@@ -120,8 +165,118 @@ func init() {
 	linktopdfCmd.Flags().BoolP(common.LinkToPdfFlagZip, "z", false, "use zip to compress pdf, (default false)")
 	viper.BindPFlag(common.LinkToPdfFlagZip, linktopdfCmd.Flags().Lookup(common.LinkToPdfFlagZip))
 
+	linktopdfCmd.Flags().String(common.LinkToPdfFlagArchiveFormat, "", "archive format, support zip and tar.gz, (default by output file name, zip)")
+	viper.BindPFlag(common.LinkToPdfFlagArchiveFormat, linktopdfCmd.Flags().Lookup(common.LinkToPdfFlagArchiveFormat))
+
+	linktopdfCmd.Flags().String(common.LinkToPdfFlagArchiveStructure, linktopdf.StructureFlat, "folder structure in the archive, support flat, supplier (url rule name or host) and date")
+	viper.BindPFlag(common.LinkToPdfFlagArchiveStructure, linktopdfCmd.Flags().Lookup(common.LinkToPdfFlagArchiveStructure))
+
+	linktopdfCmd.Flags().Int64(common.LinkToPdfFlagVolumeSize, 0, "split the archive into volumes of at most this many MB, 0 means no split")
+	viper.BindPFlag(common.LinkToPdfFlagVolumeSize, linktopdfCmd.Flags().Lookup(common.LinkToPdfFlagVolumeSize))
+
+	linktopdfCmd.Flags().Bool(common.LinkToPdfFlagRemoveSource, false, "remove the pdf directory after compressed")
+	viper.BindPFlag(common.LinkToPdfFlagRemoveSource, linktopdfCmd.Flags().Lookup(common.LinkToPdfFlagRemoveSource))
+
 	linktopdfCmd.Flags().StringP(common.LinkToPdfFlagPrintType, "t", "chromedp", "use what kind of tool to print pdf, support chromedp and wkhtmltopdf")
 	viper.BindPFlag(common.LinkToPdfFlagPrintType, linktopdfCmd.Flags().Lookup(common.LinkToPdfFlagPrintType))
 
+	linktopdfCmd.Flags().String(common.LinkToPdfFlagNameTemplate, "", "go template of pdf file name, e.g. {{.order_id}}_{{.guest}}.pdf, (default generated from url)")
+	viper.BindPFlag(common.LinkToPdfFlagNameTemplate, linktopdfCmd.Flags().Lookup(common.LinkToPdfFlagNameTemplate))
+
+	linktopdfCmd.Flags().String(common.LinkToPdfFlagOnCollision, linktopdf.CollisionSuffix, "how to rename a pdf whose name is already used, suffix (name_2.pdf) or hash (name_<md5 of url>.pdf)")
+	viper.BindPFlag(common.LinkToPdfFlagOnCollision, linktopdfCmd.Flags().Lookup(common.LinkToPdfFlagOnCollision))
+
+	linktopdfCmd.Flags().String(common.LinkToPdfFlagURLRules, "", "yaml file of per-supplier url rules (file name and download or render), tried before the built-in rules")
+	viper.BindPFlag(common.LinkToPdfFlagURLRules, linktopdfCmd.Flags().Lookup(common.LinkToPdfFlagURLRules))
+
+	linktopdfCmd.Flags().String(common.LinkToPdfFlagJSONPath, "", "path of links in .json input, e.g. $.data.orders[*].voucher_url, (default all http strings)")
+	viper.BindPFlag(common.LinkToPdfFlagJSONPath, linktopdfCmd.Flags().Lookup(common.LinkToPdfFlagJSONPath))
+
+	linktopdfCmd.Flags().String(common.LinkToPdfFlagLinkPattern, "", "regexp to filter links of .html/.eml input, (default all http links)")
+	viper.BindPFlag(common.LinkToPdfFlagLinkPattern, linktopdfCmd.Flags().Lookup(common.LinkToPdfFlagLinkPattern))
+
+	linktopdfCmd.Flags().Int(common.LinkToPdfFlagBrowsers, util.DefaultPoolBrowsers, "number of chrome processes kept alive when print type is chromedp, --concurrency is the number of tabs")
+	viper.BindPFlag(common.LinkToPdfFlagBrowsers, linktopdfCmd.Flags().Lookup(common.LinkToPdfFlagBrowsers))
+
+	linktopdfCmd.Flags().Int(common.LinkToPdfFlagPagesPerBrowser, util.DefaultPagesPerBrowser, "restart a chrome process after it printed this many pages")
+	viper.BindPFlag(common.LinkToPdfFlagPagesPerBrowser, linktopdfCmd.Flags().Lookup(common.LinkToPdfFlagPagesPerBrowser))
+
+	// 打印选项, 也可以写在配置文件~/.invtools.yaml中
+	defaultOpts := util.DefaultPrintOptions()
+
+	linktopdfCmd.Flags().String(common.LinkToPdfFlagPaperSize, defaultOpts.PaperSize, "paper size, support A3/A4/A5/Letter/Legal")
+	viper.BindPFlag(common.LinkToPdfFlagPaperSize, linktopdfCmd.Flags().Lookup(common.LinkToPdfFlagPaperSize))
+
+	linktopdfCmd.Flags().Bool(common.LinkToPdfFlagLandscape, defaultOpts.Landscape, "landscape orientation")
+	viper.BindPFlag(common.LinkToPdfFlagLandscape, linktopdfCmd.Flags().Lookup(common.LinkToPdfFlagLandscape))
+
+	for _, name := range []string{common.LinkToPdfFlagMarginTop, common.LinkToPdfFlagMarginBottom, common.LinkToPdfFlagMarginLeft, common.LinkToPdfFlagMarginRight} {
+		linktopdfCmd.Flags().Float64(name, 0, "page margin in millimeters")
+		viper.BindPFlag(name, linktopdfCmd.Flags().Lookup(name))
+	}
+
+	linktopdfCmd.Flags().Float64(common.LinkToPdfFlagScale, defaultOpts.Scale, "scale of the page rendering, between 0.1 and 2")
+	viper.BindPFlag(common.LinkToPdfFlagScale, linktopdfCmd.Flags().Lookup(common.LinkToPdfFlagScale))
+
+	linktopdfCmd.Flags().Bool(common.LinkToPdfFlagPrintBackground, defaultOpts.PrintBackground, "print background graphics")
+	viper.BindPFlag(common.LinkToPdfFlagPrintBackground, linktopdfCmd.Flags().Lookup(common.LinkToPdfFlagPrintBackground))
+
+	linktopdfCmd.Flags().String(common.LinkToPdfFlagHeaderTemplate, "", "html template of page header, support class pageNumber/totalPages/date/title/url")
+	viper.BindPFlag(common.LinkToPdfFlagHeaderTemplate, linktopdfCmd.Flags().Lookup(common.LinkToPdfFlagHeaderTemplate))
+
+	linktopdfCmd.Flags().String(common.LinkToPdfFlagFooterTemplate, "", "html template of page footer, same as header_template")
+	viper.BindPFlag(common.LinkToPdfFlagFooterTemplate, linktopdfCmd.Flags().Lookup(common.LinkToPdfFlagFooterTemplate))
+
+	linktopdfCmd.Flags().String(common.LinkToPdfFlagPageRanges, "", "pages to print, e.g. 1-3,5, (default all pages)")
+	viper.BindPFlag(common.LinkToPdfFlagPageRanges, linktopdfCmd.Flags().Lookup(common.LinkToPdfFlagPageRanges))
+
+	linktopdfCmd.Flags().String(common.LinkToPdfFlagMediaType, "", "css media type, support print/screen, (default depends on print tool)")
+	viper.BindPFlag(common.LinkToPdfFlagMediaType, linktopdfCmd.Flags().Lookup(common.LinkToPdfFlagMediaType))
+
+	// 等待策略, 只对chromedp生效
+	linktopdfCmd.Flags().String(common.LinkToPdfFlagWaitSelector, "", "wait until the element matched by this css selector is visible before printing (chromedp only)")
+	viper.BindPFlag(common.LinkToPdfFlagWaitSelector, linktopdfCmd.Flags().Lookup(common.LinkToPdfFlagWaitSelector))
+
+	linktopdfCmd.Flags().String(common.LinkToPdfFlagWaitJS, "", "wait until this javascript expression returns true before printing (chromedp only)")
+	viper.BindPFlag(common.LinkToPdfFlagWaitJS, linktopdfCmd.Flags().Lookup(common.LinkToPdfFlagWaitJS))
+
+	linktopdfCmd.Flags().Bool(common.LinkToPdfFlagWaitNetworkIdle, false, "wait until there is no network request for 500ms before printing (chromedp only)")
+	viper.BindPFlag(common.LinkToPdfFlagWaitNetworkIdle, linktopdfCmd.Flags().Lookup(common.LinkToPdfFlagWaitNetworkIdle))
+
+	linktopdfCmd.Flags().Duration(common.LinkToPdfFlagWaitDelay, 0, "fixed delay before printing, e.g. 2s (chromedp only)")
+	viper.BindPFlag(common.LinkToPdfFlagWaitDelay, linktopdfCmd.Flags().Lookup(common.LinkToPdfFlagWaitDelay))
+
+	linktopdfCmd.Flags().String(common.LinkToPdfFlagWaitRules, "", "yaml file of wait strategies per url pattern, overrides the wait flags for matched urls")
+	viper.BindPFlag(common.LinkToPdfFlagWaitRules, linktopdfCmd.Flags().Lookup(common.LinkToPdfFlagWaitRules))
+
+	linktopdfCmd.Flags().Duration(common.LinkToPdfFlagPageTimeout, util.DefaultPageTimeout, "timeout of printing a single page (chromedp only)")
+	viper.BindPFlag(common.LinkToPdfFlagPageTimeout, linktopdfCmd.Flags().Lookup(common.LinkToPdfFlagPageTimeout))
+
+	linktopdfCmd.Flags().String(common.LinkToPdfFlagScreenshotDir, "", "save a screenshot into this directory when a page timed out (chromedp only)")
+	viper.BindPFlag(common.LinkToPdfFlagScreenshotDir, linktopdfCmd.Flags().Lookup(common.LinkToPdfFlagScreenshotDir))
+
+	// 访问url时携带的cookie、header、basic auth和代理, 下载和打印都会使用
+	linktopdfCmd.Flags().String(common.LinkToPdfFlagCookies, "", "cookie file in netscape format, e.g. exported by browser extensions or curl -c")
+	viper.BindPFlag(common.LinkToPdfFlagCookies, linktopdfCmd.Flags().Lookup(common.LinkToPdfFlagCookies))
+
+	// header的值可能包含逗号, 使用StringArray, 在Run中写入viper
+	linktopdfCmd.Flags().StringArrayP(common.LinkToPdfFlagHeader, "H", nil, "extra request header \"Key: Value\", can be repeated")
+
+	linktopdfCmd.Flags().String(common.LinkToPdfFlagBasicAuth, "", "basic auth in the form user:password")
+	viper.BindPFlag(common.LinkToPdfFlagBasicAuth, linktopdfCmd.Flags().Lookup(common.LinkToPdfFlagBasicAuth))
+
+	linktopdfCmd.Flags().String(common.LinkToPdfFlagProxy, "", "proxy server, e.g. http://127.0.0.1:8080")
+	viper.BindPFlag(common.LinkToPdfFlagProxy, linktopdfCmd.Flags().Lookup(common.LinkToPdfFlagProxy))
+
+	// 下载: 每个url先请求一次, 返回pdf时直接保存, 否则打印网页
+	linktopdfCmd.Flags().Duration(common.LinkToPdfFlagDownloadTimeout, linktopdf.DefaultDownloadTimeout, "timeout of a single http request")
+	viper.BindPFlag(common.LinkToPdfFlagDownloadTimeout, linktopdfCmd.Flags().Lookup(common.LinkToPdfFlagDownloadTimeout))
+
+	linktopdfCmd.Flags().Int(common.LinkToPdfFlagRetries, linktopdf.DefaultRetries, "retry times on network error, 5xx or 429, with exponential backoff")
+	viper.BindPFlag(common.LinkToPdfFlagRetries, linktopdfCmd.Flags().Lookup(common.LinkToPdfFlagRetries))
+
+	linktopdfCmd.Flags().Float64(common.LinkToPdfFlagRateLimit, 0, "max requests per second to a single host, 0 means unlimited")
+	viper.BindPFlag(common.LinkToPdfFlagRateLimit, linktopdfCmd.Flags().Lookup(common.LinkToPdfFlagRateLimit))
+
 	viper.Set(common.RunningDetective, linktopdf.LinktopdfName)
 }
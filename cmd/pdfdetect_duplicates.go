@@ -0,0 +1,89 @@
+// Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"invtools/common"
+	"invtools/pkg/detective"
+	"invtools/pkg/detective/duplicates"
+
+	. "github.com/logrusorgru/aurora"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var duplicatesCmdExample = fmt.Sprintf("%s\n%s\n",
+	fmt.Sprintf(`%s pdfdetect duplicates /path/to/your/directory`, appName),
+	fmt.Sprintf(`%s pdfdetect duplicates /path/to/your/directory -t 0.9 -n 4 -c`, appName),
+)
+
+// duplicatesCmd represents the duplicates command
+var duplicatesCmd = &cobra.Command{
+	Use:     "duplicates",
+	Short:   "检测相似/重复的pdf.",
+	Example: duplicatesCmdExample,
+	Long: `
+根据文件md5、归一化后的文字、二维码/条形码、页面渲染后的感知哈希检测相似的pdf,
+两个文件都有条码但条码不同时不算重复, 没有条码时文字和页面需要同时相似,
+相似度不低于阈值的归为一组, 每组保留文件名排序后的第一个文件.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := common.CurrentDir
+		if len(args) > 0 {
+			dir = args[0]
+		}
+		if !path.IsAbs(dir) {
+			p, err := filepath.Abs(dir)
+			if err != nil {
+				fmt.Println(Magenta("convert dir to abs failed"))
+				os.Exit(1)
+			}
+			dir = p
+		}
+
+		viper.Set(common.RunningDetective, duplicates.DetectiveNickName)
+		err := detective.Detect(duplicates.NewDetective(dir, duplicatesThreshold, duplicatesConcurrency, duplicatesClassify))
+		if err != nil {
+			fmt.Println("Duplicates detect failed. err:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var (
+	// 相似度阈值
+	duplicatesThreshold     float64
+	duplicatesThresholdFlag = "threshold"
+
+	// 并发执行的数量
+	duplicatesConcurrency     int
+	duplicatesConcurrencyFlag = "concurrency"
+
+	// 是否把重复的文件移动到单独的目录
+	duplicatesClassify     bool
+	duplicatesClassifyFlag = "classify"
+)
+
+func init() {
+	pdfdetectCmd.AddCommand(duplicatesCmd)
+
+	duplicatesCmd.Flags().Float64VarP(&duplicatesThreshold, duplicatesThresholdFlag, "t", duplicates.DefaultThreshold, "相似度阈值(0, 1]")
+	duplicatesCmd.Flags().IntVarP(&duplicatesConcurrency, duplicatesConcurrencyFlag, "n", 1, "分N组并发计算指纹")
+	duplicatesCmd.Flags().BoolVarP(&duplicatesClassify, duplicatesClassifyFlag, "c", false, "是否把重复的文件移动到repeated_files目录")
+}
@@ -20,7 +20,7 @@ import (
 
 	"invtools/common"
 	"invtools/pkg/detective"
-	"invtools/pkg/detective/legoland"
+	"invtools/pkg/detective/rules"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -58,8 +58,12 @@ var legolandCmd = &cobra.Command{
 			valid = args[2]
 		}
 
-		// 执行Legoland解析
-		detectiveLeGoland := legoland.NewDetective(dir, activity, valid)
+		// 执行Legoland解析, 使用内置的legoland规则
+		vars := map[string]string{
+			common.LeGolandFlagActivity: activity,
+			common.LeGolandFlagValid:    valid,
+		}
+		detectiveLeGoland := rules.NewDetective(dir, rules.BuiltinLegoland, vars, viper.GetBool(common.LeGolandFlagClassify))
 		err := detective.Detect(detectiveLeGoland)
 		if err != nil {
 			fmt.Println("Legoland detect failed. err:", err)
@@ -116,5 +120,5 @@ func init() {
 	legolandCmd.Flags().BoolP("classify", "c", false, "Whether classify unexpected file together")
 	viper.BindPFlag(common.LeGolandFlagClassify, legolandCmd.Flags().Lookup(common.LeGolandFlagClassify))
 
-	viper.Set(common.RunningDetective, legoland.DetectiveNickName)
+	viper.Set(common.RunningDetective, rules.BuiltinLegoland)
 }
@@ -0,0 +1,101 @@
+// Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"invtools/common"
+	"invtools/pkg/detective"
+	"invtools/pkg/detective/rules"
+
+	. "github.com/logrusorgru/aurora"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var rulesCmdExample = fmt.Sprintf("%s\n%s\n",
+	fmt.Sprintf(`%s pdfdetect rules /path/to/your/directory --rules rules.yaml -c`, appName),
+	fmt.Sprintf(`%s pdfdetect rules /path/to/your/directory --rules legoland --var activity="expected act name" --var valid="expected effective date"`, appName),
+)
+
+// rulesCmd represents the rules command
+var rulesCmd = &cobra.Command{
+	Use:     "rules",
+	Short:   "按规则文件检测pdf.",
+	Example: rulesCmdExample,
+	Long: `
+按规则文件检测目录下的pdf, 每条规则是对页面文字的text/regexp/date/barcode断言,
+barcode_text规则用于校验二维码/条形码的内容与页面文字(比如预订号)是否一致.
+规则中可以使用变量 ${name}, 通过 --var name=value 指定.
+内置规则: legoland`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := common.CurrentDir
+		if len(args) > 0 {
+			dir = args[0]
+		}
+		if !path.IsAbs(dir) {
+			p, err := filepath.Abs(dir)
+			if err != nil {
+				fmt.Println(Magenta("convert dir to abs failed"))
+				os.Exit(1)
+			}
+			dir = p
+		}
+
+		if rulesFile == "" {
+			fmt.Println(Magenta("Please specify the rules file with --rules."))
+			os.Exit(1)
+		}
+
+		rs, err := rules.LoadRuleSet(rulesFile)
+		if err != nil {
+			fmt.Println(Magenta(fmt.Sprintf("Load rules failed, file: %s ,err:%+v", rulesFile, err)))
+			os.Exit(1)
+		}
+		viper.Set(common.RunningDetective, rs.Name)
+
+		err = detective.Detect(rules.NewDetective(dir, rulesFile, rulesVars, rulesClassify))
+		if err != nil {
+			fmt.Println("Rules detect failed. err:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var (
+	// 规则文件, 或内置规则的名字
+	rulesFile     string
+	rulesFileFlag = "rules"
+
+	// 规则中的变量
+	rulesVars     map[string]string
+	rulesVarsFlag = "var"
+
+	// 是否归类重复/检测失败的文件
+	rulesClassify     bool
+	rulesClassifyFlag = "classify"
+)
+
+func init() {
+	pdfdetectCmd.AddCommand(rulesCmd)
+
+	rulesCmd.Flags().StringVarP(&rulesFile, rulesFileFlag, "r", "", "规则文件(yaml/json), 或内置规则的名字")
+	rulesCmd.Flags().StringToStringVar(&rulesVars, rulesVarsFlag, nil, "规则中的变量, 比如 --var activity=xxx")
+	rulesCmd.Flags().BoolVarP(&rulesClassify, rulesClassifyFlag, "c", false, "是否把重复/检测失败的文件归类到单独的目录")
+}
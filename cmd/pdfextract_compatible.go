@@ -22,13 +22,15 @@ import (
 
 	"invtools/common"
 	"invtools/pkg/pdfextract/compatible"
+	"invtools/pkg/util"
 
 	. "github.com/logrusorgru/aurora"
 	"github.com/spf13/cobra"
 )
 
-var compatibleCmdExample = fmt.Sprintf("%s\n",
+var compatibleCmdExample = fmt.Sprintf("%s\n%s\n",
 	fmt.Sprintf(`%s pdfextract compatible /input/directory output.csv coord_name="coord1 coord2 coord3 coord4" reg_name="regexp" --with_coordinate=true --with_ocr=true`, appName),
+	fmt.Sprintf(`%s pdfextract compatible --with_cnf template.json --explain sample.pdf`, appName),
 )
 
 // compatibleCmd represents the compatible command
@@ -39,6 +41,11 @@ var compatibleCmd = &cobra.Command{
 	Long:    `兼容模式，允许使用坐标和OCR的方式同时解析PDF文件`,
 	Run: func(cmd *cobra.Command, args []string) {
 		//fmt.Println("compatible called")
+		if explain != "" {
+			runExplain()
+			return
+		}
+
 		if len(args) < 2 {
 			fmt.Println(Magenta("Please input 3 arguments at least."))
 			//cmd.Help();
@@ -118,8 +125,31 @@ var (
 
 	debug bool
 	debugFlag = "with_debug"
+
+	// 使用配置文件解析单个pdf, 生成标注后的pdf
+	explain     string
+	explainFlag = "explain"
 )
 
+func runExplain() {
+	input := explain
+	if !path.IsAbs(input) {
+		p, err := filepath.Abs(input)
+		if err != nil {
+			fmt.Println(Magenta("convert explain file to abs failed"))
+			os.Exit(1)
+		}
+		input = p
+	}
+	output := path.Join(common.CurrentDir, fmt.Sprintf("%s_explain%s", util.GetPureFileName(input), common.ExtPDF))
+
+	err := compatible.NewExtractor("", "", 1, maxReadPage, false, false, nil, cnf, debug).Explain(input, output)
+	if err != nil {
+		fmt.Println(Magenta(fmt.Sprintf("Explain pdf failed, input: %s ,err:%+v", input, err)))
+		os.Exit(1)
+	}
+}
+
 func init() {
 	pdfextractCmd.AddCommand(compatibleCmd)
 
@@ -146,4 +176,6 @@ func init() {
 	compatibleCmd.Flags().StringVarP(&cnf, cnfFlag, "c", "", "配置文件路径")
 
 	compatibleCmd.Flags().BoolVarP(&debug, debugFlag, "d", false, "是否开启debug")
+
+	compatibleCmd.Flags().StringVar(&explain, explainFlag, "", "使用配置文件解析单个pdf, 打印每个字段的解析过程并生成标注后的pdf")
 }
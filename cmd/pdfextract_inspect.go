@@ -0,0 +1,95 @@
+// Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"invtools/common"
+	"invtools/pkg/pdfextract/compatible"
+	"invtools/pkg/util"
+
+	. "github.com/logrusorgru/aurora"
+	"github.com/spf13/cobra"
+)
+
+var inspectCmdExample = fmt.Sprintf("%s\n%s\n",
+	fmt.Sprintf(`%s pdfextract inspect sample.pdf`, appName),
+	fmt.Sprintf(`%s pdfextract inspect sample.pdf /output/directory --format=yaml --grid_step=20`, appName),
+)
+
+// inspectCmd represents the inspect command
+var inspectCmd = &cobra.Command{
+	Use:     "inspect",
+	Short:   "查看PDF坐标, 生成配置模板",
+	Example: inspectCmdExample,
+	Long: `渲染PDF每一页为带坐标网格的图片, 导出每个单词的坐标,
+并根据 "标签: 值" 生成一份可供compatible --with_cnf使用的初始配置模板`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			fmt.Println(Magenta("Please input 1 argument at least."))
+			os.Exit(1)
+		}
+
+		input := args[0]
+		if !path.IsAbs(input) {
+			p, err := filepath.Abs(input)
+			if err != nil {
+				fmt.Println(Magenta("convert input to abs failed"))
+				os.Exit(1)
+			}
+			input = p
+		}
+
+		outputDir := path.Join(common.CurrentDir, fmt.Sprintf("%s_inspect", util.GetPureFileName(input)))
+		if len(args) > 1 {
+			outputDir = args[1]
+		}
+		if !path.IsAbs(outputDir) {
+			p, err := filepath.Abs(outputDir)
+			if err != nil {
+				fmt.Println(Magenta("convert outputDir to abs failed"))
+				os.Exit(1)
+			}
+			outputDir = p
+		}
+
+		err := compatible.NewInspector(input, outputDir, inspectFormat, inspectGridStep).Do()
+		if err != nil {
+			fmt.Println(Magenta(fmt.Sprintf("Inspect pdf failed, input: %s ,err:%+v", input, err)))
+			os.Exit(1)
+		}
+	},
+}
+
+var (
+	// 模板格式
+	inspectFormat     string
+	inspectFormatFlag = "format"
+
+	// 网格间距
+	inspectGridStep     float64
+	inspectGridStepFlag = "grid_step"
+)
+
+func init() {
+	pdfextractCmd.AddCommand(inspectCmd)
+
+	inspectCmd.Flags().StringVarP(&inspectFormat, inspectFormatFlag, "f", compatible.TemplateFormatJSON, "配置模板格式, json或yaml")
+	inspectCmd.Flags().Float64VarP(&inspectGridStep, inspectGridStepFlag, "g", compatible.DefaultGridStep, "网格间距, 单位point")
+}
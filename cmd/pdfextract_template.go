@@ -0,0 +1,106 @@
+// Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"invtools/common"
+	"invtools/pkg/pdfextract/compatible"
+
+	. "github.com/logrusorgru/aurora"
+	"github.com/spf13/cobra"
+)
+
+// templateCmd represents the template command
+var templateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "配置模板相关工具",
+	Long:  `配置模板相关工具, 比如使用期望值文件对模板做回归测试`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := cmd.Help(); err != nil {
+			fmt.Printf("call template help failed")
+		}
+	},
+}
+
+var templateTestCmdExample = fmt.Sprintf("%s\n",
+	fmt.Sprintf(`%s pdfextract template test template.json /input/directory -n 4`, appName),
+)
+
+// templateTestCmd represents the template test command
+var templateTestCmd = &cobra.Command{
+	Use:     "test",
+	Short:   "使用期望值文件对配置模板做回归测试",
+	Example: templateTestCmdExample,
+	Long: fmt.Sprintf(`解析目录下所有带期望值文件的pdf, 逐个字段与期望值对比, 并给出precision/recall.
+期望值文件与pdf放在同一目录, 命名为 voucher.pdf%s, 内容为 {"field_name": "value"}.
+有任意字段不符合期望值时以非0状态码退出`, compatible.ExpectedFileSuffix),
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 2 {
+			fmt.Println(Magenta("Please input 2 arguments at least."))
+			os.Exit(1)
+		}
+
+		var (
+			template = args[0]
+			inputDir = args[1]
+		)
+
+		if inputDir == "." || inputDir == "./" {
+			inputDir = common.CurrentDir
+		}
+		for _, p := range []*string{&template, &inputDir} {
+			if path.IsAbs(*p) {
+				continue
+			}
+			abs, err := filepath.Abs(*p)
+			if err != nil {
+				fmt.Println(Magenta(fmt.Sprintf("convert %s to abs failed", *p)))
+				os.Exit(1)
+			}
+			*p = abs
+		}
+
+		report, err := compatible.NewTemplateTester(template, inputDir, templateTestConcurrency).Run()
+		if err != nil {
+			fmt.Println(Magenta(fmt.Sprintf("Template test failed, template: %s ,err:%+v", template, err)))
+			os.Exit(1)
+		}
+
+		report.Print(inputDir)
+		if report.Regressed() {
+			fmt.Println(Red(fmt.Sprintf("%d个字段不符合期望值", report.Failed)))
+			os.Exit(1)
+		}
+		fmt.Println(Green("所有字段都符合期望值"))
+	},
+}
+
+var (
+	// 并发执行的数量
+	templateTestConcurrency     int
+	templateTestConcurrencyFlag = "concurrency"
+)
+
+func init() {
+	pdfextractCmd.AddCommand(templateCmd)
+	templateCmd.AddCommand(templateTestCmd)
+
+	templateTestCmd.Flags().IntVarP(&templateTestConcurrency, templateTestConcurrencyFlag, "n", 1, "分N组并发解析")
+}
@@ -0,0 +1,134 @@
+// Copyright © 2019 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"invtools/common"
+	"invtools/pkg/pdfmerge"
+
+	"invtools/utils"
+
+	"github.com/logrusorgru/aurora"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	pdfmergeCmdExample = fmt.Sprintf("%s\n%s\n%s\n%s\n%s\n",
+		fmt.Sprintf(`%s pdfmerge /input/directory`, appName),
+		fmt.Sprintf(`%s pdfmerge voucher_1.pdf voucher_2.pdf terms.pdf -o /output/booking.pdf --bookmarks`, appName),
+		fmt.Sprintf(`%s pdfmerge /input/files.txt -o /output/booking.pdf -p=password`, appName),
+		fmt.Sprintf(`%s pdfmerge /input/directory -o /output/directory --group_regexp="^(\w+)_guest"`, appName),
+		fmt.Sprintf(`%s pdfmerge /input/directory -o /output/directory --group_csv=/input/mapping.csv`, appName),
+	)
+)
+
+// pdfmergeCmd represents the pdfmerge command
+var pdfmergeCmd = &cobra.Command{
+	Use:   "pdfmerge",
+	Short: "Merge PDF files.",
+	Long: `Merge PDF files.
+
+The inputs are merged in the given order. An input can be a pdf file,
+a directory (pdf files sorted by natural order, e.g. 2.pdf before 10.pdf)
+or a .txt file list with one path per line.
+
+Files can be grouped into several output files by a regexp on the file
+name (--group_regexp, the first group is the key) or by a csv mapping
+file with "file" and "group" columns (--group_csv).`,
+	Example: pdfmergeCmdExample,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			fmt.Println(aurora.Magenta("至少输入一个参数，比如pdf文件或者pdf文件所在目录"))
+			os.Exit(1)
+		}
+
+		var inputs []string
+		for _, input := range args {
+			if !path.IsAbs(input) {
+				p, err := filepath.Abs(input)
+				if err != nil {
+					fmt.Println(aurora.Magenta("convert input path to abs path failed, please contact Rick~"))
+					os.Exit(1)
+				}
+				input = p
+			}
+			inputs = append(inputs, input)
+		}
+
+		outputPath := mergeOutput
+		if outputPath == "" {
+			outputPath = path.Join(viper.GetString(common.CurrentDir), fmt.Sprintf("merged_pdfs_%s", time.Now().In(utils.LocationCST).Format("2006_01_02_15_04_05")))
+		}
+		if !path.IsAbs(outputPath) {
+			if p, err := filepath.Abs(outputPath); err != nil {
+				fmt.Println(aurora.Magenta("convert output path to abs path failed, please contact Rick~"))
+				os.Exit(1)
+			} else {
+				outputPath = p
+			}
+		}
+
+		opts := &pdfmerge.Options{
+			Password:  mergePassword,
+			GroupCsv:  mergeGroupCsv,
+			Bookmarks: mergeBookmarks,
+		}
+		if mergeGroupRegexp != "" {
+			re, err := regexp.Compile(mergeGroupRegexp)
+			if err != nil {
+				fmt.Println(aurora.Magenta("group_regexp正则不合法，err:"), err)
+				os.Exit(1)
+			}
+			opts.GroupRe = re
+		}
+
+		err := pdfmerge.NewPdfMerger(inputs, outputPath, opts).Do()
+		if err != nil {
+			fmt.Println(aurora.Magenta("合并pdf出现错误，err:"), err)
+			os.Exit(1)
+		}
+	},
+}
+
+var (
+	mergeOutput          string
+	mergeOutputFlag      = "output"
+	mergePassword        string
+	mergePasswordFlag    = "password"
+	mergeGroupRegexp     string
+	mergeGroupRegexpFlag = "group_regexp"
+	mergeGroupCsv        string
+	mergeGroupCsvFlag    = "group_csv"
+	mergeBookmarks       bool
+	mergeBookmarksFlag   = "bookmarks"
+)
+
+func init() {
+	rootCmd.AddCommand(pdfmergeCmd)
+
+	pdfmergeCmd.Flags().StringVarP(&mergeOutput, mergeOutputFlag, "o", "", "输出路径, 不分组时以.pdf结尾为输出文件, 否则为目录")
+	pdfmergeCmd.Flags().StringVarP(&mergePassword, mergePasswordFlag, "p", "", "PDF文件密码")
+	pdfmergeCmd.Flags().StringVar(&mergeGroupRegexp, mergeGroupRegexpFlag, "", "按文件名匹配的正则分组, 有分组时取第一个分组, 每个分组合并成一个pdf")
+	pdfmergeCmd.Flags().StringVar(&mergeGroupCsv, mergeGroupCsvFlag, "", "按csv映射文件分组, 表头需要包含file和group列")
+	pdfmergeCmd.Flags().BoolVar(&mergeBookmarks, mergeBookmarksFlag, false, "每个输入文件生成一个书签, 标题为文件名")
+}
@@ -0,0 +1,185 @@
+// Copyright © 2019 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"invtools/common"
+	"invtools/pkg/pdfpage"
+
+	"invtools/utils"
+
+	"github.com/logrusorgru/aurora"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	pdfpageCmdExample = fmt.Sprintf("%s\n%s\n%s\n%s\n%s\n%s\n",
+		fmt.Sprintf(`%s pdfpage rotate /input/directory /output/directory --angle=90 --pages="1,3-end"`, appName),
+		fmt.Sprintf(`%s pdfpage delete /input/directory /output/directory --pages="2,5-end"`, appName),
+		fmt.Sprintf(`%s pdfpage delete /input/voucher.pdf --blank -p=password`, appName),
+		fmt.Sprintf(`%s pdfpage reorder /input/directory /output/directory --order="3,1-2"`, appName),
+		fmt.Sprintf(`%s pdfpage crop /input/directory /output/directory --margins="10,15" -c=2`, appName),
+		fmt.Sprintf(`%s pdfpage nup /input/directory /output/directory -n=4`, appName),
+	)
+)
+
+// pdfpageCmd represents the pdfpage command
+var pdfpageCmd = &cobra.Command{
+	Use:   "pdfpage",
+	Short: "Rotate, delete, reorder, crop and n-up PDF pages.",
+	Long: `Rotate, delete, reorder, crop and n-up PDF pages.
+
+Every subcommand takes a pdf file or a directory of pdf files and writes
+the processed files with the same names into the output directory.
+
+Page ranges are comma separated, e.g. 1-3,5,8-end.`,
+	Example: pdfpageCmdExample,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := cmd.Help(); err != nil {
+			fmt.Printf("call pdfpage help failed")
+		}
+	},
+}
+
+var pdfpageRotateCmd = &cobra.Command{
+	Use:   "rotate input [output]",
+	Short: "Rotate pages clockwise by a multiple of 90 degrees.",
+	Run: func(cmd *cobra.Command, args []string) {
+		runPdfPage(args, &pdfpage.RotateOp{Angle: pageAngle, Pages: pagePages})
+	},
+}
+
+var pdfpageDeleteCmd = &cobra.Command{
+	Use:   "delete input [output]",
+	Short: "Delete pages by range and/or blank pages.",
+	Run: func(cmd *cobra.Command, args []string) {
+		runPdfPage(args, &pdfpage.DeleteOp{Pages: pagePages, Blank: pageBlank})
+	},
+}
+
+var pdfpageReorderCmd = &cobra.Command{
+	Use:   "reorder input [output]",
+	Short: "Reorder pages, pages not listed are dropped.",
+	Run: func(cmd *cobra.Command, args []string) {
+		runPdfPage(args, &pdfpage.ReorderOp{Order: pageOrder})
+	},
+}
+
+var pdfpageCropCmd = &cobra.Command{
+	Use:   "crop input [output]",
+	Short: "Crop pages by margins in millimeters.",
+	Run: func(cmd *cobra.Command, args []string) {
+		runPdfPage(args, &pdfpage.CropOp{Margins: pageMargins, Pages: pagePages})
+	},
+}
+
+var pdfpageNupCmd = &cobra.Command{
+	Use:   "nup input [output]",
+	Short: "Put 2 or 4 pages on one sheet for printing.",
+	Run: func(cmd *cobra.Command, args []string) {
+		runPdfPage(args, &pdfpage.NupOp{N: pageNup})
+	},
+}
+
+// runPdfPage 解析输入输出路径后执行页面操作, 没有指定输出目录时在当前目录下新建
+func runPdfPage(args []string, op pdfpage.Operation) {
+	var (
+		inputPath, outputPath string
+	)
+
+	if len(args) < 1 {
+		fmt.Println(aurora.Magenta("至少输入一个参数，比如pdf文件或者pdf文件所在目录"))
+		os.Exit(1)
+	}
+	inputPath = args[0]
+
+	if len(args) == 2 {
+		outputPath = args[1]
+	} else {
+		outputPath = path.Join(viper.GetString(common.CurrentDir), fmt.Sprintf("paged_pdfs_%s", time.Now().In(utils.LocationCST).Format("2006_01_02_15_04_05")))
+	}
+
+	if !path.IsAbs(inputPath) {
+		if p, err := filepath.Abs(inputPath); err != nil {
+			fmt.Println(aurora.Magenta("convert input path to abs path failed, please contact Rick~"))
+			os.Exit(1)
+		} else {
+			inputPath = p
+		}
+	}
+
+	if !path.IsAbs(outputPath) {
+		if p, err := filepath.Abs(outputPath); err != nil {
+			fmt.Println(aurora.Magenta("convert output directory to abs directory failed, please contact Rick~"))
+			os.Exit(1)
+		} else {
+			outputPath = p
+		}
+	}
+
+	err := pdfpage.NewPdfPageProcessor(inputPath, outputPath, pagePassword, op, pageConcurrency).Do()
+	if err != nil {
+		fmt.Println(aurora.Magenta("处理pdf页面出现错误，err:"), err)
+		os.Exit(1)
+	}
+}
+
+var (
+	pagePassword        string
+	pagePasswordFlag    = "password"
+	pageConcurrency     int
+	pageConcurrencyFlag = "concurrency"
+
+	pagePages       string
+	pagePagesFlag   = "pages"
+	pageAngle       int
+	pageAngleFlag   = "angle"
+	pageBlank       bool
+	pageBlankFlag   = "blank"
+	pageOrder       string
+	pageOrderFlag   = "order"
+	pageMargins     string
+	pageMarginsFlag = "margins"
+	pageNup         int
+	pageNupFlag     = "per_sheet"
+)
+
+func init() {
+	rootCmd.AddCommand(pdfpageCmd)
+	pdfpageCmd.AddCommand(pdfpageRotateCmd, pdfpageDeleteCmd, pdfpageReorderCmd, pdfpageCropCmd, pdfpageNupCmd)
+
+	pdfpageCmd.PersistentFlags().StringVarP(&pagePassword, pagePasswordFlag, "p", "", "PDF文件密码")
+	pdfpageCmd.PersistentFlags().IntVarP(&pageConcurrency, pageConcurrencyFlag, "c", 1, "分N组并发处理")
+
+	pdfpageRotateCmd.Flags().IntVar(&pageAngle, pageAngleFlag, 90, "顺时针旋转的角度, 90的倍数, 负数为逆时针")
+	pdfpageRotateCmd.Flags().StringVar(&pagePages, pagePagesFlag, "", "需要旋转的页码范围, 为空时旋转所有页面, 例如: 1,3-end")
+
+	pdfpageDeleteCmd.Flags().StringVar(&pagePages, pagePagesFlag, "", "需要删除的页码范围, 例如: 2,5-end")
+	pdfpageDeleteCmd.Flags().BoolVar(&pageBlank, pageBlankFlag, false, "删除没有文字也没有图片的空白页")
+
+	pdfpageReorderCmd.Flags().StringVar(&pageOrder, pageOrderFlag, "", "新的页面顺序, 没有列出的页面不写入, 例如: 3,1-2; reverse为倒序")
+
+	pdfpageCropCmd.Flags().StringVar(&pageMargins, pageMarginsFlag, "", "裁掉的边距, 单位mm, 格式: 全部/上下,左右/上,右,下,左, 例如: 10,15")
+	pdfpageCropCmd.Flags().StringVar(&pagePages, pagePagesFlag, "", "需要裁剪的页码范围, 为空时裁剪所有页面")
+
+	pdfpageNupCmd.Flags().IntVarP(&pageNup, pageNupFlag, "n", 2, "每张纸上的页数: 2或4")
+}
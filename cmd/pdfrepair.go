@@ -41,7 +41,12 @@ var pdfrepairCmd = &cobra.Command{
 	Example: pdfrepairCmdExample,
 	Use:     "pdfrepair",
 	Short:   "Repair PDF files",
-	Long:    `PDF修复,支持批量修复受损PDF文件`,
+	Long: `PDF修复,支持批量修复受损PDF文件
+
+修复前后分别解析pdf、统计页数并提取文字, 修复完成后输出每个文件的结果:
+  healthy        原文件可以正常解析, mupdf也没有警告
+  repaired       原文件解析失败或者mupdf有警告, 修复后的文件可以正常解析
+  unrecoverable  mupdf修复失败或者修复后的文件仍然无法解析, 不保留修复后的文件`,
 	Run: func(cmd *cobra.Command, args []string) {
 		var (
 			inputPath, outputPath string
@@ -0,0 +1,168 @@
+// Copyright © 2019 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"invtools/common"
+	"invtools/pkg/pdfsecure"
+
+	"invtools/utils"
+
+	"github.com/logrusorgru/aurora"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	pdfsecureCmdExample = fmt.Sprintf("%s\n%s\n%s\n%s\n",
+		fmt.Sprintf(`%s pdfsecure status /input/directory --password_list=/input/passwords.txt`, appName),
+		fmt.Sprintf(`%s pdfsecure decrypt /input/directory /output/directory --password_list=/input/passwords.txt`, appName),
+		fmt.Sprintf(`%s pdfsecure decrypt /input/directory /output/directory --password_csv=/input/passwords.csv -c=2`, appName),
+		fmt.Sprintf(`%s pdfsecure encrypt /input/directory /output/directory --owner_password=secret --allow_print`, appName),
+	)
+)
+
+// pdfsecureCmd represents the pdfsecure command
+var pdfsecureCmd = &cobra.Command{
+	Use:   "pdfsecure",
+	Short: "Check, remove or apply PDF passwords and permissions.",
+	Long: `Check, remove or apply PDF passwords and permissions.
+
+Every subcommand takes a pdf file or a directory of pdf files and reports
+the encryption status of each file. decrypt and encrypt write the files
+with the same names into the output directory.
+
+Encrypted inputs are opened with the password from --password_csv
+(columns: file,password), then -p, then every password in --password_list,
+then an empty password.`,
+	Example: pdfsecureCmdExample,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := cmd.Help(); err != nil {
+			fmt.Printf("call pdfsecure help failed")
+		}
+	},
+}
+
+var pdfsecureStatusCmd = &cobra.Command{
+	Use:   "status input",
+	Short: "Report the encryption status and permissions of each file.",
+	Run: func(cmd *cobra.Command, args []string) {
+		runPdfSecure(args, pdfsecure.ActionStatus)
+	},
+}
+
+var pdfsecureDecryptCmd = &cobra.Command{
+	Use:   "decrypt input [output]",
+	Short: "Remove passwords and permission restrictions.",
+	Run: func(cmd *cobra.Command, args []string) {
+		runPdfSecure(args, pdfsecure.ActionDecrypt)
+	},
+}
+
+var pdfsecureEncryptCmd = &cobra.Command{
+	Use:   "encrypt input [output]",
+	Short: "Encrypt with AES-256 and set owner/user passwords and permissions.",
+	Run: func(cmd *cobra.Command, args []string) {
+		runPdfSecure(args, pdfsecure.ActionEncrypt)
+	},
+}
+
+// runPdfSecure 解析输入输出路径后执行, 没有指定输出目录时在当前目录下新建
+func runPdfSecure(args []string, action string) {
+	var (
+		inputPath, outputPath string
+	)
+
+	if len(args) < 1 {
+		fmt.Println(aurora.Magenta("至少输入一个参数，比如pdf文件或者pdf文件所在目录"))
+		os.Exit(1)
+	}
+	inputPath = args[0]
+
+	if len(args) == 2 {
+		outputPath = args[1]
+	} else {
+		outputPath = path.Join(viper.GetString(common.CurrentDir), fmt.Sprintf("%sed_pdfs_%s", action, time.Now().In(utils.LocationCST).Format("2006_01_02_15_04_05")))
+	}
+
+	if !path.IsAbs(inputPath) {
+		if p, err := filepath.Abs(inputPath); err != nil {
+			fmt.Println(aurora.Magenta("convert input path to abs path failed, please contact Rick~"))
+			os.Exit(1)
+		} else {
+			inputPath = p
+		}
+	}
+
+	if !path.IsAbs(outputPath) {
+		if p, err := filepath.Abs(outputPath); err != nil {
+			fmt.Println(aurora.Magenta("convert output directory to abs directory failed, please contact Rick~"))
+			os.Exit(1)
+		} else {
+			outputPath = p
+		}
+	}
+
+	opts := &pdfsecure.Options{
+		Password:      securePassword,
+		PasswordCsv:   securePasswordCsv,
+		UserPassword:  secureUserPassword,
+		OwnerPassword: secureOwnerPassword,
+		AllowPrint:    secureAllowPrint,
+		AllowCopy:     secureAllowCopy,
+	}
+	err := pdfsecure.NewPdfSecurer(inputPath, outputPath, action, opts, secureConcurrency).Do()
+	if err != nil {
+		fmt.Println(aurora.Magenta("处理pdf加密出现错误，err:"), err)
+		os.Exit(1)
+	}
+}
+
+var (
+	securePassword          string
+	securePasswordFlag      = "password"
+	securePasswordCsv       string
+	securePasswordCsvFlag   = "password_csv"
+	secureConcurrency       int
+	secureConcurrencyFlag   = "concurrency"
+	secureUserPassword      string
+	secureUserPasswordFlag  = "user_password"
+	secureOwnerPassword     string
+	secureOwnerPasswordFlag = "owner_password"
+	secureAllowPrint        bool
+	secureAllowPrintFlag    = "allow_print"
+	secureAllowCopy         bool
+	secureAllowCopyFlag     = "allow_copy"
+)
+
+func init() {
+	rootCmd.AddCommand(pdfsecureCmd)
+	pdfsecureCmd.AddCommand(pdfsecureStatusCmd, pdfsecureDecryptCmd, pdfsecureEncryptCmd)
+
+	pdfsecureCmd.PersistentFlags().StringVarP(&securePassword, securePasswordFlag, "p", "", "PDF文件密码, 在密码列表之前尝试")
+	pdfsecureCmd.PersistentFlags().StringVar(&securePasswordCsv, securePasswordCsvFlag, "", "每个文件的密码, 表头需要包含file和password列")
+	pdfsecureCmd.PersistentFlags().IntVarP(&secureConcurrency, secureConcurrencyFlag, "c", 1, "分N组并发处理")
+
+	pdfsecureEncryptCmd.Flags().StringVar(&secureUserPassword, secureUserPasswordFlag, "", "打开文件的密码, 为空时不需要密码就能打开, 但是受权限限制")
+	pdfsecureEncryptCmd.Flags().StringVar(&secureOwnerPassword, secureOwnerPasswordFlag, "", "修改权限的密码, 必填")
+	pdfsecureEncryptCmd.Flags().BoolVar(&secureAllowPrint, secureAllowPrintFlag, false, "允许打印")
+	pdfsecureEncryptCmd.Flags().BoolVar(&secureAllowCopy, secureAllowCopyFlag, false, "允许复制文字和图片")
+}
@@ -19,6 +19,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
 	"time"
 
 	"invtools/common"
@@ -32,10 +33,15 @@ import (
 )
 
 var (
-	pdfsplitCmdExample = fmt.Sprintf("%s\n%s\n%s\n",
+	pdfsplitCmdExample = fmt.Sprintf("%s\n%s\n%s\n%s\n%s\n%s\n%s\n%s\n",
 		fmt.Sprintf(`%s pdfsplit /input/directory`, appName),
 		fmt.Sprintf(`%s pdfsplit /input/directory /output/directory`, appName),
 		fmt.Sprintf(`%s pdfsplit /input/directory /output/directory -p=password -n=2`, appName),
+		fmt.Sprintf(`%s pdfsplit /input/directory /output/directory -m=ranges --ranges="1-3,4,5-end"`, appName),
+		fmt.Sprintf(`%s pdfsplit /input/directory /output/directory -m=bookmark --bookmark_level=1`, appName),
+		fmt.Sprintf(`%s pdfsplit /input/directory /output/directory -m=blank`, appName),
+		fmt.Sprintf(`%s pdfsplit /input/directory /output/directory -m=regexp --pattern="Booking Reference"`, appName),
+		fmt.Sprintf(`%s pdfsplit /input/directory /output/directory -m=code --code_type=qrcode --code_pattern="guest=(\w+)"`, appName),
 	)
 )
 
@@ -45,7 +51,16 @@ var pdfsplitCmd = &cobra.Command{
 	Short: "Split PDF files.",
 	Long: `Split PDF files.
 
-The command is used to extract one or more page ranges from pdf files.`,
+The command is used to extract one or more page ranges from pdf files.
+
+Split modes:
+  perpage   every N pages (-n), the remaining pages go into the last file
+  ranges    one file per comma separated range, e.g. 1-3,4,5-end
+  bookmark  one file per bookmark of the given level (--bookmark_level)
+  blank     blank pages separate the files and are dropped
+  regexp    a new file starts on every page whose text matches --pattern
+  code      consecutive pages with the same qrcode/barcode go into one file,
+            named by the decoded value or the first group of --code_pattern`,
 	Example: pdfsplitCmdExample,
 	Run: func(cmd *cobra.Command, args []string) {
 		var (
@@ -88,7 +103,32 @@ The command is used to extract one or more page ranges from pdf files.`,
 		//fmt.Println("[debug] perpage:", perPage)
 		//fmt.Println("[debug] concurrency:", concurrency)
 
-		err := pdfsplit.NewPdfSplitter(inputPath, outputPath, password, perPage, concurrency).Do()
+		spec := &pdfsplit.SplitSpec{
+			Mode:    splitMode,
+			PerPage: perPage,
+			Ranges:  splitRanges,
+			Level:   bookmarkLevel,
+
+			CodeType: codeType,
+		}
+		if splitPattern != "" {
+			re, err := regexp.Compile(splitPattern)
+			if err != nil {
+				fmt.Println(aurora.Magenta("正则不合法，err:"), err)
+				os.Exit(1)
+			}
+			spec.Pattern = re
+		}
+		if codePattern != "" {
+			re, err := regexp.Compile(codePattern)
+			if err != nil {
+				fmt.Println(aurora.Magenta("code_pattern正则不合法，err:"), err)
+				os.Exit(1)
+			}
+			spec.CodePattern = re
+		}
+
+		err := pdfsplit.NewPdfSplitter(inputPath, outputPath, password, spec, concurrency).Do()
 		if err != nil {
 			fmt.Println(aurora.Magenta("拆分pdf出现错误，err:"), err)
 			os.Exit(1)
@@ -104,6 +144,19 @@ var (
 	perPageFlag     = "perpage"
 	concurrency     int
 	concurrencyFlag = "split_concurrency"
+
+	splitMode         string
+	splitModeFlag     = "mode"
+	splitRanges       string
+	splitRangesFlag   = "ranges"
+	bookmarkLevel     int
+	bookmarkLevelFlag = "bookmark_level"
+	splitPattern      string
+	splitPatternFlag  = "pattern"
+	codeType          string
+	codeTypeFlag      = "code_type"
+	codePattern       string
+	codePatternFlag   = "code_pattern"
 )
 
 func init() {
@@ -122,4 +175,10 @@ func init() {
 	pdfsplitCmd.Flags().StringVarP(&password, passwordFlag, "p", "", "PDF文件密码")
 	pdfsplitCmd.Flags().IntVarP(&perPage, perPageFlag, "n", 1, "每N页做一次拆分")
 	pdfsplitCmd.Flags().IntVarP(&concurrency, concurrencyFlag, "c", 1, "分N组并发拆分")
+	pdfsplitCmd.Flags().StringVarP(&splitMode, splitModeFlag, "m", pdfsplit.ModePerPage, "拆分方式: perpage/ranges/bookmark/blank/regexp/code")
+	pdfsplitCmd.Flags().StringVar(&splitRanges, splitRangesFlag, "", "按页码范围拆分时的页码范围, 每一段一个文件, 例如: 1-3,4,5-end")
+	pdfsplitCmd.Flags().IntVar(&bookmarkLevel, bookmarkLevelFlag, 1, "按书签拆分时使用第几级书签")
+	pdfsplitCmd.Flags().StringVar(&splitPattern, splitPatternFlag, "", "按正则拆分时, 页面文字匹配该正则时开始一个新文件, 例如: Booking Reference")
+	pdfsplitCmd.Flags().StringVar(&codeType, codeTypeFlag, common.CodeTypeQRCode, "按二维码/条形码拆分时的code类型: qrcode/barcode128")
+	pdfsplitCmd.Flags().StringVar(&codePattern, codePatternFlag, "", "按二维码/条形码拆分时, 从识别到的内容中提取文件名的正则, 有分组时取第一个分组")
 }
@@ -0,0 +1,170 @@
+// Copyright © 2019 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"invtools/common"
+	"invtools/pkg/pdfstamp"
+
+	"invtools/utils"
+
+	"github.com/logrusorgru/aurora"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	pdfstampCmdExample = fmt.Sprintf("%s\n%s\n%s\n%s\n",
+		fmt.Sprintf(`%s pdfstamp /input/directory /output/directory --value=USED --anchor=center --rotation=45 --opacity=0.4 --font_size=72`, appName),
+		fmt.Sprintf(`%s pdfstamp /input/directory /output/directory --value="NO.{{.order_no}}" --values_csv=/input/orders.csv --anchor=top-right -x=10 -y=10`, appName),
+		fmt.Sprintf(`%s pdfstamp /input/directory /output/directory --type=qrcode --value="{{.url}}" --values_csv=/input/urls.csv --anchor=bottom-right -x=10 -y=10 --width=30 --pages=1`, appName),
+		fmt.Sprintf(`%s pdfstamp /input/voucher.pdf --type=image --value=/input/seal.png --anchor=bottom-left -x=20 -y=20 --width=40`, appName),
+	)
+)
+
+// pdfstampCmd represents the pdfstamp command
+var pdfstampCmd = &cobra.Command{
+	Use:   "pdfstamp input [output]",
+	Short: "Stamp text, images, QR codes or barcodes on PDF pages.",
+	Long: `Stamp text, images, QR codes or barcodes on PDF pages.
+
+The stamp is placed at an anchor of the page (top-left, top, top-right, left,
+center, right, bottom-left, bottom, bottom-right) and moved by x/y millimeters
+from the anchor's edges. It can be made transparent and rotated around its center.
+
+The value is a template: {{.file}} is the file name without extension, and with
+--values_csv every column of the file's row can be used, e.g. {{.order_no}}.
+The csv needs a "file" column with the pdf file names; files not in the csv are skipped.
+
+Text stamps use the standard Helvetica-Bold font, so only latin characters are
+supported; use an image stamp for other text.`,
+	Example: pdfstampCmdExample,
+	Run: func(cmd *cobra.Command, args []string) {
+		var (
+			inputPath, outputPath string
+		)
+
+		if len(args) < 1 {
+			fmt.Println(aurora.Magenta("至少输入一个参数，比如pdf文件或者pdf文件所在目录"))
+			os.Exit(1)
+		}
+		inputPath = args[0]
+
+		if len(args) == 2 {
+			outputPath = args[1]
+		} else {
+			outputPath = path.Join(viper.GetString(common.CurrentDir), fmt.Sprintf("stamped_pdfs_%s", time.Now().In(utils.LocationCST).Format("2006_01_02_15_04_05")))
+		}
+
+		if !path.IsAbs(inputPath) {
+			if p, err := filepath.Abs(inputPath); err != nil {
+				fmt.Println(aurora.Magenta("convert input path to abs path failed, please contact Rick~"))
+				os.Exit(1)
+			} else {
+				inputPath = p
+			}
+		}
+
+		if !path.IsAbs(outputPath) {
+			if p, err := filepath.Abs(outputPath); err != nil {
+				fmt.Println(aurora.Magenta("convert output directory to abs directory failed, please contact Rick~"))
+				os.Exit(1)
+			} else {
+				outputPath = p
+			}
+		}
+
+		stamp := &pdfstamp.Stamp{
+			Type:     stampType,
+			Value:    stampValue,
+			Anchor:   stampAnchor,
+			X:        stampX,
+			Y:        stampY,
+			Width:    stampWidth,
+			Height:   stampHeight,
+			FontSize: stampFontSize,
+			Color:    stampColor,
+			Opacity:  stampOpacity,
+			Rotation: stampRotation,
+			Pages:    stampPages,
+		}
+		err := pdfstamp.NewPdfStamper(inputPath, outputPath, stampPassword, stamp, stampValuesCsv, stampConcurrency).Do()
+		if err != nil {
+			fmt.Println(aurora.Magenta("pdf盖章出现错误，err:"), err)
+			os.Exit(1)
+		}
+	},
+}
+
+var (
+	stampPassword        string
+	stampPasswordFlag    = "password"
+	stampConcurrency     int
+	stampConcurrencyFlag = "concurrency"
+	stampValuesCsv       string
+	stampValuesCsvFlag   = "values_csv"
+
+	stampType         string
+	stampTypeFlag     = "type"
+	stampValue        string
+	stampValueFlag    = "value"
+	stampAnchor       string
+	stampAnchorFlag   = "anchor"
+	stampX            float64
+	stampXFlag        = "x"
+	stampY            float64
+	stampYFlag        = "y"
+	stampWidth        float64
+	stampWidthFlag    = "width"
+	stampHeight       float64
+	stampHeightFlag   = "height"
+	stampFontSize     float64
+	stampFontSizeFlag = "font_size"
+	stampColor        string
+	stampColorFlag    = "color"
+	stampOpacity      float64
+	stampOpacityFlag  = "opacity"
+	stampRotation     float64
+	stampRotationFlag = "rotation"
+	stampPages        string
+	stampPagesFlag    = "pages"
+)
+
+func init() {
+	rootCmd.AddCommand(pdfstampCmd)
+
+	pdfstampCmd.Flags().StringVarP(&stampPassword, stampPasswordFlag, "p", "", "PDF文件密码")
+	pdfstampCmd.Flags().IntVarP(&stampConcurrency, stampConcurrencyFlag, "c", 1, "分N组并发处理")
+	pdfstampCmd.Flags().StringVar(&stampValuesCsv, stampValuesCsvFlag, "", "每个文件的值, 需要包含file列, 其他列可以在value中引用, 例如: {{.order_no}}")
+
+	pdfstampCmd.Flags().StringVar(&stampType, stampTypeFlag, pdfstamp.TypeText, "类型: text/image/qrcode/barcode128")
+	pdfstampCmd.Flags().StringVar(&stampValue, stampValueFlag, "", "文字、图片路径或者码的内容, 支持模板, 例如: USED、{{.order_no}}")
+	pdfstampCmd.Flags().StringVar(&stampAnchor, stampAnchorFlag, pdfstamp.AnchorTopLeft, "位置: top-left/top/top-right/left/center/right/bottom-left/bottom/bottom-right")
+	pdfstampCmd.Flags().Float64VarP(&stampX, stampXFlag, "x", 0, "距锚点所在左右边的距离, 单位mm; 水平居中时为向右的偏移量")
+	pdfstampCmd.Flags().Float64VarP(&stampY, stampYFlag, "y", 0, "距锚点所在上下边的距离, 单位mm; 垂直居中时为向下的偏移量")
+	pdfstampCmd.Flags().Float64Var(&stampWidth, stampWidthFlag, 0, "图片/码的宽度, 单位mm, 默认二维码25, 条形码50, 图片40")
+	pdfstampCmd.Flags().Float64Var(&stampHeight, stampHeightFlag, 0, "图片/码的高度, 单位mm, 默认条形码15, 图片按比例")
+	pdfstampCmd.Flags().Float64Var(&stampFontSize, stampFontSizeFlag, 36, "文字字号")
+	pdfstampCmd.Flags().StringVar(&stampColor, stampColorFlag, "#FF0000", "文字颜色")
+	pdfstampCmd.Flags().Float64Var(&stampOpacity, stampOpacityFlag, 1, "不透明度, 0-1")
+	pdfstampCmd.Flags().Float64Var(&stampRotation, stampRotationFlag, 0, "绕中心逆时针旋转的角度")
+	pdfstampCmd.Flags().StringVar(&stampPages, stampPagesFlag, "", "需要盖章的页码范围, 为空时为所有页面, 例如: 1,3-end")
+}
@@ -22,6 +22,7 @@ import (
 	"path/filepath"
 
 	"invtools/common"
+	"invtools/pkg/util"
 
 	"github.com/mitchellh/go-homedir"
 	"github.com/spf13/cobra"
@@ -30,6 +31,12 @@ import (
 
 var cfgFile string
 
+// passwordListFile 加密pdf的密码列表文件, 所有命令打开加密的pdf时在-p指定的密码之后依次尝试
+var (
+	passwordListFile string
+	passwordListFlag = "password_list"
+)
+
 const appName = "invtools"
 const version = "1.2.0"
 
@@ -55,12 +62,13 @@ func Execute() {
 }
 
 func init() {
-	cobra.OnInitialize(initConfig)
+	cobra.OnInitialize(initConfig, initPasswordList)
 
 	// Here you will define your flags and configuration settings.
 	// Cobra supports persistent flags, which, if defined here,
 	// will be global for your application.
 	//rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.invtools.yaml)")
+	rootCmd.PersistentFlags().StringVar(&passwordListFile, passwordListFlag, "", "加密pdf的密码列表文件, 每行一个密码, 在-p指定的密码之后依次尝试")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
@@ -108,3 +116,17 @@ func initConfig() {
 		fmt.Println("Using config file:", viper.ConfigFileUsed())
 	}
 }
+
+// initPasswordList 读取密码列表, 供所有命令打开加密的pdf
+func initPasswordList() {
+	if passwordListFile == "" {
+		return
+	}
+
+	list, err := util.LoadPasswordList(passwordListFile)
+	if err != nil {
+		fmt.Println("read password list failed, err:", err)
+		os.Exit(1)
+	}
+	util.SetPasswordList(list)
+}
@@ -13,11 +13,23 @@ const (
 	ExtPng   = ".png"
 	ExtJpeg  = ".jpeg"
 	ExtJpg   = ".jpg"
+	ExtJSON  = ".json"
+	ExtYaml  = ".yaml"
+	ExtYml   = ".yml"
+	ExtHtml  = ".html"
+	ExtHtm   = ".htm"
+	ExtEml   = ".eml"
+	ExtTarGz = ".tar.gz"
+	ExtTgz   = ".tgz"
 )
 
 const (
 	CodeTypeQRCode     = "qrcode"
 	CodeTypeBarcode128 = "barcode128"
+	CodeTypeDataMatrix = "datamatrix"
+	CodeTypeEAN13      = "ean13"
+	CodeTypeEAN8       = "ean8"
+	CodeTypePDF417     = "pdf417"
 )
 
 var AllowedCsvExts = []string{
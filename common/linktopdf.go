@@ -1,5 +1,8 @@
 package common
 
+// StdinInput 输入文件为"-"时从标准输入读取链接
+const StdinInput = "-"
+
 const (
 	LinkToPdfFlagInput       = "input"
 	LinkToPdfFlagOutput      = "output"
@@ -7,6 +10,50 @@ const (
 	LinkToPdfFlagZip         = "zip"
 	LinkToPdfFlagPrintType   = "print_type"
 
+	LinkToPdfFlagArchiveFormat    = "archive_format"
+	LinkToPdfFlagArchiveStructure = "archive_structure"
+	LinkToPdfFlagVolumeSize       = "volume_size"
+	LinkToPdfFlagRemoveSource     = "remove_source"
+
+	LinkToPdfFlagNameTemplate = "name_template"
+	LinkToPdfFlagJSONPath     = "json_path"
+	LinkToPdfFlagLinkPattern  = "link_pattern"
+	LinkToPdfFlagOnCollision  = "on_collision"
+	LinkToPdfFlagURLRules     = "url_rules"
+
+	LinkToPdfFlagBrowsers        = "browsers"
+	LinkToPdfFlagPagesPerBrowser = "pages_per_browser"
+
+	LinkToPdfFlagPaperSize       = "paper_size"
+	LinkToPdfFlagLandscape       = "landscape"
+	LinkToPdfFlagMarginTop       = "margin_top"
+	LinkToPdfFlagMarginBottom    = "margin_bottom"
+	LinkToPdfFlagMarginLeft      = "margin_left"
+	LinkToPdfFlagMarginRight     = "margin_right"
+	LinkToPdfFlagScale           = "scale"
+	LinkToPdfFlagPrintBackground = "print_background"
+	LinkToPdfFlagHeaderTemplate  = "header_template"
+	LinkToPdfFlagFooterTemplate  = "footer_template"
+	LinkToPdfFlagPageRanges      = "page_ranges"
+	LinkToPdfFlagMediaType       = "media_type"
+
+	LinkToPdfFlagWaitSelector    = "wait_selector"
+	LinkToPdfFlagWaitJS          = "wait_js"
+	LinkToPdfFlagWaitNetworkIdle = "wait_network_idle"
+	LinkToPdfFlagWaitDelay       = "wait_delay"
+	LinkToPdfFlagWaitRules       = "wait_rules"
+	LinkToPdfFlagPageTimeout     = "page_timeout"
+	LinkToPdfFlagScreenshotDir   = "screenshot_dir"
+
+	LinkToPdfFlagCookies   = "cookies"
+	LinkToPdfFlagHeader    = "header"
+	LinkToPdfFlagBasicAuth = "basic_auth"
+	LinkToPdfFlagProxy     = "proxy"
+
+	LinkToPdfFlagDownloadTimeout = "download_timeout"
+	LinkToPdfFlagRetries         = "retries"
+	LinkToPdfFlagRateLimit       = "rate_limit"
+
 	PrintTypeChromedp    = "chromedp"
 	PrintTypeWkhtmltopdf = "wkhtmltopdf"
 )
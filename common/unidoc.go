@@ -0,0 +1,12 @@
+package common
+
+// UnidocLicenseKey unipdf(v3)的license, 没有设置license时写入的pdf会带水印
+const UnidocLicenseKey = `
+-----BEGIN UNIDOC LICENSE KEY-----
+eyJsaWNlbnNlX2lkIjoiYjIxYTQzOWQtM2NmYS00NmVjLTRjZmUtYTQ1NzkwMjY2NDEwIiwiY3VzdG9tZXJfaWQiOiIxM2VmZDM1MS1mYmQxLTRlNDctNzUzZS1jMzZlZWEzNzVlYWQiLCJjdXN0b21lcl9uYW1lIjoiS2xvb2sgVHJhdiIsImN1c3RvbWVyX2VtYWlsIjoiaXRAa2xvb2suY29tIiwidGllciI6ImJ1c2luZXNzIiwiY3JlYXRlZF9hdCI6MTU0NTk4OTA0MSwiZXhwaXJlc19hdCI6MCwiY3JlYXRvcl9uYW1lIjoiVW5pRG9jIFN1cHBvcnQiLCJjcmVhdG9yX2VtYWlsIjoic3VwcG9ydEB1bmlkb2MuaW8ifQ==
++
+PcQ6I/T42jVYx+3wIRqLw1sbj4sXIhTnZ/plDfNy1Njyp+6Cw4ria/K5NW0qKJHQeKBN3yJbK2siHFHiOnmtVkx74SI193YgnUKSwDkPncCkLMr4cdko64rb4PG7ClrU85dTCcqw5KVrlod+pVtIjQhLYfkmCBnJ3geGKBAN7qkT+ImktL94p9iS/gRqi3Dj02YLIuBKyHoDJBWKvc2Ae3duSvyLWt1LckMT1qfOfyWH24D6KCdPodE/YzEyaYKkkatpMvznfWhqQmkbNZeMqMuLkmjNMgBElF+6hxKbzS6NsY0HNsm2jM4h6iclN7E5zMDnHS5hOpPPjEHE4fQiBg==
+-----END UNIDOC LICENSE KEY-----`
+
+// UnidocCustomerName unipdf(v3)设置license时需要的客户名称
+const UnidocCustomerName = "Klook Trav"
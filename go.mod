@@ -2,6 +2,7 @@ module invtools
 
 require (
 	github.com/astaxie/beego v1.12.0
+	github.com/boombuler/barcode v1.0.0
 	github.com/chromedp/cdproto v0.0.0-20190731034908-29ad992845e9
 	github.com/chromedp/chromedp v0.3.1
 	github.com/gosuri/uilive v0.0.3 // indirect
@@ -13,9 +14,10 @@ require (
 	github.com/spf13/cobra v0.0.5
 	github.com/spf13/viper v1.4.0
 	github.com/tealeg/xlsx v1.0.3
-	github.com/unidoc/unidoc v2.2.0+incompatible
 	github.com/unidoc/unipdf/v3 v3.0.1
+	golang.org/x/image v0.0.0-20181116024801-cd38e8056d9b
 	golang.org/x/xerrors v0.0.0-20191204190536-9bdfabe68543 // indirect
+	gopkg.in/yaml.v2 v2.2.2
 )
 
 go 1.13
@@ -0,0 +1,215 @@
+package codegen
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"invtools/common"
+	"invtools/pkg/util"
+	"invtools/utils"
+	"invtools/utils/errors"
+
+	"github.com/gosuri/uiprogress"
+	"github.com/skratchdot/open-golang/open"
+	unicore "github.com/unidoc/unipdf/v3/core"
+	unicreator "github.com/unidoc/unipdf/v3/creator"
+)
+
+const (
+	cmdName = "codegen"
+
+	FormatPng = "png"
+	FormatPdf = "pdf"
+
+	// pdfDPI 生成pdf时图片的分辨率, 页面大小为图片按该分辨率换算后的大小
+	pdfDPI = 300
+)
+
+var codeTypes = []string{
+	common.CodeTypeQRCode, common.CodeTypeBarcode128, common.CodeTypeDataMatrix,
+	common.CodeTypeEAN13, common.CodeTypeEAN8, common.CodeTypePDF417,
+}
+
+// Options 生成码的参数, csv中的type列可以覆盖Type
+type Options struct {
+	Type            string // qrcode/barcode128/datamatrix/ean13/ean8/pdf417
+	Format          string // png/pdf
+	Width, Height   int    // 码的像素大小
+	Margin          int    // 码四周的空白, 单位为模块, util.DefaultCodeMargin时使用各类型的默认值
+	ErrorCorrection string // 二维码纠错等级: L/M/Q/H, PDF417对应安全等级2/3/4/5
+	ShowText        bool   // 在码的下方显示内容, csv中的text列优先
+}
+
+// Validate 校验参数并设置默认值
+func (o *Options) Validate() error {
+	if o.Type == "" {
+		o.Type = common.CodeTypeQRCode
+	}
+	if err := validateType(o.Type); err != nil {
+		return err
+	}
+
+	switch o.Format {
+	case "":
+		o.Format = FormatPng
+	case FormatPng, FormatPdf:
+	default:
+		return errors.Errorf(nil, "不支持的输出格式:%s, 支持: png/pdf", o.Format)
+	}
+
+	if o.Width <= 0 || o.Height <= 0 {
+		return errors.Errorf(nil, "码的大小需要大于0, width:%d, height:%d", o.Width, o.Height)
+	}
+	if o.Margin < util.DefaultCodeMargin {
+		return errors.Errorf(nil, "空白不能小于0:%d", o.Margin)
+	}
+
+	switch strings.ToUpper(o.ErrorCorrection) {
+	case "", "L", "M", "Q", "H":
+	default:
+		return errors.Errorf(nil, "不支持的纠错等级:%s, 支持: L/M/Q/H", o.ErrorCorrection)
+	}
+	return nil
+}
+
+func validateType(codeType string) error {
+	for _, t := range codeTypes {
+		if codeType == t {
+			return nil
+		}
+	}
+	return errors.Errorf(nil, "不支持的code类型:%s, 支持: %s", codeType, strings.Join(codeTypes, "/"))
+}
+
+type Generator struct {
+	input, output string
+	opts          Options
+}
+
+// NewCodeGenerator input为csv文件, 每一行生成一个码, 以name列(没有时为content列)命名保存在output目录下
+func NewCodeGenerator(input, output string, opts Options) *Generator {
+	return &Generator{
+		input:  input,
+		output: output,
+		opts:   opts,
+	}
+}
+
+func (g *Generator) validate() error {
+	if err := g.opts.Validate(); err != nil {
+		return err
+	}
+
+	if !utils.CheckFileIsExist(g.input) || utils.CheckDirIsExist(g.input) {
+		return errors.Errorf(nil, "输入的csv文件不存在:%s", g.input)
+	}
+
+	if err := utils.CheckAndMkDir(g.output); err != nil {
+		return errors.Errorf(err, "创建目录失败,目录:%s", g.output)
+	}
+	return nil
+}
+
+func (g *Generator) Do() error {
+	if err := g.validate(); err != nil {
+		return err
+	}
+
+	return g.execute()
+}
+
+func (g *Generator) execute() error {
+	rows, err := loadRows(g.input)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("[%s] 读取csv后一共得到%d行,即将开始生成...\n", cmdName, len(rows))
+
+	var (
+		st           = time.Now()
+		names        = util.NewNameAllocator()
+		successFiles []string
+		failedRows   []string
+	)
+
+	uiprogress.Start()
+	cnt := len(rows)
+	bar := uiprogress.AddBar(cnt).AppendCompleted().PrependElapsed()
+	bar.PrependFunc(func(b *uiprogress.Bar) string {
+		return fmt.Sprintf("processing: %d/%d", b.Current(), cnt)
+	})
+	for bar.Incr() {
+		row := rows[bar.Current()-1]
+		output := path.Join(g.output, names.Allocate(row.fileName(), "."+g.opts.Format))
+		if err := g.generate(row, output); err != nil {
+			failedRows = append(failedRows, fmt.Sprintf("row:%d, content:%s, err:%v", row.row, row.content, err))
+			continue
+		}
+		successFiles = append(successFiles, output)
+	}
+	uiprogress.Stop()
+
+	fmt.Printf("[%s] 本次生成, 一共成功%d个, 失败%d个, 总耗时:%s\n", cmdName, len(successFiles), len(failedRows), time.Since(st))
+	fmt.Printf("[%s] 生成的文件保存目录是: %s\n", cmdName, g.output)
+	if len(failedRows) > 0 {
+		fmt.Printf("失败的行:%s", failedRows)
+	}
+
+	open.Run(g.output)
+
+	return nil
+}
+
+// generate 生成一个码, 保存为png图片或者单页pdf
+func (g *Generator) generate(row *codeRow, output string) error {
+	codeType := g.opts.Type
+	if row.codeType != "" {
+		codeType = row.codeType
+	}
+	text := row.text
+	if text == "" && g.opts.ShowText {
+		text = row.content
+	}
+
+	img, err := util.EncodeCodeWithOptions(row.content, codeType, util.CodeOptions{
+		Width:           g.opts.Width,
+		Height:          g.opts.Height,
+		Margin:          g.opts.Margin,
+		ErrorCorrection: g.opts.ErrorCorrection,
+		Text:            text,
+	})
+	if err != nil {
+		return err
+	}
+
+	if g.opts.Format == FormatPng {
+		return util.SaveImage(output, img)
+	}
+
+	c := unicreator.New()
+	pdfImg, err := c.NewImageFromGoImage(img)
+	if err != nil {
+		return errors.Errorf(err, "create pdf image failed")
+	}
+	// 码不能使用有损压缩, 否则边缘模糊影响扫描
+	pdfImg.SetEncoder(unicore.NewFlateEncoder())
+
+	w := float64(img.Bounds().Dx()) * 72 / pdfDPI
+	h := float64(img.Bounds().Dy()) * 72 / pdfDPI
+	pdfImg.SetPos(0, 0)
+	pdfImg.SetWidth(w)
+	pdfImg.SetHeight(h)
+
+	c.SetPageSize(unicreator.PageSize{w, h})
+	c.NewPage()
+	if err := c.Draw(pdfImg); err != nil {
+		return errors.Errorf(err, "draw image failed")
+	}
+	if err := c.WriteToFile(output); err != nil {
+		return errors.Errorf(err, "write pdf file failed, file:%s", output)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package codegen
+
+import (
+	"image"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	"invtools/common"
+	"invtools/pkg/util"
+)
+
+func TestOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		want    Options
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			opts: Options{Width: 300, Height: 300, Margin: util.DefaultCodeMargin},
+			want: Options{Type: common.CodeTypeQRCode, Format: FormatPng, Width: 300, Height: 300, Margin: util.DefaultCodeMargin},
+		},
+		{
+			name:    "bad type",
+			opts:    Options{Type: "aztec", Width: 300, Height: 300},
+			wantErr: true,
+		},
+		{
+			name:    "bad format",
+			opts:    Options{Format: "jpg", Width: 300, Height: 300},
+			wantErr: true,
+		},
+		{
+			name:    "bad size",
+			opts:    Options{Width: 0, Height: 300},
+			wantErr: true,
+		},
+		{
+			name:    "bad margin",
+			opts:    Options{Width: 300, Height: 300, Margin: -2},
+			wantErr: true,
+		},
+		{
+			name:    "bad error correction",
+			opts:    Options{Width: 300, Height: 300, ErrorCorrection: "X"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := tt.opts
+			err := o.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && o != tt.want {
+				t.Errorf("Validate() got = %+v, want %+v", o, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerator_generate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "codegen_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name     string
+		opts     Options
+		row      *codeRow
+		codeType string
+	}{
+		{
+			name:     "qrcode png",
+			opts:     Options{Type: common.CodeTypeQRCode, Format: FormatPng, Width: 300, Height: 300, Margin: util.DefaultCodeMargin, ErrorCorrection: "H", ShowText: true},
+			row:      &codeRow{row: 2, content: "https://example.com/v/A001"},
+			codeType: common.CodeTypeQRCode,
+		},
+		{
+			name:     "row type overrides",
+			opts:     Options{Type: common.CodeTypeQRCode, Format: FormatPng, Width: 400, Height: 120, Margin: util.DefaultCodeMargin},
+			row:      &codeRow{row: 3, content: "6901234567892", codeType: common.CodeTypeEAN13, text: "6901234567892"},
+			codeType: common.CodeTypeEAN13,
+		},
+		{
+			name:     "barcode128 pdf",
+			opts:     Options{Type: common.CodeTypeBarcode128, Format: FormatPdf, Width: 600, Height: 150, Margin: util.DefaultCodeMargin},
+			row:      &codeRow{row: 4, content: "ORDER-A001"},
+			codeType: common.CodeTypeBarcode128,
+		},
+		{
+			name:     "pdf417 png",
+			opts:     Options{Type: common.CodeTypePDF417, Format: FormatPng, Width: 600, Height: 200, Margin: util.DefaultCodeMargin, ErrorCorrection: "Q"},
+			row:      &codeRow{row: 5, content: "ORDER-A001"},
+			codeType: common.CodeTypePDF417,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewCodeGenerator("", dir, tt.opts)
+			output := path.Join(dir, tt.row.fileName()+"."+tt.opts.Format)
+			if err := g.generate(tt.row, output); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.opts.Format == FormatPdf {
+				codes, err := util.NewUniPdf().ScanPageCodes(output, "", tt.codeType)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(codes, []string{tt.row.content}) {
+					t.Errorf("got codes %q, want %q", codes, tt.row.content)
+				}
+				return
+			}
+
+			img, err := util.LoadImage(output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.codeType == common.CodeTypePDF417 {
+				// gozxing没有pdf417的解码器, 只检查图片大小
+				if want := image.Rect(0, 0, tt.opts.Width, tt.opts.Height); img.Bounds() != want {
+					t.Errorf("bounds = %v, want %v", img.Bounds(), want)
+				}
+				return
+			}
+			got, _, err := util.LocateCode(img, tt.codeType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.row.content {
+				t.Errorf("decoded = %q, want %q", got, tt.row.content)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package codegen
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"invtools/pkg/util"
+	"invtools/utils/errors"
+)
+
+const (
+	// FieldContent 码的内容, 必须有这一列
+	FieldContent = "content"
+	// FieldName 生成的文件名(不带后缀), 没有时使用content
+	FieldName = "name"
+	// FieldType 这一行的code类型, 为空时使用--type
+	FieldType = "type"
+	// FieldText 码下方显示的文字, 为空时按--show_text决定是否显示content
+	FieldText = "text"
+)
+
+// codeRow csv中的一行, row为csv中的行号, 表头为第1行
+type codeRow struct {
+	row                           int
+	content, name, codeType, text string
+}
+
+// fileName 去掉不允许的字符后的文件名, 为空时使用行号
+func (r *codeRow) fileName() string {
+	name := r.name
+	if name == "" {
+		name = r.content
+	}
+	name = util.SanitizeFileName(name)
+	if name == "" {
+		name = fmt.Sprintf("row_%d", r.row)
+	}
+	return name
+}
+
+func loadRows(file string) ([]*codeRow, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, errors.Errorf(err, "open csv failed, file:%s", file)
+	}
+	defer f.Close()
+
+	return readRows(f)
+}
+
+// readRows 第一行为表头, 需要包含content列, 可选name/type/text列; content为空的行跳过
+func readRows(r io.Reader) ([]*codeRow, error) {
+	table, err := util.ReadCsvTable(r, FieldContent)
+	if err != nil {
+		return nil, err
+	}
+
+	get := func(record []string, field string) string {
+		return strings.TrimSpace(table.Value(record, field))
+	}
+
+	var rows []*codeRow
+	for i, record := range table.Rows {
+		row := &codeRow{
+			row:      table.Line(i),
+			content:  get(record, FieldContent),
+			name:     get(record, FieldName),
+			codeType: strings.ToLower(get(record, FieldType)),
+			text:     get(record, FieldText),
+		}
+		if row.content == "" {
+			continue
+		}
+		if row.codeType != "" {
+			if err := validateType(row.codeType); err != nil {
+				return nil, errors.Errorf(err, "csv文件第%d行", row.row)
+			}
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return nil, errors.Errorf(nil, "csv文件中没有需要生成的内容")
+	}
+	return rows, nil
+}
@@ -0,0 +1,75 @@
+package codegen
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_readRows(t *testing.T) {
+	tests := []struct {
+		name    string
+		csv     string
+		want    []*codeRow
+		wantErr bool
+	}{
+		{
+			name: "content only",
+			csv:  "\ufeffContent\nA001\n \nA002\n",
+			want: []*codeRow{{row: 2, content: "A001"}, {row: 4, content: "A002"}},
+		},
+		{
+			name: "all columns",
+			csv:  "name,content,type,text\nvoucher 1,A001,EAN13,\n,A002,,NO.A002\n",
+			want: []*codeRow{
+				{row: 2, content: "A001", name: "voucher 1", codeType: "ean13"},
+				{row: 3, content: "A002", text: "NO.A002"},
+			},
+		},
+		{
+			name:    "no content column",
+			csv:     "name,code\na,A001\n",
+			wantErr: true,
+		},
+		{
+			name:    "bad type",
+			csv:     "content,type\nA001,aztec\n",
+			wantErr: true,
+		},
+		{
+			name:    "no rows",
+			csv:     "content\n\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readRows(strings.NewReader(tt.csv))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readRows() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readRows() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_codeRow_fileName(t *testing.T) {
+	tests := []struct {
+		row  codeRow
+		want string
+	}{
+		{codeRow{row: 2, content: "A001"}, "A001"},
+		{codeRow{row: 2, content: "A001", name: "voucher"}, "voucher"},
+		{codeRow{row: 2, content: "https://example.com/v?id=1"}, "https___example.com_v_id=1"},
+		{codeRow{row: 5, content: "..."}, "row_5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.row.fileName(); got != tt.want {
+				t.Errorf("fileName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package detective
+
+import (
+	"os"
+	"path"
+
+	"invtools/pkg/util"
+	"invtools/utils"
+	"invtools/utils/errors"
+)
+
+const (
+	RepeatedFilesDir   = "repeated_files"
+	UnexpectedFilesDir = "unexpected_files"
+)
+
+// DedupeByMd5 根据文件md5去重, 返回第一次出现的文件和重复的文件(文件名), 计算md5失败的文件(文件名)
+func DedupeByMd5(files []string) (unique, repeated, failed []string) {
+	var fileMd5Keys = make(map[string]string)
+	for _, fi := range files {
+		md5key, err := utils.ComputeMd5String(fi)
+		if err != nil {
+			failed = append(failed, path.Base(fi))
+			continue
+		}
+
+		if _, ok := fileMd5Keys[md5key]; ok {
+			repeated = append(repeated, path.Base(fi))
+			continue
+		}
+		fileMd5Keys[md5key] = path.Base(fi)
+		unique = append(unique, fi)
+	}
+	return
+}
+
+// ClassifyFiles 把重复的文件和检测失败的文件分别移动到dir下的repeated_files和unexpected_files目录
+func ClassifyFiles(dir string, repeatedFiles, failedFiles []string) error {
+	if err := moveFiles(dir, RepeatedFilesDir, repeatedFiles); err != nil {
+		return errors.Errorf(err, "move repeated files failed")
+	}
+	if err := moveFiles(dir, UnexpectedFilesDir, failedFiles); err != nil {
+		return errors.Errorf(err, "move unexpected files failed")
+	}
+	util.Printf("classify files finished!\n")
+	return nil
+}
+
+func moveFiles(dir, subDir string, files []string) error {
+	if len(files) == 0 {
+		return nil
+	}
+
+	dstDir := path.Join(dir, subDir)
+	if err := utils.CheckAndMkDir(dstDir); err != nil {
+		return errors.Errorf(err, "create directory failed")
+	}
+
+	for _, v := range files {
+		if err := os.Rename(path.Join(dir, v), path.Join(dstDir, v)); err != nil {
+			return errors.Errorf(err, "move file failed,filename:%s", v)
+		}
+	}
+	return nil
+}
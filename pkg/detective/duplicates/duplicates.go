@@ -0,0 +1,377 @@
+package duplicates
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"invtools/common"
+	"invtools/pkg/detective"
+	"invtools/pkg/util"
+	"invtools/pkg/util/xpdf"
+	"invtools/utils"
+	"invtools/utils/errors"
+
+	"github.com/gosuri/uiprogress"
+)
+
+const (
+	DetectiveNickName = "duplicates"
+
+	DefaultThreshold = 0.95
+
+	tmpDirName = "pdfdetect_tmp"
+
+	SignalMd5     = "md5"     // 文件完全相同
+	SignalText    = "text"    // 文字相似
+	SignalBarcode = "barcode" // 二维码/条形码相同
+	SignalVisual  = "visual"  // 页面渲染后的图片相似
+)
+
+// fingerprint 单个pdf的指纹
+type fingerprint struct {
+	file     string
+	md5      string
+	shingles map[string]struct{} // 归一化后文字的3-gram
+	codes    map[string]struct{} // 扫描到的二维码/条形码
+	hashes   []uint64            // 每一页的感知哈希
+	err      error
+}
+
+// Group 一组相似的pdf, 第一个文件作为保留的文件
+type Group struct {
+	Files  []string
+	Score  float64 // 组内最低的相似度
+	Signal string  // 相似度最低的一对文件是根据哪个指纹判断的
+}
+
+// DetectiveDuplicates 根据文字、条码、页面图片检测相似的pdf
+type DetectiveDuplicates struct {
+	PdfFileDir  string
+	Threshold   float64
+	Concurrency int
+	Classify    bool
+}
+
+func NewDetective(dir string, threshold float64, concurrency int, classify bool) *DetectiveDuplicates {
+	return &DetectiveDuplicates{
+		PdfFileDir:  dir,
+		Threshold:   threshold,
+		Concurrency: concurrency,
+		Classify:    classify,
+	}
+}
+
+func (d *DetectiveDuplicates) Validate() error {
+	if ok := utils.CheckDirIsExist(d.PdfFileDir); !ok {
+		return errors.Errorf(nil, "目标文件夹不存在,dir:[%s]", d.PdfFileDir)
+	}
+	if d.Threshold <= 0 || d.Threshold > 1 {
+		return errors.Errorf(nil, "相似度阈值需要在(0, 1]之间, threshold:%v", d.Threshold)
+	}
+	if d.Concurrency <= 0 {
+		d.Concurrency = 1
+	}
+	return nil
+}
+
+func (d *DetectiveDuplicates) Detect() error {
+	if err := d.Validate(); err != nil {
+		return errors.Errorf(err, "参数校验失败")
+	}
+
+	fis, err := util.ReadDirFiles(d.PdfFileDir, common.ExtPDF)
+	if err != nil {
+		return errors.Errorf(err, "读取目录失败")
+	}
+	if len(fis) < 2 {
+		util.Printf("目录中的PDF文件少于2张, 无需检测\n")
+		return nil
+	}
+	sort.Strings(fis)
+	util.Printf("一共有%d张PDF文件, 开始计算指纹...\n", len(fis))
+
+	tmpDir := path.Join(common.CurrentDir, fmt.Sprintf("%s_%s", tmpDirName, utils.GetUUIDString()))
+	if err := utils.CheckAndMkDir(tmpDir); err != nil {
+		return errors.Errorf(err, "创建临时目录失败")
+	}
+	defer utils.RmAll(tmpDir)
+
+	fps := d.fingerprints(fis, tmpDir)
+
+	var failed []string
+	for _, fp := range fps {
+		if fp.err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %s", path.Base(fp.file), errors.GetInnerMostV2(fp.err)))
+		}
+	}
+	if len(failed) > 0 {
+		util.Printf("以下文件计算指纹失败, 只使用md5比较:\n%v\n", strings.Join(failed, "\n"))
+	}
+
+	groups := groupDuplicates(fps, d.Threshold)
+	if len(groups) == 0 {
+		util.Printf("没有发现相似度不低于%.2f的文件\n", d.Threshold)
+		return nil
+	}
+
+	var repeatedFiles []string
+	for i, g := range groups {
+		util.Printf("第%d组, 相似度:%.2f(%s)\n  保留: %s\n  重复: %s\n", i+1, g.Score, g.Signal,
+			path.Base(g.Files[0]), strings.Join(baseNames(g.Files[1:]), "\n        "))
+		repeatedFiles = append(repeatedFiles, baseNames(g.Files[1:])...)
+	}
+	util.Printf("一共%d组相似的文件, 重复文件:%d张\n", len(groups), len(repeatedFiles))
+
+	if d.Classify {
+		if err := detective.ClassifyFiles(d.PdfFileDir, repeatedFiles, nil); err != nil {
+			util.Printf("归类文件失败,err:%v", err)
+		}
+	}
+	return nil
+}
+
+// fingerprints 分组并发计算每个pdf的指纹
+func (d *DetectiveDuplicates) fingerprints(fis []string, tmpDir string) []*fingerprint {
+	var (
+		wg      sync.WaitGroup
+		resultm sync.Map
+	)
+
+	groups := util.DivideSliceIntoGroup(fis, d.Concurrency)
+	uiprogress.Start()
+	wg.Add(len(groups))
+
+	for i := 0; i < len(groups); i++ {
+		var (
+			ctx = context.Background()
+			grp = groups[i]
+			cnt = len(grp)
+		)
+		go utils.HandlePanicV2(ctx, func(i interface{}) {
+			ggrp := *i.(*[]string)
+			defer wg.Done()
+
+			bar := uiprogress.AddBar(cnt).AppendCompleted().PrependElapsed()
+			bar.PrependFunc(func(b *uiprogress.Bar) string {
+				return fmt.Sprintf("processing: %d/%d", b.Current(), cnt)
+			})
+
+			for bar.Incr() {
+				f := ggrp[bar.Current()-1]
+				resultm.Store(f, computeFingerprint(f, tmpDir))
+			}
+		})(&grp)
+	}
+
+	wg.Wait()
+	uiprogress.Stop()
+
+	var fps []*fingerprint
+	for _, f := range fis {
+		if v, ok := resultm.Load(f); ok {
+			fps = append(fps, v.(*fingerprint))
+		}
+	}
+	return fps
+}
+
+func computeFingerprint(file, tmpDir string) *fingerprint {
+	fp := &fingerprint{file: file}
+
+	md5key, err := utils.ComputeMd5String(file)
+	if err != nil {
+		fp.err = errors.Errorf(err, "计算md5失败")
+		return fp
+	}
+	fp.md5 = md5key
+
+	text, err := util.NewUniPdf().ExtractText(file, "", []int{})
+	if err != nil {
+		fp.err = errors.Errorf(err, "解析文字失败")
+		return fp
+	}
+	fp.shingles = textShingles(text)
+
+	pngFiles, err := xpdf.PdfToPngV2(file, tmpDir, utils.GetUUIDString())
+	if err != nil {
+		fp.err = errors.Errorf(err, "pdf转图片失败")
+		return fp
+	}
+	fp.codes = make(map[string]struct{})
+	for _, f := range pngFiles {
+		img, err := util.LoadImage(f)
+		if err != nil {
+			fp.err = errors.Errorf(err, "加载图片失败")
+			return fp
+		}
+		fp.hashes = append(fp.hashes, util.DifferenceHash(img))
+
+		for _, codeType := range []string{common.CodeTypeQRCode, common.CodeTypeBarcode128} {
+			if code, _, err := util.LocateCode(img, codeType); err == nil {
+				fp.codes[code] = struct{}{}
+			}
+		}
+	}
+	return fp
+}
+
+// textShingles 文字归一化(小写, 去掉空白)后, 每3个连续单词作为一个片段
+func textShingles(text string) map[string]struct{} {
+	words := strings.Fields(strings.ToLower(text))
+	shingles := make(map[string]struct{})
+	for i := 0; i+3 <= len(words); i++ {
+		shingles[strings.Join(words[i:i+3], " ")] = struct{}{}
+	}
+	if len(words) > 0 && len(words) < 3 {
+		shingles[strings.Join(words, " ")] = struct{}{}
+	}
+	return shingles
+}
+
+// jaccard 两个集合的交集/并集, 任意一个为空时返回0
+func jaccard(a, b map[string]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	var inter int
+	for k := range a {
+		if _, ok := b[k]; ok {
+			inter++
+		}
+	}
+	return float64(inter) / float64(len(a)+len(b)-inter)
+}
+
+// visualSimilarity 逐页比较感知哈希取平均值, 页数不同时按多的页数计算
+func visualSimilarity(a, b []uint64) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	n, total := len(a), len(a)
+	if len(b) < n {
+		n = len(b)
+	} else {
+		total = len(b)
+	}
+
+	var sum float64
+	for i := 0; i < n; i++ {
+		sum += util.HashSimilarity(a[i], b[i])
+	}
+	return sum / float64(total)
+}
+
+// similarity 两个文件都扫描到条码时, 条码没有交集直接判定为不同(同一模板的凭证只有姓名和条码不同,
+// 文字和页面几乎一样); 文字和页面图片都有时取两者中较低的, 需要同时相似
+func similarity(a, b *fingerprint) (float64, string) {
+	if a.md5 != "" && a.md5 == b.md5 {
+		return 1, SignalMd5
+	}
+
+	var codeScore float64
+	if len(a.codes) > 0 && len(b.codes) > 0 {
+		if codeScore = jaccard(a.codes, b.codes); codeScore == 0 {
+			return 0, SignalBarcode
+		}
+	}
+
+	score, signal := contentSimilarity(a, b)
+	if codeScore > 0 && codeScore >= score {
+		return codeScore, SignalBarcode
+	}
+	return score, signal
+}
+
+// contentSimilarity 文字和页面图片的相似度, 只有一种指纹时使用该指纹
+func contentSimilarity(a, b *fingerprint) (float64, string) {
+	hasText := len(a.shingles) > 0 && len(b.shingles) > 0
+	hasVisual := len(a.hashes) > 0 && len(b.hashes) > 0
+
+	text := jaccard(a.shingles, b.shingles)
+	visual := visualSimilarity(a.hashes, b.hashes)
+	switch {
+	case hasText && hasVisual:
+		if visual < text {
+			return visual, SignalVisual
+		}
+		return text, SignalText
+	case hasVisual:
+		return visual, SignalVisual
+	default:
+		return text, SignalText
+	}
+}
+
+// groupDuplicates 相似度不低于阈值的两个文件归为一组(传递), 组内按文件名排序
+func groupDuplicates(fps []*fingerprint, threshold float64) []*Group {
+	parent := make([]int, len(fps))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	type edge struct {
+		score  float64
+		signal string
+	}
+	edges := make(map[int]edge) // 每组中相似度最低的一对
+	for i := 0; i < len(fps); i++ {
+		for j := i + 1; j < len(fps); j++ {
+			score, signal := similarity(fps[i], fps[j])
+			if score < threshold {
+				continue
+			}
+			ri, rj := find(i), find(j)
+			e := edge{score: score, signal: signal}
+			for _, r := range []int{ri, rj} {
+				if old, ok := edges[r]; ok && old.score <= e.score {
+					e = old
+				}
+			}
+			delete(edges, ri)
+			delete(edges, rj)
+			parent[rj] = ri
+			edges[ri] = e
+		}
+	}
+
+	members := make(map[int][]string)
+	var roots []int
+	for i, fp := range fps {
+		r := find(i)
+		if _, ok := members[r]; !ok {
+			roots = append(roots, r)
+		}
+		members[r] = append(members[r], fp.file)
+	}
+
+	var groups []*Group
+	for _, r := range roots {
+		if len(members[r]) < 2 {
+			continue
+		}
+		files := members[r]
+		sort.Strings(files)
+		e := edges[find(r)]
+		groups = append(groups, &Group{Files: files, Score: e.score, Signal: e.signal})
+	}
+	return groups
+}
+
+func baseNames(files []string) []string {
+	var names []string
+	for _, f := range files {
+		names = append(names, path.Base(f))
+	}
+	return names
+}
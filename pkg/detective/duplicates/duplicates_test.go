@@ -0,0 +1,88 @@
+package duplicates
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_groupDuplicates(t *testing.T) {
+	set := func(vs ...string) map[string]struct{} {
+		m := make(map[string]struct{})
+		for _, v := range vs {
+			m[v] = struct{}{}
+		}
+		return m
+	}
+
+	fps := []*fingerprint{
+		// a和b: 重新下载, 只有元数据中的时间不同, 条码相同
+		{file: "a.pdf", md5: "1", codes: set("916000308600093383"), shingles: textShingles("Ticket: COMBO TRD 2D TP Customer"), hashes: []uint64{0xff00}},
+		{file: "b.pdf", md5: "2", codes: set("916000308600093383"), shingles: textShingles("Ticket: COMBO TRD 2D TP Customer"), hashes: []uint64{0xff00}},
+		// c: 文字完全不同, 页面渲染后相同(扫描件)
+		{file: "c.pdf", md5: "3", hashes: []uint64{0x0f0f}},
+		{file: "d.pdf", md5: "4", hashes: []uint64{0x0f0f}},
+		// e: 与其他文件都不同
+		{file: "e.pdf", md5: "5", codes: set("123"), shingles: textShingles("Order No 123 Total JPY 12,000"), hashes: []uint64{0xffffffff00000000}},
+		// f: 与a完全相同
+		{file: "f.pdf", md5: "1"},
+	}
+
+	got := groupDuplicates(fps, DefaultThreshold)
+	want := []*Group{
+		{Files: []string{"a.pdf", "b.pdf", "f.pdf"}, Score: 1, Signal: SignalBarcode},
+		{Files: []string{"c.pdf", "d.pdf"}, Score: 1, Signal: SignalVisual},
+	}
+	if !reflect.DeepEqual(got, want) {
+		for _, g := range got {
+			t.Logf("got group: %+v", g)
+		}
+		t.Errorf("groupDuplicates() got %d groups, want %d", len(got), len(want))
+	}
+}
+
+func Test_similarity(t *testing.T) {
+	a := &fingerprint{shingles: textShingles("Booking No ABC123 Printed at 2020-05-01 10:00 Guest John Smith Adult 2")}
+	b := &fingerprint{shingles: textShingles("Booking No ABC123 Printed at 2020-05-02 09:30 Guest John Smith Adult 2")}
+
+	score, signal := similarity(a, b)
+	if signal != SignalText {
+		t.Errorf("similarity() signal = %v, want %v", signal, SignalText)
+	}
+	if score <= 0.3 || score >= 1 {
+		t.Errorf("similarity() score = %v, want between 0.3 and 1", score)
+	}
+
+	if got := visualSimilarity([]uint64{0, 0}, []uint64{0}); got != 0.5 {
+		t.Errorf("visualSimilarity() = %v, want 0.5", got)
+	}
+}
+
+func Test_similarity_sameTemplate(t *testing.T) {
+	set := func(vs ...string) map[string]struct{} {
+		m := make(map[string]struct{})
+		for _, v := range vs {
+			m[v] = struct{}{}
+		}
+		return m
+	}
+	template := "Universal Studios Japan 1 Day Studio Pass Adult Valid 2020-05-01 Present this ticket at the entrance Guest "
+
+	fps := []*fingerprint{
+		// 同一供应商模板的两张凭证, 只有姓名和二维码不同
+		{file: "a.pdf", md5: "1", codes: set("916000308600093383"), shingles: textShingles(template + "John Smith"), hashes: []uint64{0xff00ff00ff00ff00}},
+		{file: "b.pdf", md5: "2", codes: set("916000308600093384"), shingles: textShingles(template + "Jane Smith"), hashes: []uint64{0xff00ff00ff00ff01}},
+	}
+	if score, signal := similarity(fps[0], fps[1]); score != 0 || signal != SignalBarcode {
+		t.Errorf("similarity() = %v, %v, want 0, %v", score, signal, SignalBarcode)
+	}
+	if got := groupDuplicates(fps, DefaultThreshold); len(got) != 0 {
+		t.Errorf("groupDuplicates() got %d groups, want 0", len(got))
+	}
+
+	// 没有条码时文字和页面图片需要同时相似
+	a := &fingerprint{shingles: textShingles(template + "John Smith"), hashes: []uint64{0}}
+	b := &fingerprint{shingles: textShingles(template + "John Smith"), hashes: []uint64{0xffffffff}}
+	if score, signal := similarity(a, b); score >= DefaultThreshold || signal != SignalVisual {
+		t.Errorf("similarity() = %v, %v, want below threshold by %v", score, signal, SignalVisual)
+	}
+}
@@ -0,0 +1,26 @@
+package rules
+
+const (
+	// BuiltinLegoland 内置的legoland票券规则, 需要变量activity和valid
+	BuiltinLegoland = "legoland"
+)
+
+// builtinRuleSets 内置规则, 可以直接用名字代替规则文件: --rules legoland
+var builtinRuleSets = map[string]string{
+	BuiltinLegoland: legolandRules,
+}
+
+const legolandRules = `
+name: legoland
+rules:
+  - name: activity_name
+    type: text
+    start: "Ticket:"
+    end: "Customer"
+    expected: "${activity}"
+  - name: effective_date
+    type: text
+    start: "Valid from:"
+    end: "Order No"
+    expected: "${valid}"
+`
@@ -0,0 +1,161 @@
+package rules
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"invtools/common"
+	"invtools/pkg/detective"
+	"invtools/pkg/util"
+	"invtools/pkg/util/xpdf"
+	"invtools/utils"
+	"invtools/utils/errors"
+	"invtools/utils/validator"
+
+	"github.com/gosuri/uiprogress"
+)
+
+const tmpDirName = "pdfdetect_tmp"
+
+// RuleDetective 按规则文件检测目录下的pdf
+type RuleDetective struct {
+	PdfFileDir string            `json:"pdf_file_dir" valid:"required"`
+	RulesFile  string            `json:"rules_file" valid:"required"`
+	Vars       map[string]string `json:"vars"`
+	Classify   bool              `json:"classify"`
+
+	ruleSet *RuleSet
+}
+
+func NewDetective(dir, rulesFile string, vars map[string]string, classify bool) *RuleDetective {
+	return &RuleDetective{
+		PdfFileDir: dir,
+		RulesFile:  rulesFile,
+		Vars:       vars,
+		Classify:   classify,
+	}
+}
+
+func (d *RuleDetective) Validate() error {
+	ok, err := validator.ValidateStruct(d)
+	if err != nil {
+		return errors.Errorf(err, "validator validate error")
+	}
+
+	if !ok {
+		return errors.Errorf(nil, "validator validate failed")
+	}
+
+	if ok := utils.CheckDirIsExist(d.PdfFileDir); !ok {
+		return errors.Errorf(nil, "目标文件夹不存在,dir:[%s]", d.PdfFileDir)
+	}
+
+	rs, err := LoadRuleSet(d.RulesFile)
+	if err != nil {
+		return errors.Errorf(err, "读取规则文件失败")
+	}
+	if err := rs.Prepare(d.Vars); err != nil {
+		return errors.Errorf(err, "规则校验失败")
+	}
+	d.ruleSet = rs
+	return nil
+}
+
+func (d *RuleDetective) Detect() error {
+	if err := d.Validate(); err != nil {
+		return errors.Errorf(err, "参数校验失败")
+	}
+
+	fis, err := util.ReadDirFiles(d.PdfFileDir, common.ExtPDF)
+	if err != nil {
+		return errors.Errorf(err, "读取目录失败")
+	}
+	if len(fis) == 0 {
+		util.Printf("目录中没有PDF文件\n")
+		return nil
+	}
+	util.Printf("一共有%d张PDF文件, 规则:%s, 共%d条\n", len(fis), d.ruleSet.Name, len(d.ruleSet.Rules))
+
+	tmpDir := path.Join(common.CurrentDir, fmt.Sprintf("%s_%s", tmpDirName, utils.GetUUIDString()))
+	if d.ruleSet.needImages() {
+		if err := utils.CheckAndMkDir(tmpDir); err != nil {
+			return errors.Errorf(err, "创建临时目录失败")
+		}
+		defer utils.RmAll(tmpDir)
+	}
+
+	unique, repeatedFiles, unexpectedFiles := detective.DedupeByMd5(fis)
+
+	uiprogress.Start()
+	bar := uiprogress.AddBar(len(unique)).AppendCompleted().PrependElapsed()
+	bar.PrependFunc(func(b *uiprogress.Bar) string {
+		if b.Current() == 0 {
+			return "processing: "
+		}
+		return "processing: " + path.Base(unique[b.Current()-1])
+	})
+
+	var reasons []string
+	for bar.Incr() {
+		fi := unique[bar.Current()-1]
+		if err := d.checkFile(fi, tmpDir); err != nil {
+			unexpectedFiles = append(unexpectedFiles, path.Base(fi))
+			reasons = append(reasons, fmt.Sprintf("%s: %s", path.Base(fi), errors.GetInnerMostV2(err)))
+		}
+	}
+	uiprogress.Stop()
+
+	var (
+		count        = len(fis)
+		repeated     = len(repeatedFiles)
+		detectFailed = len(unexpectedFiles)
+		success      = count - detectFailed - repeated
+		successRate  = float64(100*success) / float64(count)
+	)
+	util.Printf("本次检测一共扫描了%d张文件,重复文件:%d张,检测失败:%d张,符合预期的有%d张,达标率:%.02f%%\n", count, repeated, detectFailed, success, successRate)
+	if repeated > 0 {
+		util.Printf("以下是重复的文件:\n%v\n", strings.Join(repeatedFiles, "\n"))
+	}
+	if detectFailed > 0 {
+		util.Printf("以下是检测失败的:\n%v\n", strings.Join(reasons, "\n"))
+	}
+
+	if d.Classify {
+		if err := detective.ClassifyFiles(d.PdfFileDir, repeatedFiles, unexpectedFiles); err != nil {
+			util.Printf("归类文件失败,err:%v", err)
+		}
+	}
+	return nil
+}
+
+// checkFile 检测单个pdf, 返回第一条不满足的规则
+func (d *RuleDetective) checkFile(filePath, tmpDir string) error {
+	pages, err := util.NewUniPdf().ExtractTextWithPages(filePath, "", []int{})
+	if err != nil {
+		return errors.Errorf(err, "ExtractText from pdf file failed")
+	}
+	doc := &document{file: filePath, pages: pages}
+
+	if d.ruleSet.needImages() {
+		pngFiles, err := xpdf.PdfToPngV2(filePath, tmpDir, utils.GetUUIDString())
+		if err != nil {
+			return errors.Errorf(err, "pdf转图片失败")
+		}
+		for _, f := range pngFiles {
+			img, err := util.LoadImage(f)
+			if err != nil {
+				return errors.Errorf(err, "加载图片失败")
+			}
+			doc.images = append(doc.images, img)
+		}
+	}
+
+	for _, r := range d.ruleSet.Rules {
+		if err := r.Check(doc); err != nil {
+			// 只保留失败原因, 用于汇总输出
+			return errors.Errorf(nil, "[%s] %s", r.Name, errors.GetInnerMostV2(err))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,439 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"invtools/common"
+	"invtools/pkg/util"
+	"invtools/utils"
+	"invtools/utils/errors"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	RuleTypeText    = "text"    // 文字断言
+	RuleTypeRegexp  = "regexp"  // 正则断言
+	RuleTypeDate    = "date"    // 日期断言
+	RuleTypeBarcode = "barcode" // 二维码/条形码断言
+
+	// RuleTypeBarcodeText 二维码/条形码与页面文字交叉校验, 比如码中的内容需要与打印的预订号一致
+	RuleTypeBarcodeText = "barcode_text"
+
+	CompareEqual    = "equal"    // 码的内容与文字相同
+	CompareContains = "contains" // 码的内容包含文字
+
+	// DateToday 日期断言中表示当天
+	DateToday = "today"
+)
+
+var varPattern = regexp.MustCompile(`\$\{(\w+)\}`)
+
+// RuleSet 一组检测规则, 一个pdf需要满足所有规则
+type RuleSet struct {
+	Name  string  `json:"name" yaml:"name"`
+	Rules []*Rule `json:"rules" yaml:"rules"`
+}
+
+// Rule 针对pdf页面文字的一条断言
+//
+// 取值方式(text/regexp/date/barcode_text):
+//   - start/end: 截取两个标记之间的文字, end为空时截取到行尾
+//   - pattern: 正则匹配, 有分组时取第一个分组
+//   - 都没有配置时取整页文字
+type Rule struct {
+	Name string `json:"name" yaml:"name"`
+	Type string `json:"type" yaml:"type"` // text/regexp/date/barcode/barcode_text
+	Page int    `json:"page" yaml:"page"` // 第几页, 0表示所有页
+
+	Start   string `json:"start" yaml:"start"`
+	End     string `json:"end" yaml:"end"`
+	Pattern string `json:"pattern" yaml:"pattern"`
+
+	// 期望值, 比较时忽略空格和大小写; text类型没有配置取值方式时, 判断页面是否包含期望值
+	Expected string `json:"expected" yaml:"expected"`
+
+	// date类型: 日期格式(go layout), 以及允许的范围, 可以使用today
+	Layout    string `json:"layout" yaml:"layout"`
+	NotBefore string `json:"not_before" yaml:"not_before"`
+	NotAfter  string `json:"not_after" yaml:"not_after"`
+
+	// barcode/barcode_text类型: qrcode/barcode128
+	CodeType string `json:"code_type" yaml:"code_type"`
+
+	// barcode_text类型: 从码的内容中取值的正则(有分组时取第一个分组), 以及比较方式 equal/contains
+	CodePattern string `json:"code_pattern" yaml:"code_pattern"`
+	Compare     string `json:"compare" yaml:"compare"`
+
+	compiledPattern     *regexp.Regexp
+	compiledCodePattern *regexp.Regexp
+}
+
+// document 待检测的pdf
+type document struct {
+	file   string
+	pages  []string      // 每一页的文字
+	images []image.Image // 每一页渲染后的图片, barcode规则才会用到
+}
+
+// LoadRuleSet 读取规则文件, 支持json和yaml; 文件不存在时查找内置的规则
+func LoadRuleSet(file string) (*RuleSet, error) {
+	var b []byte
+	if builtin, ok := builtinRuleSets[file]; ok && !utils.CheckFileIsExist(file) {
+		b = []byte(builtin)
+	} else {
+		if !utils.CheckFileIsExist(file) {
+			return nil, errors.Errorf(nil, "rules file not exists, file:%s", file)
+		}
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, errors.Errorf(err, "read rules file error,file:%s", file)
+		}
+		b = data
+	}
+
+	var (
+		rs  = &RuleSet{}
+		err error
+	)
+	switch strings.ToLower(path.Ext(file)) {
+	case common.ExtJSON:
+		err = json.Unmarshal(b, rs)
+	default:
+		err = yaml.Unmarshal(b, rs)
+	}
+	if err != nil {
+		return nil, errors.Errorf(err, "unmarshal rules file failed")
+	}
+	if len(rs.Rules) == 0 {
+		return nil, errors.Errorf(nil, "rules file has no rule, file:%s", file)
+	}
+	return rs, nil
+}
+
+// Prepare 替换规则中的变量 ${name}, 并校验每条规则
+func (rs *RuleSet) Prepare(vars map[string]string) error {
+	for i, r := range rs.Rules {
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("rule_%d", i+1)
+		}
+
+		for _, field := range []*string{&r.Start, &r.End, &r.Pattern, &r.Expected, &r.NotBefore, &r.NotAfter, &r.CodePattern} {
+			v, err := expandVars(*field, vars)
+			if err != nil {
+				return errors.Errorf(err, "rule:%s", r.Name)
+			}
+			*field = v
+		}
+
+		if err := r.validate(); err != nil {
+			return errors.Errorf(err, "rule:%s 配置不合法", r.Name)
+		}
+	}
+	return nil
+}
+
+// needImages 是否需要渲染页面图片
+func (rs *RuleSet) needImages() bool {
+	for _, r := range rs.Rules {
+		if r.Type == RuleTypeBarcode || r.Type == RuleTypeBarcodeText {
+			return true
+		}
+	}
+	return false
+}
+
+func expandVars(s string, vars map[string]string) (string, error) {
+	var missing []string
+	res := varPattern.ReplaceAllStringFunc(s, func(m string) string {
+		name := varPattern.FindStringSubmatch(m)[1]
+		v, ok := vars[name]
+		if !ok {
+			missing = append(missing, name)
+		}
+		return v
+	})
+	if len(missing) > 0 {
+		return "", errors.Errorf(nil, "缺少变量:%s, 请使用--var %s=value指定", strings.Join(missing, ","), missing[0])
+	}
+	return res, nil
+}
+
+func (r *Rule) validate() error {
+	if r.Pattern != "" {
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return errors.Errorf(err, "正则表达式不合法:%s", r.Pattern)
+		}
+		r.compiledPattern = re
+	}
+
+	switch r.Type {
+	case RuleTypeText:
+		if r.Expected == "" {
+			return errors.Errorf(nil, "text 规则需要配置expected")
+		}
+	case RuleTypeRegexp:
+		if r.compiledPattern == nil {
+			return errors.Errorf(nil, "regexp 规则需要配置pattern")
+		}
+	case RuleTypeDate:
+		if r.Layout == "" {
+			return errors.Errorf(nil, "date 规则需要配置layout")
+		}
+		for _, d := range []string{r.NotBefore, r.NotAfter} {
+			if _, err := r.parseBound(d); err != nil {
+				return err
+			}
+		}
+	case RuleTypeBarcode:
+		if r.CodeType != common.CodeTypeQRCode && r.CodeType != common.CodeTypeBarcode128 {
+			return errors.Errorf(nil, "barcode 规则不支持的code_type:%s", r.CodeType)
+		}
+	case RuleTypeBarcodeText:
+		if r.CodeType != common.CodeTypeQRCode && r.CodeType != common.CodeTypeBarcode128 {
+			return errors.Errorf(nil, "barcode_text 规则不支持的code_type:%s", r.CodeType)
+		}
+		if r.compiledPattern == nil && r.Start == "" {
+			return errors.Errorf(nil, "barcode_text 规则需要配置pattern或start, 用于从页面文字中取值")
+		}
+		if r.CodePattern != "" {
+			re, err := regexp.Compile(r.CodePattern)
+			if err != nil {
+				return errors.Errorf(err, "正则表达式不合法:%s", r.CodePattern)
+			}
+			r.compiledCodePattern = re
+		}
+		switch r.Compare {
+		case "":
+			r.Compare = CompareEqual
+		case CompareEqual, CompareContains:
+		default:
+			return errors.Errorf(nil, "barcode_text 规则不支持的compare:%s", r.Compare)
+		}
+	default:
+		return errors.Errorf(nil, "不支持的规则类型:%s", r.Type)
+	}
+	return nil
+}
+
+// Check 检测pdf是否满足规则
+func (r *Rule) Check(doc *document) error {
+	switch r.Type {
+	case RuleTypeBarcode:
+		return r.checkBarcode(doc)
+	case RuleTypeBarcodeText:
+		return r.checkBarcodeText(doc)
+	}
+
+	content, err := r.content(doc)
+	if err != nil {
+		return err
+	}
+
+	if r.Type == RuleTypeText && r.Start == "" && r.Pattern == "" {
+		if !strings.Contains(normalize(content), normalize(r.Expected)) {
+			return errors.Errorf(nil, "页面中没有找到:%s", r.Expected)
+		}
+		return nil
+	}
+
+	value, err := r.value(content)
+	if err != nil {
+		return err
+	}
+
+	if r.Type == RuleTypeDate {
+		return r.checkDate(value)
+	}
+	return r.checkExpected(value)
+}
+
+// content 规则对应页面的文字
+func (r *Rule) content(doc *document) (string, error) {
+	if r.Page == 0 {
+		return strings.Join(doc.pages, "\n"), nil
+	}
+	if r.Page < 0 || r.Page > len(doc.pages) {
+		return "", errors.Errorf(nil, "页码不存在, page:%d, 总页数:%d", r.Page, len(doc.pages))
+	}
+	return doc.pages[r.Page-1], nil
+}
+
+// value 按start/end或pattern取值
+func (r *Rule) value(content string) (string, error) {
+	if r.Start != "" {
+		idx := strings.Index(content, r.Start)
+		if idx < 0 {
+			return "", errors.Errorf(nil, "页面中没有找到开始标记:%s", r.Start)
+		}
+		content = content[idx+len(r.Start):]
+
+		end := r.End
+		if end == "" {
+			end = "\n"
+		}
+		if idx := strings.Index(content, end); idx >= 0 {
+			content = content[:idx]
+		} else if r.End != "" {
+			return "", errors.Errorf(nil, "页面中没有找到结束标记:%s", r.End)
+		}
+	}
+
+	if r.compiledPattern != nil {
+		res := r.compiledPattern.FindStringSubmatch(content)
+		if res == nil {
+			return "", errors.Errorf(nil, "正则没有匹配:%s", r.Pattern)
+		}
+		if len(res) > 1 {
+			return strings.TrimSpace(res[1]), nil
+		}
+		return strings.TrimSpace(res[0]), nil
+	}
+
+	return strings.TrimSpace(content), nil
+}
+
+func (r *Rule) checkExpected(value string) error {
+	if r.Expected == "" {
+		return nil
+	}
+	if !strings.EqualFold(normalize(value), normalize(r.Expected)) {
+		return errors.Errorf(nil, "期望:%q, 实际:%q", r.Expected, value)
+	}
+	return nil
+}
+
+func (r *Rule) checkDate(value string) error {
+	date, err := time.ParseInLocation(r.Layout, value, utils.LocationCST)
+	if err != nil {
+		return errors.Errorf(err, "日期格式不正确, layout:%s, value:%s", r.Layout, value)
+	}
+
+	notBefore, _ := r.parseBound(r.NotBefore)
+	if !notBefore.IsZero() && date.Before(notBefore) {
+		return errors.Errorf(nil, "日期%s早于%s", value, r.NotBefore)
+	}
+	notAfter, _ := r.parseBound(r.NotAfter)
+	if !notAfter.IsZero() && date.After(notAfter) {
+		return errors.Errorf(nil, "日期%s晚于%s", value, r.NotAfter)
+	}
+	return nil
+}
+
+// parseBound 解析日期范围, today为当天0点
+func (r *Rule) parseBound(s string) (time.Time, error) {
+	switch s {
+	case "":
+		return time.Time{}, nil
+	case DateToday:
+		y, m, d := time.Now().In(utils.LocationCST).Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, utils.LocationCST), nil
+	}
+
+	t, err := time.ParseInLocation(r.Layout, s, utils.LocationCST)
+	if err != nil {
+		return time.Time{}, errors.Errorf(err, "日期范围格式不正确, layout:%s, value:%s", r.Layout, s)
+	}
+	return t, nil
+}
+
+// scanCodes 扫描规则对应页面中的码
+func (r *Rule) scanCodes(doc *document) ([]string, error) {
+	images := doc.images
+	if r.Page > 0 {
+		if r.Page > len(images) {
+			return nil, errors.Errorf(nil, "页码不存在, page:%d, 总页数:%d", r.Page, len(images))
+		}
+		images = images[r.Page-1 : r.Page]
+	}
+
+	var codes []string
+	for _, img := range images {
+		if code, _, err := util.LocateCode(img, r.CodeType); err == nil {
+			codes = append(codes, code)
+		}
+	}
+	if len(codes) == 0 {
+		return nil, errors.Errorf(nil, "页面中没有扫描到%s", r.CodeType)
+	}
+	return codes, nil
+}
+
+// checkBarcode 页面中的码需要满足pattern/expected
+func (r *Rule) checkBarcode(doc *document) error {
+	found, err := r.scanCodes(doc)
+	if err != nil {
+		return err
+	}
+
+	for _, code := range found {
+
+		value, err := r.value(code)
+		if err != nil {
+			continue
+		}
+		if r.checkExpected(value) == nil {
+			return nil
+		}
+	}
+
+	return errors.Errorf(nil, "扫描到的%s不符合规则:%s", r.CodeType, strings.Join(found, ","))
+}
+
+// checkBarcodeText 页面中任意一个码的内容与页面文字中取到的值一致即可
+func (r *Rule) checkBarcodeText(doc *document) error {
+	content, err := r.content(doc)
+	if err != nil {
+		return err
+	}
+	text, err := r.value(content)
+	if err != nil {
+		return err
+	}
+	if text == "" {
+		return errors.Errorf(nil, "页面文字中取到的值为空")
+	}
+
+	codes, err := r.scanCodes(doc)
+	if err != nil {
+		return err
+	}
+
+	for _, code := range codes {
+		if r.matchCode(code, text) {
+			return nil
+		}
+	}
+	return errors.Errorf(nil, "%s内容:%s 与页面文字:%s 不一致", r.CodeType, strings.Join(codes, ","), text)
+}
+
+func (r *Rule) matchCode(code, text string) bool {
+	if r.compiledCodePattern != nil {
+		res := r.compiledCodePattern.FindStringSubmatch(code)
+		if res == nil {
+			return false
+		}
+		code = res[0]
+		if len(res) > 1 {
+			code = res[1]
+		}
+	}
+
+	if r.Compare == CompareContains {
+		return strings.Contains(normalize(code), normalize(text))
+	}
+	return normalize(code) == normalize(text)
+}
+
+// normalize 去掉所有空白, 比较时忽略空格
+func normalize(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), ""))
+}
@@ -0,0 +1,213 @@
+package rules
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"invtools/pkg/util"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/qrcode"
+)
+
+func TestRule_Check(t *testing.T) {
+	doc := &document{
+		pages: []string{
+			"Ticket: COMBO TRD 2D TP Customer\nValid from: 25/07/2019 thru 25/01/2020 Order No 123",
+			"Total: JPY 12,000\nBooking No. ABC-123",
+		},
+	}
+
+	tests := []struct {
+		name    string
+		rule    *Rule
+		vars    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "TestRule_Check_text_between",
+			rule: &Rule{Type: RuleTypeText, Start: "Ticket:", End: "Customer", Expected: "${activity}"},
+			vars: map[string]string{"activity": "combo trd 2dtp"},
+		},
+		{
+			name:    "TestRule_Check_text_between_wrong",
+			rule:    &Rule{Type: RuleTypeText, Start: "Ticket:", End: "Customer", Expected: "COMBO TRD 3D TP"},
+			wantErr: true,
+		},
+		{
+			name: "TestRule_Check_text_contains",
+			rule: &Rule{Type: RuleTypeText, Page: 2, Expected: "JPY 12,000"},
+		},
+		{
+			name:    "TestRule_Check_text_contains_wrong_page",
+			rule:    &Rule{Type: RuleTypeText, Page: 1, Expected: "JPY 12,000"},
+			wantErr: true,
+		},
+		{
+			name: "TestRule_Check_regexp",
+			rule: &Rule{Type: RuleTypeRegexp, Pattern: `Booking No\. ([A-Z]+-\d+)`, Expected: "ABC-123"},
+		},
+		{
+			name:    "TestRule_Check_regexp_not_match",
+			rule:    &Rule{Type: RuleTypeRegexp, Pattern: `Order No\. (\d+)`},
+			wantErr: true,
+		},
+		{
+			name: "TestRule_Check_date_in_range",
+			rule: &Rule{Type: RuleTypeDate, Pattern: `thru (\S+)`, Layout: "02/01/2006", NotBefore: "01/01/2020", NotAfter: "31/12/2020"},
+		},
+		{
+			name:    "TestRule_Check_date_expired",
+			rule:    &Rule{Type: RuleTypeDate, Pattern: `thru (\S+)`, Layout: "02/01/2006", NotBefore: DateToday},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs := &RuleSet{Rules: []*Rule{tt.rule}}
+			if err := rs.Prepare(tt.vars); err != nil {
+				t.Fatalf("Prepare() error = %v", err)
+			}
+			if err := tt.rule.Check(doc); (err != nil) != tt.wantErr {
+				t.Errorf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRule_Check_barcodeText(t *testing.T) {
+	qr := func(content string) image.Image {
+		m, err := qrcode.NewQRCodeWriter().Encode(content, gozxing.BarcodeFormat_QR_CODE, 200, 200, nil)
+		if err != nil {
+			t.Fatalf("encode qrcode failed, err:%v", err)
+		}
+		img := image.NewGray(image.Rect(0, 0, m.GetWidth(), m.GetHeight()))
+		for y := 0; y < m.GetHeight(); y++ {
+			for x := 0; x < m.GetWidth(); x++ {
+				if m.Get(x, y) {
+					img.SetGray(x, y, color.Gray{Y: 0})
+				} else {
+					img.SetGray(x, y, color.Gray{Y: 255})
+				}
+			}
+		}
+		return img
+	}
+
+	doc := &document{
+		pages:  []string{"Reservation Code: RSV-0001\nGuest: John Smith"},
+		images: []image.Image{qr("https://example.com/check?code=RSV-0001&lang=en")},
+	}
+
+	tests := []struct {
+		name    string
+		rule    *Rule
+		wantErr bool
+	}{
+		{
+			name: "TestRule_Check_barcodeText_code_pattern",
+			rule: &Rule{Type: RuleTypeBarcodeText, CodeType: "qrcode", Pattern: `Reservation Code: (\S+)`, CodePattern: `code=([^&]+)`},
+		},
+		{
+			name: "TestRule_Check_barcodeText_contains",
+			rule: &Rule{Type: RuleTypeBarcodeText, CodeType: "qrcode", Start: "Reservation Code:", Compare: CompareContains},
+		},
+		{
+			name:    "TestRule_Check_barcodeText_equal_mismatch",
+			rule:    &Rule{Type: RuleTypeBarcodeText, CodeType: "qrcode", Pattern: `Reservation Code: (\S+)`},
+			wantErr: true,
+		},
+		{
+			name:    "TestRule_Check_barcodeText_no_barcode128",
+			rule:    &Rule{Type: RuleTypeBarcodeText, CodeType: "barcode128", Pattern: `Reservation Code: (\S+)`},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs := &RuleSet{Rules: []*Rule{tt.rule}}
+			if err := rs.Prepare(nil); err != nil {
+				t.Fatalf("Prepare() error = %v", err)
+			}
+			if err := tt.rule.Check(doc); (err != nil) != tt.wantErr {
+				t.Errorf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRuleSet_Prepare(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    *Rule
+		wantErr bool
+	}{
+		{name: "TestRuleSet_Prepare_missing_var", rule: &Rule{Type: RuleTypeText, Expected: "${activity}"}, wantErr: true},
+		{name: "TestRuleSet_Prepare_unknown_type", rule: &Rule{Type: "json", Expected: "x"}, wantErr: true},
+		{name: "TestRuleSet_Prepare_bad_pattern", rule: &Rule{Type: RuleTypeRegexp, Pattern: "("}, wantErr: true},
+		{name: "TestRuleSet_Prepare_date_without_layout", rule: &Rule{Type: RuleTypeDate, Pattern: `(\d+)`}, wantErr: true},
+		{name: "TestRuleSet_Prepare_barcode", rule: &Rule{Type: RuleTypeBarcode, CodeType: "qrcode"}},
+		{name: "TestRuleSet_Prepare_barcode_text_without_pattern", rule: &Rule{Type: RuleTypeBarcodeText, CodeType: "qrcode"}, wantErr: true},
+		{name: "TestRuleSet_Prepare_barcode_text_bad_compare", rule: &Rule{Type: RuleTypeBarcodeText, CodeType: "qrcode", Pattern: `(\d+)`, Compare: "like"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs := &RuleSet{Rules: []*Rule{tt.rule}}
+			if err := rs.Prepare(nil); (err != nil) != tt.wantErr {
+				t.Errorf("Prepare() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBuiltinLegoland(t *testing.T) {
+	pages, err := util.NewUniPdf().ExtractTextWithPages("../../../testdata/legoland.pdf", "", []int{})
+	if err != nil {
+		t.Fatalf("extract text content from legoland pdf failed. err:%v", err)
+	}
+	doc := &document{pages: pages}
+
+	tests := []struct {
+		name    string
+		vars    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "TestBuiltinLegoland",
+			vars: map[string]string{
+				"activity": "COMBO TRD 2D TP + WP + SLC (C/S) OPEN",
+				"valid":    "25/07/2019 thru 25/01/2020",
+			},
+		},
+		{
+			name: "TestBuiltinLegoland_wrong_activity",
+			vars: map[string]string{
+				"activity": "TEST_WRONG_COMBO TRD 2D TP + WP + SLC (C/S) OPEN",
+				"valid":    "25/07/2019 thru 25/01/2020",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs, err := LoadRuleSet(BuiltinLegoland)
+			if err != nil {
+				t.Fatalf("LoadRuleSet() error = %v", err)
+			}
+			if err := rs.Prepare(tt.vars); err != nil {
+				t.Fatalf("Prepare() error = %v", err)
+			}
+
+			var checkErr error
+			for _, r := range rs.Rules {
+				if checkErr = r.Check(doc); checkErr != nil {
+					break
+				}
+			}
+			if (checkErr != nil) != tt.wantErr {
+				t.Errorf("Check() error = %v, wantErr %v", checkErr, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+// Package testpdf 测试中用unipdf生成pdf文件, 只在各命令的测试中使用
+package testpdf
+
+import (
+	"testing"
+
+	unicreator "github.com/unidoc/unipdf/v3/creator"
+)
+
+// Write 生成每个text一页的pdf, text为空时为空白页
+func Write(t testing.TB, file string, texts ...string) {
+	t.Helper()
+
+	c := unicreator.New()
+	for _, text := range texts {
+		c.NewPage()
+		if text == "" {
+			continue
+		}
+		if err := c.Draw(c.NewParagraph(text)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.WriteToFile(file); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,270 @@
+package linktopdf
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"invtools/common"
+	"invtools/pkg/util"
+	"invtools/utils"
+	"invtools/utils/errors"
+)
+
+const (
+	ArchiveFormatZip   = "zip"
+	ArchiveFormatTarGz = "tar.gz"
+
+	// StructureFlat 所有pdf放在压缩包的根目录
+	StructureFlat = "flat"
+	// StructureSupplier 按匹配的url规则名(没有匹配的规则时为host)分目录
+	StructureSupplier = "supplier"
+	// StructureDate 按pdf的生成日期分目录
+	StructureDate = "date"
+
+	// attachmentFolder 邮件附件按供应商分目录时使用的目录名
+	attachmentFolder = "attachment"
+)
+
+// ArchiveOptions 压缩选项
+type ArchiveOptions struct {
+	Format       string // zip/tar.gz, 为空时按输出文件名判断, 默认zip
+	Structure    string // flat/supplier/date
+	VolumeSize   int64  // 每个分卷的最大字节数(按pdf原始大小估算), 0为不分卷
+	RemoveSource bool   // 压缩完成后删除pdf目录
+}
+
+// Validate 校验选项, Format为空时根据压缩文件名决定
+func (o *ArchiveOptions) Validate(archiveName string) error {
+	if o.Format == "" {
+		o.Format = ArchiveFormatZip
+		if isTarGz(archiveName) {
+			o.Format = ArchiveFormatTarGz
+		}
+	}
+	switch o.Format {
+	case ArchiveFormatZip, ArchiveFormatTarGz:
+	default:
+		return errors.Errorf(nil, "不支持的压缩格式:%s, 支持: zip/tar.gz", o.Format)
+	}
+
+	switch o.Structure {
+	case "":
+		o.Structure = StructureFlat
+	case StructureFlat, StructureSupplier, StructureDate:
+	default:
+		return errors.Errorf(nil, "不支持的目录结构:%s, 支持: flat/supplier/date", o.Structure)
+	}
+
+	if o.VolumeSize < 0 {
+		return errors.Errorf(nil, "分卷大小不能小于0")
+	}
+	return nil
+}
+
+// archiveEntry 压缩包中的一个文件
+type archiveEntry struct {
+	src     string // 源文件路径
+	name    string // 在压缩包中的路径
+	size    int64
+	modTime time.Time
+}
+
+// archiveEntries 按输入顺序列出打印成功的pdf, manifest.csv放在每个分卷的根目录
+func archiveEntries(dir string, records []*LinkRecord, results map[*LinkRecord]*linkResult, rules *URLRules, structure string) (entries []archiveEntry, manifest *archiveEntry, err error) {
+	for _, rec := range records {
+		res, ok := results[rec]
+		if !ok || res.err != nil || res.duplicateOf != nil {
+			continue
+		}
+
+		fi, err := os.Stat(res.file)
+		if err != nil {
+			return nil, nil, errors.Errorf(err, "get file stat failed, file:%s", res.file)
+		}
+
+		name := path.Base(res.file)
+		switch structure {
+		case StructureSupplier:
+			name = path.Join(supplierFolder(rec, rules), name)
+		case StructureDate:
+			name = path.Join(fi.ModTime().In(utils.LocationCST).Format("2006-01-02"), name)
+		}
+		entries = append(entries, archiveEntry{src: res.file, name: name, size: fi.Size(), modTime: fi.ModTime()})
+	}
+
+	if fi, err := os.Stat(path.Join(dir, manifestFileName)); err == nil {
+		manifest = &archiveEntry{src: path.Join(dir, manifestFileName), name: manifestFileName, size: fi.Size(), modTime: fi.ModTime()}
+	}
+	return entries, manifest, nil
+}
+
+// supplierFolder 匹配的url规则名, 没有匹配的规则时为host
+func supplierFolder(rec *LinkRecord, rules *URLRules) string {
+	if len(rec.Attachment) > 0 {
+		return attachmentFolder
+	}
+
+	URL, err := url.Parse(rec.URL)
+	if err != nil || URL.Host == "" {
+		return "unknown"
+	}
+	if rule := rules.Match(URL); rule != nil && rule.Name != "" {
+		return util.SanitizeFileName(rule.Name)
+	}
+	return util.SanitizeFileName(URL.Hostname())
+}
+
+// splitVolumes 按pdf的原始大小分卷, pdf本身已经压缩过, 压缩后的大小与原始大小接近.
+// 单个文件超过分卷大小时单独一个分卷
+func splitVolumes(entries []archiveEntry, volumeSize int64) [][]archiveEntry {
+	if volumeSize <= 0 {
+		return [][]archiveEntry{entries}
+	}
+
+	var (
+		volumes [][]archiveEntry
+		current []archiveEntry
+		size    int64
+	)
+	for _, e := range entries {
+		if len(current) > 0 && size+e.size > volumeSize {
+			volumes = append(volumes, current)
+			current, size = nil, 0
+		}
+		current = append(current, e)
+		size += e.size
+	}
+	if len(current) > 0 || len(volumes) == 0 {
+		volumes = append(volumes, current)
+	}
+	return volumes
+}
+
+// volumeFileName 只有一个分卷时使用原文件名, 否则为 name_part1.zip, name_part2.zip
+func volumeFileName(archivePath string, i, total int) string {
+	if total <= 1 {
+		return archivePath
+	}
+	base, ext := splitArchiveExt(archivePath)
+	return fmt.Sprintf("%s_part%d%s", base, i+1, ext)
+}
+
+// archiveFileName 按压缩格式替换文件后缀
+func archiveFileName(name, format string) string {
+	base, _ := splitArchiveExt(name)
+	if format == ArchiveFormatTarGz {
+		return base + common.ExtTarGz
+	}
+	return base + common.ExtZip
+}
+
+func splitArchiveExt(name string) (base, ext string) {
+	lower := strings.ToLower(name)
+	for _, e := range []string{common.ExtTarGz, common.ExtTgz, common.ExtZip} {
+		if strings.HasSuffix(lower, e) {
+			return name[:len(name)-len(e)], name[len(name)-len(e):]
+		}
+	}
+	return name, ""
+}
+
+func isTarGz(name string) bool {
+	lower := strings.ToLower(name)
+	return strings.HasSuffix(lower, common.ExtTarGz) || strings.HasSuffix(lower, common.ExtTgz)
+}
+
+// isArchiveName 输出路径是压缩文件而不是目录
+func isArchiveName(name string) bool {
+	_, ext := splitArchiveExt(name)
+	return ext != ""
+}
+
+// archiveWriter 边读边写入压缩文件, 不在内存中缓存整个压缩包
+type archiveWriter interface {
+	add(e archiveEntry, r io.Reader) error
+	Close() error
+}
+
+func newArchiveWriter(format, file string) (archiveWriter, error) {
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, errors.Errorf(err, "create archive file failed, file:%s", file)
+	}
+
+	if format == ArchiveFormatTarGz {
+		gz := gzip.NewWriter(f)
+		return &tarGzWriter{f: f, gz: gz, w: tar.NewWriter(gz)}, nil
+	}
+	return &zipWriter{f: f, w: zip.NewWriter(f)}, nil
+}
+
+type zipWriter struct {
+	f *os.File
+	w *zip.Writer
+}
+
+func (z *zipWriter) add(e archiveEntry, r io.Reader) error {
+	wf, err := z.w.Create(e.name)
+	if err != nil {
+		return errors.Errorf(err, "zip writer create failed,fileName:[%s]", e.name)
+	}
+	if _, err := io.Copy(wf, r); err != nil {
+		return errors.Errorf(err, "write file into zip file failed,file:[%s]", e.name)
+	}
+	return nil
+}
+
+func (z *zipWriter) Close() error {
+	err := z.w.Close()
+	if cerr := z.f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return errors.Errorf(err, "close zip file writer failed")
+	}
+	return nil
+}
+
+type tarGzWriter struct {
+	f  *os.File
+	gz *gzip.Writer
+	w  *tar.Writer
+}
+
+func (t *tarGzWriter) add(e archiveEntry, r io.Reader) error {
+	hdr := &tar.Header{
+		Name:    e.name,
+		Mode:    0644,
+		Size:    e.size,
+		ModTime: e.modTime,
+	}
+	if err := t.w.WriteHeader(hdr); err != nil {
+		return errors.Errorf(err, "tar writer write header failed,fileName:[%s]", e.name)
+	}
+	if _, err := io.Copy(t.w, r); err != nil {
+		return errors.Errorf(err, "write file into tar file failed,file:[%s]", e.name)
+	}
+	return nil
+}
+
+func (t *tarGzWriter) Close() error {
+	err := t.w.Close()
+	if gerr := t.gz.Close(); err == nil {
+		err = gerr
+	}
+	if cerr := t.f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return errors.Errorf(err, "close tar.gz file writer failed")
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package linktopdf
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func Test_splitVolumes(t *testing.T) {
+	entries := []archiveEntry{{name: "a", size: 4}, {name: "b", size: 4}, {name: "c", size: 12}, {name: "d", size: 1}}
+
+	tests := []struct {
+		name       string
+		volumeSize int64
+		want       [][]string
+	}{
+		{name: "no split", volumeSize: 0, want: [][]string{{"a", "b", "c", "d"}}},
+		{name: "split", volumeSize: 10, want: [][]string{{"a", "b"}, {"c"}, {"d"}}},
+		{name: "large", volumeSize: 100, want: [][]string{{"a", "b", "c", "d"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got [][]string
+			for _, vol := range splitVolumes(entries, tt.volumeSize) {
+				var names []string
+				for _, e := range vol {
+					names = append(names, e.name)
+				}
+				got = append(got, names)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitVolumes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_compress(t *testing.T) {
+	dir, err := ioutil.TempDir("", "linktopdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var entries []archiveEntry
+	for _, name := range []string{"a.pdf", "b.pdf", "c.pdf"} {
+		src := path.Join(dir, name)
+		if err := ioutil.WriteFile(src, []byte("%PDF-"+name+"%%EOF"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, archiveEntry{src: src, name: path.Join("supplier", name), size: 15})
+	}
+	manifestFile := path.Join(dir, manifestFileName)
+	if err := ioutil.WriteFile(manifestFile, []byte("url,file\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	manifest := &archiveEntry{src: manifestFile, name: manifestFileName, size: 9}
+
+	tests := []struct {
+		name   string
+		format string
+		output string
+		want   map[string][]string
+	}{
+		{
+			name:   "zip",
+			format: ArchiveFormatZip,
+			output: "out.zip",
+			want: map[string][]string{
+				"out_part1.zip": {"manifest.csv", "supplier/a.pdf", "supplier/b.pdf"},
+				"out_part2.zip": {"manifest.csv", "supplier/c.pdf"},
+			},
+		},
+		{
+			name:   "tar.gz",
+			format: ArchiveFormatTarGz,
+			output: "out.tar.gz",
+			want: map[string][]string{
+				"out_part1.tar.gz": {"manifest.csv", "supplier/a.pdf", "supplier/b.pdf"},
+				"out_part2.tar.gz": {"manifest.csv", "supplier/c.pdf"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := &ArchiveOptions{Format: tt.format, VolumeSize: 30}
+			files, err := compress(entries, manifest, path.Join(dir, tt.output), opts)
+			if err != nil {
+				t.Fatalf("compress() error = %v", err)
+			}
+
+			got := make(map[string][]string)
+			for _, f := range files {
+				got[path.Base(f)] = archiveNames(t, f, tt.format)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("compress() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func archiveNames(t *testing.T, file, format string) []string {
+	var names []string
+	if format == ArchiveFormatZip {
+		r, err := zip.OpenReader(file)
+		if err != nil {
+			t.Fatalf("open zip failed, err:%v", err)
+		}
+		defer r.Close()
+		for _, f := range r.File {
+			names = append(names, f.Name)
+		}
+	} else {
+		f, err := os.Open(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatalf("open gzip failed, err:%v", err)
+		}
+		tr := tar.NewReader(gz)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("read tar failed, err:%v", err)
+			}
+			names = append(names, hdr.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,282 @@
+package linktopdf
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"invtools/pkg/util"
+	"invtools/utils/errors"
+)
+
+const (
+	DefaultDownloadTimeout = 60 * time.Second
+	DefaultRetries         = 3
+
+	// defaultBackoff 第一次重试前的等待时间, 之后每次翻倍
+	defaultBackoff = 500 * time.Millisecond
+	// maxBackoff 重试等待时间的上限, 包括服务端返回的Retry-After
+	maxBackoff = 30 * time.Second
+
+	// pdfTailSize 在文件末尾多少字节内查找%%EOF, 部分pdf在%%EOF之后还有换行或垃圾数据
+	pdfTailSize = 1024
+)
+
+var (
+	pdfMagic = []byte("%PDF-")
+	pdfEOF   = []byte("%%EOF")
+)
+
+// Downloader 请求url, 返回内容是pdf时直接保存, 否则交给打印工具渲染.
+// 网络错误、5xx和429会按指数退避重试, 同一个host的请求按rateLimit限速
+type Downloader struct {
+	client  *http.Client
+	req     *util.RequestOptions
+	retries int
+	backoff time.Duration
+	limiter *hostLimiter
+}
+
+// NewDownloader timeout: 单次请求的超时时间, retries: 失败后的重试次数, rateLimit: 每个host每秒最多请求几次, 0为不限制
+func NewDownloader(req *util.RequestOptions, timeout time.Duration, retries int, rateLimit float64) (*Downloader, error) {
+	if timeout <= 0 {
+		timeout = DefaultDownloadTimeout
+	}
+	if retries < 0 {
+		retries = 0
+	}
+
+	client, err := req.HTTPClient(timeout)
+	if err != nil {
+		return nil, errors.Errorf(err, "create http client failed")
+	}
+
+	return &Downloader{
+		client:  client,
+		req:     req,
+		retries: retries,
+		backoff: defaultBackoff,
+		limiter: newHostLimiter(rateLimit),
+	}, nil
+}
+
+// attempt 单次请求的结果
+type attempt struct {
+	isPdf      bool
+	retry      bool          // 失败后是否可以重试
+	retryAfter time.Duration // 服务端要求的重试等待时间
+	err        error
+}
+
+// Download 请求u, 内容是pdf时保存到filePath并返回true; 内容不是pdf(例如html)时返回false, 不保存
+func (d *Downloader) Download(u, filePath string) (bool, error) {
+	var last attempt
+	for i := 0; i <= d.retries; i++ {
+		if i > 0 {
+			time.Sleep(d.wait(i, last.retryAfter))
+		}
+
+		last = d.try(u, filePath)
+		if last.err == nil {
+			return last.isPdf, nil
+		}
+		if !last.retry {
+			return false, last.err
+		}
+	}
+	return false, errors.Errorf(last.err, "重试%d次后仍然失败", d.retries)
+}
+
+// wait 第n次重试前的等待时间: backoff * 2^(n-1), 服务端返回了Retry-After时取两者中较大的
+func (d *Downloader) wait(n int, retryAfter time.Duration) time.Duration {
+	w := d.backoff << uint(n-1)
+	if retryAfter > w {
+		w = retryAfter
+	}
+	if w > maxBackoff {
+		w = maxBackoff
+	}
+	return w
+}
+
+func (d *Downloader) try(u, filePath string) attempt {
+	URL, err := url.Parse(u)
+	if err != nil || URL.Host == "" {
+		return attempt{err: errors.Errorf(err, "url不合法,url:[%s]", u)}
+	}
+	d.limiter.Wait(URL.Host)
+
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return attempt{err: errors.Errorf(err, "创建http请求失败,url:%s", u)}
+	}
+	d.req.Apply(req)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return attempt{retry: true, err: errors.Errorf(err, "http请求URL失败,url:%s", u)}
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError:
+		return attempt{
+			retry:      true,
+			retryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+			err:        errors.Errorf(nil, "http状态码:%d,url:%s", resp.StatusCode, u),
+		}
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return attempt{err: errors.Errorf(nil, "http状态码:%d,url:%s", resp.StatusCode, u)}
+	}
+
+	body := bufio.NewReader(resp.Body)
+	head, _ := body.Peek(len(pdfMagic))
+	if !isPdfContent(resp.Header.Get("Content-Type"), head) {
+		return attempt{isPdf: false}
+	}
+
+	if retry, err := savePdf(body, filePath); err != nil {
+		return attempt{retry: retry, err: errors.Errorf(err, "保存pdf失败,url:%s", u)}
+	}
+	return attempt{isPdf: true}
+}
+
+// isPdfContent 以文件头为准, 有些服务端返回pdf时Content-Type为application/octet-stream;
+// Content-Type为pdf但文件头不对时也当作pdf处理, 保存后的校验会失败
+func isPdfContent(contentType string, head []byte) bool {
+	if bytes.HasPrefix(head, pdfMagic) {
+		return true
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == "application/pdf"
+}
+
+// savePdf 先写入临时文件, 校验通过后再重命名, 避免留下不完整的pdf.
+// 只有读取数据流时连接中断、内容被截断才返回retry为true, 校验失败和本地文件错误重试也不会成功
+func savePdf(r io.Reader, filePath string) (retry bool, err error) {
+	tmpPath := filePath + ".part"
+	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0644)
+	if err != nil {
+		return false, errors.Errorf(err, "打开文件失败,filePath:%s", tmpPath)
+	}
+
+	src := &readErrReader{r: r}
+	_, err = io.Copy(f, src)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		if src.err != nil {
+			return true, errors.Errorf(src.err, "读取数据流失败,连接中断或内容不完整")
+		}
+		return false, errors.Errorf(err, "写入数据流到文件失败")
+	}
+
+	if err := ValidatePdf(tmpPath); err != nil {
+		os.Remove(tmpPath)
+		return false, errors.Errorf(err, "pdf校验失败")
+	}
+
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		os.Remove(tmpPath)
+		return false, errors.Errorf(err, "重命名文件失败,filePath:%s", filePath)
+	}
+	return false, nil
+}
+
+// readErrReader 记录读取时的错误, 用于区分连接中断和写入本地文件失败
+type readErrReader struct {
+	r   io.Reader
+	err error
+}
+
+func (r *readErrReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err != nil && err != io.EOF {
+		r.err = err
+	}
+	return n, err
+}
+
+// ValidatePdf 校验文件以%PDF-开头, 并且末尾有%%EOF
+func ValidatePdf(filePath string) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return errors.Errorf(err, "打开文件失败,filePath:%s", filePath)
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return errors.Errorf(err, "get file stat failed")
+	}
+
+	head := make([]byte, len(pdfMagic))
+	if _, err := io.ReadFull(f, head); err != nil || !bytes.Equal(head, pdfMagic) {
+		return errors.Errorf(err, "不是pdf文件,文件头:%q", head)
+	}
+
+	offset := fi.Size() - pdfTailSize
+	if offset < 0 {
+		offset = 0
+	}
+	tail := make([]byte, fi.Size()-offset)
+	if _, err := f.ReadAt(tail, offset); err != nil && err != io.EOF {
+		return errors.Errorf(err, "读取文件末尾失败")
+	}
+	if !bytes.Contains(tail, pdfEOF) {
+		return errors.Errorf(nil, "pdf文件不完整,末尾没有%%EOF")
+	}
+	return nil
+}
+
+// parseRetryAfter 只支持秒数格式
+func parseRetryAfter(v string) time.Duration {
+	secs, err := strconv.Atoi(v)
+	if err != nil || secs < 0 {
+		return 0
+	}
+	return time.Duration(secs) * time.Second
+}
+
+// hostLimiter 同一个host的两次请求之间至少间隔interval
+type hostLimiter struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	next map[string]time.Time // 每个host下一次可以请求的时间
+}
+
+func newHostLimiter(rate float64) *hostLimiter {
+	l := &hostLimiter{next: make(map[string]time.Time)}
+	if rate > 0 {
+		l.interval = time.Duration(float64(time.Second) / rate)
+	}
+	return l
+}
+
+// Wait 阻塞到可以请求host为止
+func (l *hostLimiter) Wait(host string) {
+	if l.interval <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	t := l.next[host]
+	if t.Before(now) {
+		t = now
+	}
+	l.next[host] = t.Add(l.interval)
+	l.mu.Unlock()
+
+	time.Sleep(t.Sub(now))
+}
@@ -0,0 +1,148 @@
+package linktopdf
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testPdfContent = "%PDF-1.4\n1 0 obj\n<<>>\nendobj\ntrailer\n<<>>\n%%EOF\n"
+
+func TestDownloaderDownload(t *testing.T) {
+	var hits int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/voucher.pdf", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write([]byte(testPdfContent))
+	})
+	mux.HandleFunc("/octet", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write([]byte(testPdfContent))
+	})
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte("<html><body>voucher</body></html>"))
+	})
+	mux.HandleFunc("/missing.pdf", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		http.Error(w, "<html>not found</html>", http.StatusNotFound)
+	})
+	mux.HandleFunc("/flaky.pdf", func(w http.ResponseWriter, r *http.Request) {
+		// 前两次返回503
+		if atomic.AddInt32(&hits, 1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(testPdfContent))
+	})
+	mux.HandleFunc("/truncated.pdf", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write([]byte("%PDF-1.4\n1 0 obj\n"))
+	})
+	mux.HandleFunc("/cut.pdf", func(w http.ResponseWriter, r *http.Request) {
+		// Content-Length比实际内容长, 模拟传输中连接断开
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Length", strconv.Itoa(len(testPdfContent)))
+		w.Write([]byte("%PDF-1.4\n1 0 obj\n"))
+	})
+	mux.HandleFunc("/fake.pdf", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write([]byte("<html>error</html>"))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "downloader")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name      string
+		path      string
+		wantPdf   bool
+		wantErr   bool
+		wantHits  int32
+		wantSaved bool
+		dir       string // 保存目录, 为空时使用临时目录
+	}{
+		{name: "pdf", path: "/voucher.pdf", wantPdf: true, wantSaved: true},
+		{name: "sniff magic bytes", path: "/octet", wantPdf: true, wantSaved: true},
+		{name: "html page", path: "/page"},
+		{name: "404 not retried", path: "/missing.pdf", wantErr: true, wantHits: 1},
+		{name: "retry 503", path: "/flaky.pdf", wantPdf: true, wantHits: 3, wantSaved: true},
+		{name: "missing eof not retried", path: "/truncated.pdf", wantErr: true, wantHits: 1},
+		{name: "connection cut retried", path: "/cut.pdf", wantErr: true, wantHits: 3},
+		{name: "content type pdf but html", path: "/fake.pdf", wantErr: true, wantHits: 1},
+		{name: "local error not retried", path: "/voucher.pdf", dir: "missing", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			atomic.StoreInt32(&hits, 0)
+			d, err := NewDownloader(nil, time.Second, 2, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			d.backoff = time.Millisecond
+
+			file := path.Join(dir, tt.dir, path.Base(tt.path)+".pdf")
+			isPdf, err := d.Download(srv.URL+tt.path, file)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Download() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if isPdf != tt.wantPdf {
+				t.Errorf("Download() isPdf = %v, want %v", isPdf, tt.wantPdf)
+			}
+			if tt.wantHits > 0 && atomic.LoadInt32(&hits) != tt.wantHits {
+				t.Errorf("server hits = %d, want %d", hits, tt.wantHits)
+			}
+			if _, err := os.Stat(file); (err == nil) != tt.wantSaved {
+				t.Errorf("file saved = %v, want %v", err == nil, tt.wantSaved)
+			}
+			if _, err := os.Stat(file + ".part"); err == nil {
+				t.Errorf("temporary file should be removed")
+			}
+		})
+	}
+}
+
+func TestDownloaderWait(t *testing.T) {
+	d := &Downloader{backoff: 100 * time.Millisecond}
+	tests := []struct {
+		n          int
+		retryAfter time.Duration
+		want       time.Duration
+	}{
+		{n: 1, want: 100 * time.Millisecond},
+		{n: 3, want: 400 * time.Millisecond},
+		{n: 1, retryAfter: 2 * time.Second, want: 2 * time.Second},
+		{n: 20, want: maxBackoff},
+	}
+	for _, tt := range tests {
+		if got := d.wait(tt.n, tt.retryAfter); got != tt.want {
+			t.Errorf("wait(%d, %s) = %s, want %s", tt.n, tt.retryAfter, got, tt.want)
+		}
+	}
+}
+
+func TestHostLimiter(t *testing.T) {
+	l := newHostLimiter(20) // 每次间隔50ms
+	st := time.Now()
+	for i := 0; i < 3; i++ {
+		l.Wait("a.com")
+	}
+	l.Wait("b.com")
+	if elapsed := time.Since(st); elapsed < 100*time.Millisecond || elapsed > time.Second {
+		t.Errorf("3 requests to the same host took %s, want about 100ms", elapsed)
+	}
+}
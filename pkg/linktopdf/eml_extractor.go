@@ -0,0 +1,185 @@
+package linktopdf
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"invtools/common"
+	"invtools/utils"
+	"invtools/utils/errors"
+)
+
+// EmlExtractor 解析邮件导出的.eml文件, 提取正文中的链接和pdf附件, pdf附件直接保存, 不需要打印
+type EmlExtractor struct {
+	Input   string
+	Pattern *regexp.Regexp
+}
+
+// emlContent 邮件中提取到的内容
+type emlContent struct {
+	links       []string
+	attachments []emlAttachment
+	seen        map[string]struct{}
+}
+
+type emlAttachment struct {
+	name string
+	data []byte
+}
+
+func (e *EmlExtractor) Extract() ([]*LinkRecord, error) {
+	if ok := utils.CheckFileIsExist(e.Input); !ok {
+		return nil, errors.Errorf(nil, "input file does not exists!")
+	}
+
+	f, err := os.Open(e.Input)
+	if err != nil {
+		return nil, errors.Errorf(err, "open eml file failed")
+	}
+	defer f.Close()
+
+	msg, err := mail.ReadMessage(f)
+	if err != nil {
+		return nil, errors.Errorf(err, "parse eml file failed")
+	}
+
+	var dec mime.WordDecoder
+	subject, err := dec.DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		subject = msg.Header.Get("Subject")
+	}
+
+	c := &emlContent{seen: make(map[string]struct{})}
+	if err := c.parsePart(textproto.MIMEHeader(msg.Header), msg.Body, e.Pattern); err != nil {
+		return nil, errors.Errorf(err, "parse eml body failed")
+	}
+
+	var (
+		records     []*LinkRecord
+		linkColumns = []string{FieldURL, FieldSubject}
+		attColumns  = []string{FieldURL, FieldSubject, FieldAttachment}
+	)
+	for _, l := range c.links {
+		records = append(records, &LinkRecord{
+			Row:     len(records) + 1,
+			URL:     l,
+			Fields:  map[string]string{FieldURL: l, FieldSubject: subject},
+			Columns: linkColumns,
+		})
+	}
+	for _, a := range c.attachments {
+		u := attachmentScheme + a.name
+		records = append(records, &LinkRecord{
+			Row:        len(records) + 1,
+			URL:        u,
+			Fields:     map[string]string{FieldURL: u, FieldSubject: subject, FieldAttachment: a.name},
+			Columns:    attColumns,
+			Attachment: a.data,
+		})
+	}
+
+	checkRepeatedLinks(records)
+	return records, nil
+}
+
+// parsePart 递归解析multipart, text/html和text/plain提取链接, pdf附件保存内容
+func (c *emlContent) parsePart(header textproto.MIMEHeader, body io.Reader, pattern *regexp.Regexp) error {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		// 没有Content-Type时按纯文本处理
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			p, err := mr.NextPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return errors.Errorf(err, "read multipart failed")
+			}
+			if err := c.parsePart(p.Header, p, pattern); err != nil {
+				return err
+			}
+		}
+	}
+
+	data, err := ioutil.ReadAll(decodeTransferEncoding(header.Get("Content-Transfer-Encoding"), body))
+	if err != nil {
+		return errors.Errorf(err, "read mime part failed, content-type:%s", mediaType)
+	}
+
+	if name := attachmentName(header, params); mediaType == "application/pdf" ||
+		strings.EqualFold(path.Ext(name), common.ExtPDF) {
+		if name == "" {
+			name = "attachment" + common.ExtPDF
+		}
+		c.attachments = append(c.attachments, emlAttachment{name: name, data: data})
+		return nil
+	}
+
+	switch mediaType {
+	case "text/html":
+		for _, l := range extractHTMLLinks(string(data), pattern) {
+			c.addLink(l.URL)
+		}
+	case "text/plain":
+		for _, l := range extractPlainLinks(string(data), pattern) {
+			c.addLink(l)
+		}
+	}
+	return nil
+}
+
+// addLink html和纯文本正文中通常是同样的链接, 只保留一个
+func (c *emlContent) addLink(l string) {
+	if _, ok := c.seen[l]; ok {
+		return
+	}
+	c.seen[l] = struct{}{}
+	c.links = append(c.links, l)
+}
+
+// decodeTransferEncoding multipart.Reader只会自动解码quoted-printable, 这里统一处理
+func decodeTransferEncoding(encoding string, r io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		// base64内容每行有换行, 需要去掉
+		b, _ := ioutil.ReadAll(r)
+		b = bytes.Join(bytes.Fields(b), nil)
+		return base64.NewDecoder(base64.StdEncoding, bytes.NewReader(b))
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	}
+	return r
+}
+
+// attachmentName 附件的文件名, 依次取Content-Disposition的filename和Content-Type的name
+func attachmentName(header textproto.MIMEHeader, params map[string]string) string {
+	name := params["name"]
+	if _, dp, err := mime.ParseMediaType(header.Get("Content-Disposition")); err == nil && dp["filename"] != "" {
+		name = dp["filename"]
+	}
+
+	var dec mime.WordDecoder
+	if decoded, err := dec.DecodeHeader(name); err == nil {
+		name = decoded
+	}
+	if name == "" {
+		return ""
+	}
+	return path.Base(strings.Replace(name, "\\", "/", -1))
+}
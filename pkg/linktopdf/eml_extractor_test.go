@@ -0,0 +1,100 @@
+package linktopdf
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestEmlExtractor_Extract(t *testing.T) {
+	dir, err := ioutil.TempDir("", "linktopdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	pdf := "%PDF-1.4\n%%EOF\n"
+	eml := strings.Join([]string{
+		"Subject: =?UTF-8?B?6K6i5Y2V?=",
+		"MIME-Version: 1.0",
+		`Content-Type: multipart/mixed; boundary="mixed"`,
+		"",
+		"--mixed",
+		`Content-Type: multipart/alternative; boundary="alt"`,
+		"",
+		"--alt",
+		"Content-Type: text/plain; charset=utf-8",
+		"",
+		"Voucher: https://a.com/voucher/1. Help: https://a.com/help",
+		"--alt",
+		"Content-Type: text/html; charset=utf-8",
+		"Content-Transfer-Encoding: quoted-printable",
+		"",
+		`<a href=3D"https://a.com/voucher/1">v1</a><a href=3D"https://a.com/voucher/2?a=3D1&amp;b=3D2">v2</a>`,
+		"--alt--",
+		"--mixed",
+		`Content-Type: application/octet-stream; name="ticket.pdf"`,
+		"Content-Transfer-Encoding: base64",
+		`Content-Disposition: attachment; filename="ticket.pdf"`,
+		"",
+		base64.StdEncoding.EncodeToString([]byte(pdf)),
+		"--mixed--",
+		"",
+	}, "\r\n")
+	input := path.Join(dir, "booking.eml")
+	if err := ioutil.WriteFile(input, []byte(eml), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		pattern  *regexp.Regexp
+		wantURLs []string
+	}{
+		{
+			name:     "all links",
+			wantURLs: []string{"https://a.com/voucher/1", "https://a.com/help", "https://a.com/voucher/2?a=1&b=2", "attachment:ticket.pdf"},
+		},
+		{
+			name:     "pattern",
+			pattern:  regexp.MustCompile(`voucher`),
+			wantURLs: []string{"https://a.com/voucher/1", "https://a.com/voucher/2?a=1&b=2", "attachment:ticket.pdf"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := NewLinkExtractor(input, &ExtractOptions{LinkPattern: tt.pattern})
+			if err != nil {
+				t.Fatalf("NewLinkExtractor() error = %v", err)
+			}
+			got, err := e.Extract()
+			if err != nil {
+				t.Fatalf("Extract() error = %v", err)
+			}
+
+			var urls []string
+			for _, rec := range got {
+				urls = append(urls, rec.URL)
+				if rec.Fields[FieldSubject] != "订单" {
+					t.Errorf("Extract() subject = %s, want 订单", rec.Fields[FieldSubject])
+				}
+			}
+			if !reflect.DeepEqual(urls, tt.wantURLs) {
+				t.Errorf("Extract() urls = %v, want %v", urls, tt.wantURLs)
+			}
+
+			att := got[len(got)-1]
+			if string(att.Attachment) != pdf {
+				t.Errorf("Extract() attachment = %q, want %q", att.Attachment, pdf)
+			}
+			if name, _ := defaultFileName(att, nil); name != "ticket.pdf" {
+				t.Errorf("defaultFileName() = %s, want ticket.pdf", name)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package linktopdf
+
+import (
+	"html"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"invtools/utils"
+	"invtools/utils/errors"
+)
+
+var (
+	// anchorRe <a href="...">text</a>, 只支持带引号的href
+	anchorRe = regexp.MustCompile(`(?is)<a\s[^>]*?href\s*=\s*(?:"([^"]*)"|'([^']*)')[^>]*>(.*?)</a>`)
+	// tagRe 去掉链接文字中的标签
+	tagRe = regexp.MustCompile(`(?s)<[^>]*>`)
+	// plainLinkRe 纯文本中的链接
+	plainLinkRe = regexp.MustCompile(`https?://[^\s<>"']+`)
+)
+
+// htmlLink html中的一个链接
+type htmlLink struct {
+	URL  string
+	Text string
+}
+
+// HTMLExtractor 提取html中<a href>的链接, 例如订单页面另存为的html
+type HTMLExtractor struct {
+	Input   string
+	Pattern *regexp.Regexp
+}
+
+func (e *HTMLExtractor) Extract() ([]*LinkRecord, error) {
+	if ok := utils.CheckFileIsExist(e.Input); !ok {
+		return nil, errors.Errorf(nil, "input file does not exists!")
+	}
+
+	b, err := ioutil.ReadFile(e.Input)
+	if err != nil {
+		return nil, errors.Errorf(err, "read html file failed")
+	}
+
+	var (
+		records []*LinkRecord
+		columns = []string{FieldURL, FieldText}
+	)
+	for _, l := range extractHTMLLinks(string(b), e.Pattern) {
+		records = append(records, &LinkRecord{
+			Row:     len(records) + 1,
+			URL:     l.URL,
+			Fields:  map[string]string{FieldURL: l.URL, FieldText: l.Text},
+			Columns: columns,
+		})
+	}
+
+	checkRepeatedLinks(records)
+	return records, nil
+}
+
+// extractHTMLLinks 只保留http(s)链接, pattern不为nil时只保留匹配的链接, 同一个链接只保留第一次出现的
+func extractHTMLLinks(content string, pattern *regexp.Regexp) []htmlLink {
+	var (
+		links []htmlLink
+		seen  = make(map[string]struct{})
+	)
+	for _, m := range anchorRe.FindAllStringSubmatch(content, -1) {
+		href := m[1]
+		if href == "" {
+			href = m[2]
+		}
+		href = strings.TrimSpace(html.UnescapeString(href))
+		if !matchLink(href, pattern) {
+			continue
+		}
+		if _, ok := seen[href]; ok {
+			continue
+		}
+		seen[href] = struct{}{}
+
+		text := html.UnescapeString(tagRe.ReplaceAllString(m[3], ""))
+		links = append(links, htmlLink{URL: href, Text: strings.Join(strings.Fields(text), " ")})
+	}
+	return links
+}
+
+// extractPlainLinks 提取纯文本中的链接
+func extractPlainLinks(content string, pattern *regexp.Regexp) []string {
+	var (
+		links []string
+		seen  = make(map[string]struct{})
+	)
+	for _, l := range plainLinkRe.FindAllString(content, -1) {
+		// 句末的标点不属于链接
+		l = strings.TrimRight(l, ".,;:!?)]")
+		if !matchLink(l, pattern) {
+			continue
+		}
+		if _, ok := seen[l]; ok {
+			continue
+		}
+		seen[l] = struct{}{}
+		links = append(links, l)
+	}
+	return links
+}
+
+func matchLink(link string, pattern *regexp.Regexp) bool {
+	lower := strings.ToLower(link)
+	if !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") {
+		return false
+	}
+	return pattern == nil || pattern.MatchString(link)
+}
@@ -0,0 +1,198 @@
+package linktopdf
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"invtools/utils"
+	"invtools/utils/errors"
+)
+
+// jsonWildcard [*] 匹配数组的所有元素或对象的所有值
+const jsonWildcard = -1
+
+// jsonPathToken json路径中的一段, key不为空时取对象的字段, 否则取数组下标
+type jsonPathToken struct {
+	key   string
+	index int
+}
+
+// JSONExtractor 按json路径提取链接, 链接所在对象的其他字段作为命名模板可以使用的列
+type JSONExtractor struct {
+	Input string
+
+	selector []jsonPathToken
+}
+
+func (e *JSONExtractor) Extract() ([]*LinkRecord, error) {
+	if ok := utils.CheckFileIsExist(e.Input); !ok {
+		return nil, errors.Errorf(nil, "input file does not exists!")
+	}
+
+	f, err := os.Open(e.Input)
+	if err != nil {
+		return nil, errors.Errorf(err, "open json file failed")
+	}
+	defer f.Close()
+
+	var v interface{}
+	d := json.NewDecoder(f)
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil {
+		return nil, errors.Errorf(err, "decode json file failed")
+	}
+
+	var records []*LinkRecord
+	add := func(value interface{}, parent map[string]interface{}) {
+		s, ok := value.(string)
+		if s = strings.TrimSpace(s); !ok || !matchLink(s, nil) {
+			return
+		}
+		rec := recordFromJSONObject(parent)
+		rec.Row = len(records) + 1
+		rec.URL = s
+		if _, ok := rec.Fields[FieldURL]; !ok {
+			rec.Fields[FieldURL] = rec.URL
+			rec.Columns = append(rec.Columns, FieldURL)
+		}
+		records = append(records, rec)
+	}
+
+	if len(e.selector) == 0 {
+		walkJSONStrings(v, nil, add)
+	} else {
+		selectJSON(v, nil, e.selector, add)
+	}
+
+	checkRepeatedLinks(records)
+	return records, nil
+}
+
+// parseJSONPath 支持 $.a.b[0].c[*].d 形式的路径, 开头的$可以省略, 为空时返回nil
+func parseJSONPath(p string) ([]jsonPathToken, error) {
+	p = strings.TrimPrefix(strings.TrimSpace(p), "$")
+	if p == "" {
+		return nil, nil
+	}
+
+	var tokens []jsonPathToken
+	for _, seg := range strings.Split(strings.TrimPrefix(p, "."), ".") {
+		key := seg
+		var indexes []string
+		if i := strings.Index(seg, "["); i >= 0 {
+			key = seg[:i]
+			for _, idx := range strings.Split(seg[i:], "]") {
+				if idx == "" {
+					continue
+				}
+				if !strings.HasPrefix(idx, "[") {
+					return nil, errors.Errorf(nil, "json路径格式不合法:%s", seg)
+				}
+				indexes = append(indexes, idx[1:])
+			}
+		}
+		if key == "" && len(indexes) == 0 {
+			return nil, errors.Errorf(nil, "json路径中有空的字段:%s", p)
+		}
+
+		if key != "" {
+			tokens = append(tokens, jsonPathToken{key: key})
+		}
+		for _, idx := range indexes {
+			if idx == "*" {
+				tokens = append(tokens, jsonPathToken{index: jsonWildcard})
+				continue
+			}
+			n, err := strconv.Atoi(idx)
+			if err != nil || n < 0 {
+				return nil, errors.Errorf(err, "json路径中的下标不合法:%s", seg)
+			}
+			tokens = append(tokens, jsonPathToken{index: n})
+		}
+	}
+	return tokens, nil
+}
+
+// selectJSON 按路径查找, parent为当前值所在的对象
+func selectJSON(v interface{}, parent map[string]interface{}, tokens []jsonPathToken, fn func(value interface{}, parent map[string]interface{})) {
+	if len(tokens) == 0 {
+		fn(v, parent)
+		return
+	}
+
+	t, rest := tokens[0], tokens[1:]
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if t.key != "" {
+			if child, ok := v[t.key]; ok {
+				selectJSON(child, v, rest, fn)
+			}
+		} else if t.index == jsonWildcard {
+			for _, k := range sortedKeys(v) {
+				selectJSON(v[k], v, rest, fn)
+			}
+		}
+	case []interface{}:
+		switch {
+		case t.key != "":
+		case t.index == jsonWildcard:
+			for _, child := range v {
+				selectJSON(child, parent, rest, fn)
+			}
+		case t.index < len(v):
+			selectJSON(v[t.index], parent, rest, fn)
+		}
+	}
+}
+
+// walkJSONStrings 没有指定路径时遍历所有字符串
+func walkJSONStrings(v interface{}, parent map[string]interface{}, fn func(value interface{}, parent map[string]interface{})) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for _, k := range sortedKeys(v) {
+			walkJSONStrings(v[k], v, fn)
+		}
+	case []interface{}:
+		for _, child := range v {
+			walkJSONStrings(child, parent, fn)
+		}
+	default:
+		fn(v, parent)
+	}
+}
+
+// recordFromJSONObject 对象中的字符串、数字和布尔值作为列, 列名按字母排序
+func recordFromJSONObject(obj map[string]interface{}) *LinkRecord {
+	rec := &LinkRecord{Fields: make(map[string]string)}
+	for _, k := range sortedKeys(obj) {
+		var value string
+		switch v := obj[k].(type) {
+		case string:
+			value = strings.TrimSpace(v)
+		case json.Number, bool:
+			value = fmt.Sprint(v)
+		default:
+			continue
+		}
+		name := normalizeColumnName(k)
+		if _, ok := rec.Fields[name]; ok {
+			continue
+		}
+		rec.Fields[name] = value
+		rec.Columns = append(rec.Columns, name)
+	}
+	return rec
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,82 @@
+package linktopdf
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+)
+
+func TestJSONExtractor_Extract(t *testing.T) {
+	dir, err := ioutil.TempDir("", "linktopdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	input := path.Join(dir, "orders.json")
+	content := `{"data": {"orders": [
+		{"order_id": "A001", "amount": 12.5, "voucher_url": "https://a.com/1", "items": [1, 2]},
+		{"order_id": "A002", "voucher_url": "https://a.com/2", "note": "see http://b.com"}
+	]}}`
+	if err := ioutil.WriteFile(input, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		jsonPath   string
+		wantURLs   []string
+		wantFields map[string]string // 第一条记录的字段
+		wantErr    bool
+	}{
+		{
+			name:       "wildcard",
+			jsonPath:   "$.data.orders[*].voucher_url",
+			wantURLs:   []string{"https://a.com/1", "https://a.com/2"},
+			wantFields: map[string]string{"order_id": "A001", "amount": "12.5", "voucher_url": "https://a.com/1", "url": "https://a.com/1"},
+		},
+		{
+			name:       "index",
+			jsonPath:   "data.orders[1].voucher_url",
+			wantURLs:   []string{"https://a.com/2"},
+			wantFields: map[string]string{"order_id": "A002", "note": "see http://b.com", "voucher_url": "https://a.com/2", "url": "https://a.com/2"},
+		},
+		{
+			name:     "all http strings",
+			wantURLs: []string{"https://a.com/1", "https://a.com/2"},
+		},
+		{
+			name:     "invalid index",
+			jsonPath: "$.data.orders[x].voucher_url",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := NewLinkExtractor(input, &ExtractOptions{JSONPath: tt.jsonPath})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewLinkExtractor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			got, err := e.Extract()
+			if err != nil {
+				t.Fatalf("Extract() error = %v", err)
+			}
+			var urls []string
+			for _, rec := range got {
+				urls = append(urls, rec.URL)
+			}
+			if !reflect.DeepEqual(urls, tt.wantURLs) {
+				t.Errorf("Extract() urls = %v, want %v", urls, tt.wantURLs)
+			}
+			if tt.wantFields != nil && !reflect.DeepEqual(got[0].Fields, tt.wantFields) {
+				t.Errorf("Extract() fields = %v, want %v", got[0].Fields, tt.wantFields)
+			}
+		})
+	}
+}
@@ -3,9 +3,11 @@ package linktopdf
 import (
 	"encoding/csv"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path"
+	"regexp"
 	"strings"
 
 	"invtools/common"
@@ -18,26 +20,76 @@ import (
 	"github.com/tealeg/xlsx"
 )
 
+const (
+	// FieldURL 链接所在列的列名, 也可以在命名模板中使用
+	FieldURL = "url"
+	// FieldRow 行号, 只在命名模板中使用
+	FieldRow = "row"
+	// FieldText html链接的文字
+	FieldText = "text"
+	// FieldSubject 邮件主题
+	FieldSubject = "subject"
+	// FieldAttachment 邮件中pdf附件的文件名
+	FieldAttachment = "attachment"
+
+	// attachmentScheme 邮件附件没有url, 用 attachment:文件名 代替
+	attachmentScheme = "attachment:"
+)
+
+// LinkRecord 输入文件中的一行
+type LinkRecord struct {
+	Row     int               // 在输入文件中的行号, 从1开始
+	URL     string            // 链接
+	Fields  map[string]string // 每一列的值, 有表头时key为列名(小写, 空格替换为_), 没有表头时为col1, col2...
+	Columns []string          // 列名, 按输入文件中的顺序
+
+	Attachment []byte // 邮件中的pdf附件, 不为空时直接保存, 不请求URL
+
+	FileName string // 输出的pdf文件名, 打印之前按输入顺序分配
+}
+
 // LinkExtractor extracts links from input file
 type LinkExtractor interface {
-	Extract() ([]string, error)
+	Extract() ([]*LinkRecord, error)
+}
+
+// ExtractOptions json/html/eml文件的提取选项
+type ExtractOptions struct {
+	JSONPath    string         // json中链接的路径, 例如: $.data.orders[*].voucher_url, 为空时提取所有http链接
+	LinkPattern *regexp.Regexp // 只保留匹配的html/eml链接, 为nil时保留所有http链接
 }
 
-func NewLinkExtractor(input string) (LinkExtractor, error) {
+// NewLinkExtractor input为"-"时从标准输入读取, 每行一个链接
+func NewLinkExtractor(input string, opts *ExtractOptions) (LinkExtractor, error) {
 	if input == "" {
 		return nil, errors.Errorf(nil, "input file is empty")
 	}
+	if opts == nil {
+		opts = &ExtractOptions{}
+	}
 
 	var e LinkExtractor
 
-	ext := path.Ext(input)
-	switch ext {
-	case common.ExtCsv:
+	ext := strings.ToLower(path.Ext(input))
+	switch {
+	case input == common.StdinInput:
+		e = &StdinExtractor{os.Stdin}
+	case ext == common.ExtCsv:
 		e = &CsvExtractor{input}
-	case common.ExtExecl:
+	case ext == common.ExtExecl:
 		e = &ExcelExtractor{input}
-	case common.ExtTxt:
+	case ext == common.ExtTxt:
 		e = &TxtExtractor{input}
+	case ext == common.ExtJSON:
+		selector, err := parseJSONPath(opts.JSONPath)
+		if err != nil {
+			return nil, errors.Errorf(err, "json path不合法")
+		}
+		e = &JSONExtractor{Input: input, selector: selector}
+	case ext == common.ExtHtml || ext == common.ExtHtm:
+		e = &HTMLExtractor{Input: input, Pattern: opts.LinkPattern}
+	case ext == common.ExtEml:
+		e = &EmlExtractor{Input: input, Pattern: opts.LinkPattern}
 	default:
 		return nil, errors.Errorf(nil, "unsupported file extension:%s", ext)
 	}
@@ -49,116 +101,220 @@ type CsvExtractor struct {
 	Input string
 }
 
-func (e *CsvExtractor) Extract() (links []string, err error) {
+func (e *CsvExtractor) Extract() ([]*LinkRecord, error) {
 	if ok := utils.CheckFileIsExist(e.Input); !ok {
-		err = errors.Errorf(nil, "input file does not exists!")
-		return
+		return nil, errors.Errorf(nil, "input file does not exists!")
 	}
 
 	f, err := os.OpenFile(e.Input, os.O_RDONLY, 0644)
 	if err != nil {
-		err = errors.Errorf(err, "cannot open input file,err:%v", err)
-		return
+		return nil, errors.Errorf(err, "cannot open input file,err:%v", err)
 	}
 	defer f.Close()
 
 	r := csv.NewReader(f)
-	records, err := r.ReadAll()
+	// 每一行的列数可以不同
+	r.FieldsPerRecord = -1
+	rows, err := r.ReadAll()
 	if err != nil {
-		err = errors.Errorf(err, "csv readAll failed")
-		return
-	}
-
-	for _, record := range records {
-		if len(record) > 0 {
-			if record[0] != "" && strings.Contains(record[0], "http") {
-				links = append(links, record[0])
-			}
-		}
-	}
-
-	repeated := util.CheckRepeat(links)
-	if len(repeated) != 0 {
-		fmt.Printf("[linktopdf] 发现有重复的链接，请检查. 以下是重复链接\n%s\n", strings.Join(repeated, "\n"))
+		return nil, errors.Errorf(err, "csv readAll failed")
 	}
 
-	return
+	return recordsFromRows(rows), nil
 }
 
 type ExcelExtractor struct {
 	Input string
 }
 
-func (e *ExcelExtractor) Extract() (links []string, err error) {
+func (e *ExcelExtractor) Extract() ([]*LinkRecord, error) {
 	if ok := utils.CheckFileIsExist(e.Input); !ok {
-		err = errors.Errorf(nil, "input file does not exists!")
-		return
+		return nil, errors.Errorf(nil, "input file does not exists!")
 	}
 
 	// open excel
 	f, err := xlsx.OpenFile(e.Input)
 	if err != nil {
-		err = errors.Errorf(err, "xlsx open file failed")
-		return
+		return nil, errors.Errorf(err, "xlsx open file failed")
 	}
 
 	if len(f.Sheets) == 0 {
-		err = errors.Errorf(nil, "excel file has no sheet,please check!")
-		return
+		return nil, errors.Errorf(nil, "excel file has no sheet,please check!")
 	}
 
-	sheet := f.Sheets[0]
-	for _, row := range sheet.Rows {
-		if len(row.Cells) > 0 {
-			link := row.Cells[0].String()
-			if link != "" && strings.Contains(link, "http") {
-				links = append(links, link)
-			}
+	var rows [][]string
+	for _, row := range f.Sheets[0].Rows {
+		var cells []string
+		for _, cell := range row.Cells {
+			cells = append(cells, cell.String())
 		}
+		rows = append(rows, cells)
 	}
 
-	repeated := util.CheckRepeat(links)
-	if len(repeated) != 0 {
-		fmt.Printf("[linktopdf] 发现有重复的链接，请检查. 以下是重复链接\n%s\n", strings.Join(repeated, "\n"))
-	}
-
-	return
+	return recordsFromRows(rows), nil
 }
 
 type TxtExtractor struct {
 	Input string
 }
 
-func (e *TxtExtractor) Extract() (links []string, err error) {
+func (e *TxtExtractor) Extract() ([]*LinkRecord, error) {
 	if ok := utils.CheckFileIsExist(e.Input); !ok {
-		err = errors.Errorf(nil, "input file does not exists!")
-		return
+		return nil, errors.Errorf(nil, "input file does not exists!")
 	}
 
 	f, err := os.OpenFile(e.Input, os.O_RDONLY, 0644)
 	if err != nil {
-		err = errors.Errorf(err, "open txt file failed")
-		return
+		return nil, errors.Errorf(err, "open txt file failed")
 	}
 	defer f.Close()
 
-	b, err := ioutil.ReadAll(f)
+	return recordsFromLines(f)
+}
+
+// StdinExtractor 从标准输入读取链接, 格式与txt文件相同
+type StdinExtractor struct {
+	Reader io.Reader
+}
+
+func (e *StdinExtractor) Extract() ([]*LinkRecord, error) {
+	return recordsFromLines(e.Reader)
+}
+
+// recordsFromLines 每行一个链接, 忽略不包含http的行
+func recordsFromLines(r io.Reader) ([]*LinkRecord, error) {
+	b, err := ioutil.ReadAll(r)
 	if err != nil {
-		err = errors.Errorf(err, "read txt file failed")
-		return
+		return nil, errors.Errorf(err, "read links failed")
 	}
 
-	rows := strings.Split(string(b), "\n")
-	for _, row := range rows {
+	var (
+		records []*LinkRecord
+		columns = []string{FieldURL}
+	)
+	for i, row := range strings.Split(string(b), "\n") {
+		row = strings.TrimSpace(row)
 		if row != "" && strings.Contains(row, "http") {
-			links = append(links, row)
+			records = append(records, &LinkRecord{
+				Row:     i + 1,
+				URL:     row,
+				Fields:  map[string]string{FieldURL: row},
+				Columns: columns,
+			})
 		}
 	}
 
+	checkRepeatedLinks(records)
+	return records, nil
+}
+
+// recordsFromRows 表格的第一行中没有链接时作为表头, 链接所在列优先取表头为url/link的列, 否则取每行第一个包含http的单元格
+func recordsFromRows(rows [][]string) []*LinkRecord {
+	var (
+		records []*LinkRecord
+		header  []string
+		urlCol  = -1
+		start   int
+	)
+	if len(rows) > 0 && findLinkColumn(rows[0]) < 0 {
+		for _, h := range rows[0] {
+			header = append(header, normalizeColumnName(h))
+		}
+		for i, h := range header {
+			if h == FieldURL || h == "link" {
+				urlCol = i
+				break
+			}
+		}
+		start = 1
+	}
+
+	for i := start; i < len(rows); i++ {
+		row := rows[i]
+		col := urlCol
+		if col < 0 || col >= len(row) || !strings.Contains(row[col], "http") {
+			col = findLinkColumn(row)
+		}
+		if col < 0 {
+			continue
+		}
+
+		rec := &LinkRecord{
+			Row:    i + 1,
+			URL:    strings.TrimSpace(row[col]),
+			Fields: make(map[string]string),
+		}
+		for j, v := range row {
+			name := fmt.Sprintf("col%d", j+1)
+			if j < len(header) && header[j] != "" {
+				name = header[j]
+			}
+			rec.Fields[name] = strings.TrimSpace(v)
+			rec.Columns = append(rec.Columns, name)
+		}
+		if _, ok := rec.Fields[FieldURL]; !ok {
+			rec.Fields[FieldURL] = rec.URL
+		}
+		records = append(records, rec)
+	}
+
+	checkRepeatedLinks(records)
+	return records
+}
+
+// findLinkColumn 第一个包含http的单元格
+func findLinkColumn(row []string) int {
+	for i, v := range row {
+		if strings.Contains(v, "http") {
+			return i
+		}
+	}
+	return -1
+}
+
+// normalizeColumnName 列名转换为小写, 空格和-替换为_, 方便在模板中使用: Order ID -> order_id
+func normalizeColumnName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(name)
+}
+
+// dedupeRecords 相同的链接只打印一次, duplicateOf记录重复的行对应第一次出现的行.
+// 邮件附件按内容去重
+func dedupeRecords(records []*LinkRecord) (unique []*LinkRecord, duplicateOf map[*LinkRecord]*LinkRecord) {
+	first := make(map[string]*LinkRecord, len(records))
+	duplicateOf = make(map[*LinkRecord]*LinkRecord)
+	for _, rec := range records {
+		key := dedupeKey(rec)
+		if orig, ok := first[key]; ok {
+			duplicateOf[rec] = orig
+			continue
+		}
+		first[key] = rec
+		unique = append(unique, rec)
+	}
+	return
+}
+
+// dedupeKey 忽略url首尾的空白和#后面的锚点
+func dedupeKey(rec *LinkRecord) string {
+	if len(rec.Attachment) > 0 {
+		return attachmentScheme + util.ComputeMd5String(string(rec.Attachment))
+	}
+	u := strings.TrimSpace(rec.URL)
+	if i := strings.Index(u, "#"); i >= 0 {
+		u = u[:i]
+	}
+	return u
+}
+
+func checkRepeatedLinks(records []*LinkRecord) {
+	var links []string
+	for _, rec := range records {
+		links = append(links, rec.URL)
+	}
+
 	repeated := util.CheckRepeat(links)
 	if len(repeated) != 0 {
 		fmt.Printf("[linktopdf] 发现有重复的链接，请检查. 以下是重复链接\n%s\n", strings.Join(repeated, "\n"))
 	}
-
-	return
 }
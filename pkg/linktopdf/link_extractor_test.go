@@ -2,11 +2,12 @@ package linktopdf
 
 import (
 	"fmt"
+	"reflect"
 	"testing"
 )
 
 func TestCsvExtractor_Extract(t *testing.T) {
-	e, err := NewLinkExtractor("../../testdata/linktopdf.csv")
+	e, err := NewLinkExtractor("../../testdata/linktopdf.csv", nil)
 	if err != nil {
 		t.Fatalf("NewLinkExtractor failed, err:%v", err)
 	}
@@ -35,7 +36,7 @@ func TestCsvExtractor_Extract(t *testing.T) {
 }
 
 func TestExcelExtractor_Extract(t *testing.T) {
-	e, err := NewLinkExtractor("../../testdata/linktopdf.xlsx")
+	e, err := NewLinkExtractor("../../testdata/linktopdf.xlsx", nil)
 	if err != nil {
 		t.Fatalf("NewLinkExtractor failed, err:%v", err)
 	}
@@ -64,7 +65,7 @@ func TestExcelExtractor_Extract(t *testing.T) {
 }
 
 func TestTxtExtractor_Extract(t *testing.T) {
-	e, err := NewLinkExtractor("../../testdata/linktopdf.txt")
+	e, err := NewLinkExtractor("../../testdata/linktopdf.txt", nil)
 	if err != nil {
 		t.Fatalf("NewLinkExtractor failed, err:%v", err)
 	}
@@ -91,3 +92,87 @@ func TestTxtExtractor_Extract(t *testing.T) {
 		})
 	}
 }
+
+func Test_recordsFromRows(t *testing.T) {
+	tests := []struct {
+		name       string
+		rows       [][]string
+		wantURLs   []string
+		wantFields []map[string]string
+	}{
+		{
+			name: "header with url column",
+			rows: [][]string{
+				{"Order ID", "Guest", "Link"},
+				{"A001", "Tom", "https://a.com/1"},
+				{"A002", "Jerry", "https://a.com/2"},
+			},
+			wantURLs: []string{"https://a.com/1", "https://a.com/2"},
+			wantFields: []map[string]string{
+				{"order_id": "A001", "guest": "Tom", "link": "https://a.com/1", "url": "https://a.com/1"},
+				{"order_id": "A002", "guest": "Jerry", "link": "https://a.com/2", "url": "https://a.com/2"},
+			},
+		},
+		{
+			name: "header without url column",
+			rows: [][]string{
+				{"order-id", "voucher"},
+				{"A001", "https://a.com/1"},
+			},
+			wantURLs: []string{"https://a.com/1"},
+			wantFields: []map[string]string{
+				{"order_id": "A001", "voucher": "https://a.com/1", "url": "https://a.com/1"},
+			},
+		},
+		{
+			name: "no header",
+			rows: [][]string{
+				{"A001", " https://a.com/1 "},
+				{"A002", "no link"},
+			},
+			wantURLs: []string{"https://a.com/1"},
+			wantFields: []map[string]string{
+				{"col1": "A001", "col2": "https://a.com/1", "url": "https://a.com/1"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := recordsFromRows(tt.rows)
+			if len(got) != len(tt.wantURLs) {
+				t.Fatalf("recordsFromRows() got %d records, want %d", len(got), len(tt.wantURLs))
+			}
+			for i, rec := range got {
+				if rec.URL != tt.wantURLs[i] {
+					t.Errorf("record %d URL = %s, want %s", i, rec.URL, tt.wantURLs[i])
+				}
+				if !reflect.DeepEqual(rec.Fields, tt.wantFields[i]) {
+					t.Errorf("record %d Fields = %v, want %v", i, rec.Fields, tt.wantFields[i])
+				}
+			}
+		})
+	}
+}
+
+func Test_dedupeRecords(t *testing.T) {
+	records := []*LinkRecord{
+		{Row: 1, URL: "https://a.com/1"},
+		{Row: 2, URL: "https://a.com/2"},
+		{Row: 3, URL: " https://a.com/1#top"},
+		{Row: 4, URL: "attachment:a.pdf", Attachment: []byte("1")},
+		{Row: 5, URL: "attachment:a.pdf", Attachment: []byte("2")},
+	}
+
+	unique, duplicateOf := dedupeRecords(records)
+
+	var rows []int
+	for _, rec := range unique {
+		rows = append(rows, rec.Row)
+	}
+	if want := []int{1, 2, 4, 5}; !reflect.DeepEqual(rows, want) {
+		t.Errorf("dedupeRecords() unique rows = %v, want %v", rows, want)
+	}
+	if len(duplicateOf) != 1 || duplicateOf[records[2]] != records[0] {
+		t.Errorf("dedupeRecords() duplicateOf = %v, want row 3 -> row 1", duplicateOf)
+	}
+}
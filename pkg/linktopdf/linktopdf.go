@@ -1,19 +1,16 @@
 package linktopdf
 
 import (
-	"archive/zip"
 	"bytes"
 	"context"
 	"crypto/md5"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"math"
-	"net/http"
-	"net/url"
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"sync"
 	"time"
@@ -34,46 +31,148 @@ const (
 )
 
 func Execute(input, output string, concurrency int, needCompress bool) error {
-	if ok := utils.CheckFileIsExist(input); !ok {
+	if ok := input == common.StdinInput || utils.CheckFileIsExist(input); !ok {
 		return errors.Errorf(nil, "input file not exists")
 	}
 
-	e, err := NewLinkExtractor(input)
+	extractOpts := &ExtractOptions{JSONPath: viper.GetString(common.LinkToPdfFlagJSONPath)}
+	if pattern := viper.GetString(common.LinkToPdfFlagLinkPattern); pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return errors.Errorf(err, "链接正则不合法, pattern:%s", pattern)
+		}
+		extractOpts.LinkPattern = re
+	}
+
+	e, err := NewLinkExtractor(input, extractOpts)
 	if err != nil {
 		return errors.Errorf(err, "new NewLinkExtractor failed")
 	}
 
-	links, err := e.Extract()
-	if err != nil || len(links) == 0 {
+	records, err := e.Extract()
+	if err != nil || len(records) == 0 {
 		return errors.Errorf(err, "extract links from input file failed")
 	}
 
+	var nameTpl *NameTemplate
+	if text := viper.GetString(common.LinkToPdfFlagNameTemplate); text != "" {
+		if nameTpl, err = NewNameTemplate(text); err != nil {
+			return errors.Errorf(err, "命名模板不合法")
+		}
+	}
+
+	req, err := util.NewRequestOptions(
+		viper.GetString(common.LinkToPdfFlagCookies),
+		viper.GetStringSlice(common.LinkToPdfFlagHeader),
+		viper.GetString(common.LinkToPdfFlagBasicAuth),
+		viper.GetString(common.LinkToPdfFlagProxy),
+	)
+	if err != nil {
+		return errors.Errorf(err, "请求选项不合法")
+	}
+
+	opts := printOptionsFromConfig()
+	opts.Request = req
+	if err := opts.Validate(); err != nil {
+		return errors.Errorf(err, "打印选项不合法")
+	}
+
+	waits, err := waitRulesFromConfig()
+	if err != nil {
+		return errors.Errorf(err, "读取等待策略失败")
+	}
+
 	dir, zipFileName, err := getOutput(output)
 	if err != nil {
 		return errors.Errorf(err, "detect output failed")
 	}
 
-	count := len(links)
-	fmt.Printf("[linktopdf] 检测到%d个链接，即将开始打印\n", count)
+	archiveOpts := &ArchiveOptions{
+		Format:       viper.GetString(common.LinkToPdfFlagArchiveFormat),
+		Structure:    viper.GetString(common.LinkToPdfFlagArchiveStructure),
+		VolumeSize:   viper.GetInt64(common.LinkToPdfFlagVolumeSize) * 1024 * 1024,
+		RemoveSource: viper.GetBool(common.LinkToPdfFlagRemoveSource),
+	}
+	if err := archiveOpts.Validate(zipFileName); err != nil {
+		return errors.Errorf(err, "压缩选项不合法")
+	}
+	zipFileName = archiveFileName(zipFileName, archiveOpts.Format)
 
-	batchGrp := divideLinksIntoGroupV3(links, concurrency)
+	unique, duplicateOf := dedupeRecords(records)
+	if n := len(records) - len(unique); n > 0 {
+		fmt.Printf("[linktopdf] 跳过%d个重复的链接\n", n)
+	}
+
+	rules, err := LoadURLRules(viper.GetString(common.LinkToPdfFlagURLRules))
+	if err != nil {
+		return errors.Errorf(err, "读取url规则失败")
+	}
+
+	allocator, err := newNameAllocator(viper.GetString(common.LinkToPdfFlagOnCollision))
+	if err != nil {
+		return errors.Errorf(err, "文件名冲突处理方式不合法")
+	}
+
+	downloader, err := NewDownloader(req,
+		viper.GetDuration(common.LinkToPdfFlagDownloadTimeout),
+		viper.GetInt(common.LinkToPdfFlagRetries),
+		viper.GetFloat64(common.LinkToPdfFlagRateLimit),
+	)
+	if err != nil {
+		return errors.Errorf(err, "创建下载器失败")
+	}
+
+	p := &printer{
+		printType:  viper.GetString(common.LinkToPdfFlagPrintType),
+		opts:       opts,
+		waits:      waits,
+		downloader: downloader,
+		nameTpl:    nameTpl,
+		rules:      rules,
+	}
 
-	uiprogress.Start()
 	var (
 		wg sync.WaitGroup
+		mu sync.Mutex
 		//waitTime       = time.Millisecond * 100
 		successPrinted []string
 		failedPrinted  []string
+		results        = make(map[*LinkRecord]*linkResult, len(records))
+		printable      []*LinkRecord
 		st             = time.Now()
 	)
 
+	// 打印之前按输入顺序分配文件名, 同样的输入每次得到同样的文件名, 重名时不会互相覆盖
+	for _, rec := range unique {
+		name, err := p.fileName(rec)
+		if err != nil {
+			failedPrinted = append(failedPrinted, fmt.Sprintf("URL:%s, err:%v", rec.URL, err))
+			results[rec] = &linkResult{err: errors.Errorf(err, "生成文件名失败")}
+			continue
+		}
+		rec.FileName = allocator.allocate(name, rec.URL)
+		printable = append(printable, rec)
+	}
+
+	count := len(printable)
+	fmt.Printf("[linktopdf] 检测到%d个链接，即将开始打印\n", count)
+
+	batchGrp := divideRecordsIntoGroup(printable, concurrency)
+
+	// chromedp打印时所有分组共用常驻的chrome, 每个分组对应一个tab
+	if p.printType == common.PrintTypeChromedp {
+		p.pool = util.NewBrowserPool(viper.GetInt(common.LinkToPdfFlagBrowsers), len(batchGrp), viper.GetInt(common.LinkToPdfFlagPagesPerBrowser), req.Proxy)
+		defer p.pool.Close()
+	}
+
+	uiprogress.Start()
 	wg.Add(len(batchGrp))
 	for _, value := range batchGrp {
 		ctx := context.Background()
 		grp := value
 		cnt := len(grp)
 		go utils.HandlePanicV2(ctx, func(i interface{}) {
-			grp := *i.(*[]string)
+			grp := *i.(*[]*LinkRecord)
 			defer wg.Done()
 			bar := uiprogress.AddBar(cnt).AppendCompleted().PrependElapsed()
 			bar.PrependFunc(func(b *uiprogress.Bar) string {
@@ -82,13 +181,16 @@ func Execute(input, output string, concurrency int, needCompress bool) error {
 
 			for bar.Incr() {
 				//time.Sleep(waitTime)
-				u := grp[bar.Current()-1]
-				filePath, err := printPdf(u, dir)
+				rec := grp[bar.Current()-1]
+				filePath, err := p.print(rec, dir)
+				mu.Lock()
 				if err != nil {
-					failedPrinted = append(failedPrinted, fmt.Sprintf("URL:%s, err:%v", u, err))
+					failedPrinted = append(failedPrinted, fmt.Sprintf("URL:%s, err:%v", rec.URL, err))
 				} else {
 					successPrinted = append(successPrinted, filePath)
 				}
+				results[rec] = &linkResult{file: filePath, err: err}
+				mu.Unlock()
 			}
 		})(&grp)
 	}
@@ -103,18 +205,54 @@ func Execute(input, output string, concurrency int, needCompress bool) error {
 
 	fmt.Printf("[linktopdf] pdf保存目录:%s\n", dir)
 
+	for dup, orig := range duplicateOf {
+		r, ok := results[orig]
+		if !ok {
+			// 第一次出现的行没有结果(打印时panic被recover), 重复的行也记为失败
+			results[dup] = &linkResult{err: errors.Errorf(nil, "第%d行没有打印结果", orig.Row), duplicateOf: orig}
+			continue
+		}
+		res := *r
+		res.duplicateOf = orig
+		results[dup] = &res
+	}
+
+	if manifest, err := writeManifest(dir, records, results); err != nil {
+		fmt.Printf("[linktopdf] 写入manifest文件失败:%v\n", err)
+	} else {
+		fmt.Printf("[linktopdf] manifest文件:%s\n", manifest)
+	}
+
+	// 压缩后可能删除pdf目录, 结果文件保存在pdf目录的上一级
+	resultsFile := path.Join(path.Dir(dir), path.Base(dir)+resultsFileSuffix)
+	if err := writeResults(resultsFile, records, results); err != nil {
+		fmt.Printf("[linktopdf] 写入结果文件失败:%v\n", err)
+	} else {
+		fmt.Printf("[linktopdf] 结果文件:%s\n", resultsFile)
+	}
+
 	if !needCompress {
 		return nil
 	}
 
 	st = time.Now()
 	fmt.Println("[linktopdf] 打印pdf完毕，准备压缩!")
-	err = compress(dir, path.Join(path.Dir(dir), zipFileName))
+	entries, manifest, err := archiveEntries(dir, records, results, rules, archiveOpts.Structure)
+	if err != nil {
+		return errors.Errorf(err, "列出需要压缩的文件失败")
+	}
+	archives, err := compress(entries, manifest, path.Join(path.Dir(dir), zipFileName), archiveOpts)
 	if err != nil {
 		return errors.Errorf(err, "压缩失败")
 	}
 
-	fmt.Printf("[linktopdf] 打包压缩完毕! 耗时:%v, 压缩文件:%s\n", time.Since(st), zipFileName)
+	fmt.Printf("[linktopdf] 打包压缩完毕! 耗时:%v, 压缩文件:\n%s\n", time.Since(st), strings.Join(archives, "\n"))
+
+	if archiveOpts.RemoveSource {
+		if err := utils.RmAll(dir); err != nil {
+			return errors.Errorf(err, "删除pdf文件失败")
+		}
+	}
 
 	if len(failedPrinted) > 0 {
 		fmt.Printf("以下打印失败的链接:\n%s\n", strings.Join(failedPrinted, "\n"))
@@ -123,98 +261,73 @@ func Execute(input, output string, concurrency int, needCompress bool) error {
 	return nil
 }
 
-// compress 打包压缩
-func compress(fileDir, zipFilePath string) error {
+// compress 边读边写入压缩文件, 超过分卷大小时拆分为多个压缩文件, 每个分卷都包含manifest.csv, 返回生成的压缩文件
+func compress(entries []archiveEntry, manifest *archiveEntry, archivePath string, opts *ArchiveOptions) ([]string, error) {
 	var (
-		buf       = new(bytes.Buffer)
-		w         = zip.NewWriter(buf)
 		hm        = make(map[string]struct{})
 		repeatedF []string
+		files     []string
+		volumes   = splitVolumes(entries, opts.VolumeSize)
 	)
 
-	// read dir
-	fis, err := ioutil.ReadDir(fileDir)
-	if err != nil {
-		return errors.Errorf(err, "readDir failed,dir:%s", fileDir)
-	}
-
-	for _, fi := range fis {
-		if fi.IsDir() {
-			continue
-		}
-
-		// ignore unexpected file extension
-		if strings.ToLower(path.Ext(fi.Name())) != common.ExtPDF {
-			continue
-		}
-
-		// create buf writer
-		wf, err := w.Create(fi.Name())
-		if err != nil {
-			return errors.Errorf(err, "zip writer create failed,fileName:[%s]", fi.Name())
-		}
-
-		// open file
-		f, err := os.OpenFile(path.Join(fileDir, fi.Name()), os.O_RDONLY, 0644)
+	for i, vol := range volumes {
+		file := volumeFileName(archivePath, i, len(volumes))
+		w, err := newArchiveWriter(opts.Format, file)
 		if err != nil {
-			return errors.Errorf(err, "open pdf file failed")
+			return files, errors.Errorf(err, "创建压缩文件失败")
 		}
 
-		// write to buf writer
-		_, err = io.Copy(wf, f)
-		if err != nil {
-			return errors.Errorf(err, "write file into zip file failed,file:[%s]", fi.Name())
-		}
+		for _, e := range vol {
+			hs, err := addArchiveEntry(w, e)
+			if err != nil {
+				w.Close()
+				return files, err
+			}
 
-		// get md5 of this file
-		hs, err := computeMd5(f)
-		if err != nil {
-			return errors.Errorf(err, "compute md5 failed")
+			// detect repeat files
+			if _, ok := hm[hs]; ok {
+				repeatedF = append(repeatedF, e.src)
+			} else {
+				hm[hs] = struct{}{}
+			}
 		}
-
-		// detect repeat files
-		if _, ok := hm[hs]; ok {
-			repeatedF = append(repeatedF, f.Name())
-		} else {
-			hm[hs] = struct{}{}
+		if manifest != nil {
+			if _, err := addArchiveEntry(w, *manifest); err != nil {
+				w.Close()
+				return files, err
+			}
 		}
 
-		// close file
-		if err := f.Close(); err != nil {
-			return errors.Errorf(err, "关闭pdf文件失败")
+		if err := w.Close(); err != nil {
+			return files, err
 		}
+		files = append(files, file)
 	}
 
-	// close buf writer
-	err = w.Close()
-	if err != nil {
-		return errors.Errorf(err, "close zip file writer failed")
-	}
-
-	// create a zip file
-	zipf, err := os.OpenFile(zipFilePath, os.O_CREATE|os.O_RDWR|os.O_TRUNC|os.O_APPEND, 0644)
-	if err != nil {
-		return errors.Errorf(err, "create zip file failed")
+	if len(repeatedF) > 0 {
+		fmt.Printf("[linktopdf] 压缩时校验重复，发现以下重复文件:\n%s\n", strings.Join(repeatedF, "\n"))
 	}
-	defer zipf.Close()
+	return files, nil
+}
 
-	// copy write buf to zip file
-	_, err = io.Copy(zipf, buf)
+// addArchiveEntry 写入压缩文件, 返回文件的md5
+func addArchiveEntry(w archiveWriter, e archiveEntry) (string, error) {
+	f, err := os.Open(e.src)
 	if err != nil {
-		return errors.Errorf(err, "write buf into zip file failed")
+		return "", errors.Errorf(err, "open pdf file failed")
 	}
+	defer f.Close()
 
-	if len(repeatedF) > 0 {
-		fmt.Printf("[linktopdf] 压缩时校验重复，发现以下重复文件:\n%s\n", strings.Join(repeatedF, "\n"))
+	if err := w.add(e, f); err != nil {
+		return "", err
 	}
 
-	// delete files
-	err = utils.RmAll(fileDir)
+	// get md5 of this file
+	hs, err := computeMd5(f)
 	if err != nil {
-		return errors.Errorf(err, "删除pdf文件失败")
+		return "", errors.Errorf(err, "compute md5 failed")
 	}
-
-	return nil
+	return hs, nil
 }
 
 func computeMd5(f *os.File) (string, error) {
@@ -239,32 +352,53 @@ func computeMd5(f *os.File) (string, error) {
 	return hvs, nil
 }
 
-func printPdf(u, dir string) (string, error) {
+// printer 下载或打印单个url
+type printer struct {
+	printType  string
+	opts       *util.PrintOptions
+	waits      *util.WaitRules
+	downloader *Downloader
+	pool       *util.BrowserPool
+	nameTpl    *NameTemplate
+	rules      *URLRules
+}
 
-	fileName, err := genFileNameFromURL(u)
-	if err != nil {
-		return "", errors.Errorf(err, "根据url生成文件名失败")
-	}
+func (p *printer) print(rec *LinkRecord, dir string) (string, error) {
+	u := rec.URL
+	filepath := path.Join(dir, rec.FileName)
 
-	filepath := path.Join(dir, fileName)
+	// 邮件中的pdf附件直接保存
+	if len(rec.Attachment) > 0 {
+		if _, err := savePdf(bytes.NewReader(rec.Attachment), filepath); err != nil {
+			return "", errors.Errorf(err, "保存pdf附件失败")
+		}
+		return filepath, nil
+	}
 
-	// pdf资源直接下载
-	if strings.Contains(u, ".pdf") || strings.Contains(u, "skybus.umd.com.au") {
-		if err := downloadPdf(u, filepath); err != nil {
+	// 先请求url, 返回的内容是pdf时直接保存, 否则使用打印工具渲染; url规则可以指定只下载或直接打印
+	action := p.rules.Action(u)
+	if action != ActionRender {
+		isPdf, err := p.downloader.Download(u, filepath)
+		switch {
+		case err != nil && action == ActionDownload:
 			return "", errors.Errorf(err, "下载pdf文件失败")
+		case isPdf:
+			return filepath, nil
+		case action == ActionDownload:
+			return "", errors.Errorf(nil, "url规则要求直接下载, 但返回的内容不是pdf")
 		}
-		return filepath, nil
+		// auto: 有些html页面对非浏览器的请求返回403/401(反爬虫、js设置的cookie), 请求失败时交给浏览器打印
 	}
 
 	// 决定打印形式(html转pdf)
-	printType := viper.GetString(common.LinkToPdfFlagPrintType)
-	switch printType {
+	var err error
+	switch p.printType {
 	case common.PrintTypeChromedp:
-		err = util.ChromedpPrintPdf(u, filepath)
+		err = p.pool.PrintPdf(u, filepath, p.opts, p.waits.Match(u))
 	case common.PrintTypeWkhtmltopdf:
-		err = util.WkHtmlToPDf(u, filepath)
+		err = util.WkHtmlToPDf(u, filepath, p.opts)
 	default:
-		err = errors.Errorf(nil, "unexpected print type:%s", printType)
+		err = errors.Errorf(nil, "unexpected print type:%s", p.printType)
 	}
 
 	if err != nil {
@@ -273,68 +407,68 @@ func printPdf(u, dir string) (string, error) {
 	return filepath, nil
 }
 
-func downloadPdf(u string, filePath string) error {
-	if !strings.Contains(u, "http") {
-		return errors.Errorf(nil, "url不合法,url:[%s]", u)
-	}
-
-	resp, err := http.Get(u)
-	if err != nil {
-		return errors.Errorf(err, "http请求URL失败,url:%s", u)
-
+// fileName 设置了命名模板时使用模板, 否则根据url生成
+func (p *printer) fileName(rec *LinkRecord) (string, error) {
+	def, err := defaultFileName(rec, p.rules)
+	if p.nameTpl != nil {
+		// 默认文件名只在模板中作为{{.default}}使用, 生成失败时不影响模板
+		return p.nameTpl.Execute(rec, def)
 	}
-	defer resp.Body.Close()
+	return def, err
+}
 
-	f, err := os.OpenFile(filePath, os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0644)
-	if err != nil {
-		return errors.Errorf(err, "打开文件失败,filePath:%s", filePath)
+// defaultFileName 邮件附件使用附件的文件名, 其他按url规则生成
+func defaultFileName(rec *LinkRecord, rules *URLRules) (string, error) {
+	if len(rec.Attachment) > 0 {
+		attachment := rec.Fields[FieldAttachment]
+		name := util.SanitizeFileName(strings.TrimSuffix(attachment, path.Ext(attachment)))
+		if name == "" {
+			return "", errors.Errorf(nil, "附件的文件名为空")
+		}
+		return name + common.ExtPDF, nil
 	}
-	defer f.Close()
 
-	_, err = io.Copy(f, resp.Body)
+	name, err := rules.FileName(rec.URL)
 	if err != nil {
-		return errors.Errorf(err, "写入数据流到文件失败")
+		return "", errors.Errorf(err, "根据url生成文件名失败")
 	}
-
-	return nil
+	return name, nil
 }
 
-func genFileNameFromURL(u string) (string, error) {
-	var (
-		fileName string
-		err      error
-	)
-
-	URL, err := url.Parse(u)
-	if err != nil {
-		return "", errors.Errorf(err, "parse url failed")
-	}
-
-	switch {
-	case strings.Contains(u, "www.puroland.jp"):
-		// puroland的url直接取参数p
-		fileName = URL.Query().Get("p")
-	case strings.Contains(u, "skybus.umd.com.au"):
-		// 根据url:https://skybus.umd.com.au/skybus/bulk/template/generic/12170/1234567890/, 组合文件名:skybus_1234567890.pdf
-		fileName = fmt.Sprintf("skybus_%s", path.Base(URL.Path))
-	case strings.Contains(u, ".pdf"):
-		// 根据url:https://www.xxx.com/upload_voucher/2019/08/01/xxx.pdf, 得到文件名: xxx
-		fileName = strings.Replace(GetFileNameFromPdfURL(u), ".pdf", "", -1)
-	case strings.Contains(u, "voucher/KLK"):
-		// 根据url:https://www.xxx.com/zh-CN/voucher/KLK0877301055?token=5005f4db-8cde-4f3b-74b4-09493e6b3739&lang=zh_CN,得到文件名
-		uRL, _ := url.Parse(u)
-		token := uRL.Query().Get("token")
-		fileName = token
-	default:
-		fileName = util.ComputeMd5String(u)
+// printOptionsFromConfig 从命令行参数或配置文件(~/.invtools.yaml)读取打印选项
+func printOptionsFromConfig() *util.PrintOptions {
+	return &util.PrintOptions{
+		PaperSize:       viper.GetString(common.LinkToPdfFlagPaperSize),
+		Landscape:       viper.GetBool(common.LinkToPdfFlagLandscape),
+		MarginTop:       viper.GetFloat64(common.LinkToPdfFlagMarginTop),
+		MarginBottom:    viper.GetFloat64(common.LinkToPdfFlagMarginBottom),
+		MarginLeft:      viper.GetFloat64(common.LinkToPdfFlagMarginLeft),
+		MarginRight:     viper.GetFloat64(common.LinkToPdfFlagMarginRight),
+		Scale:           viper.GetFloat64(common.LinkToPdfFlagScale),
+		PrintBackground: viper.GetBool(common.LinkToPdfFlagPrintBackground),
+		HeaderTemplate:  viper.GetString(common.LinkToPdfFlagHeaderTemplate),
+		FooterTemplate:  viper.GetString(common.LinkToPdfFlagFooterTemplate),
+		PageRanges:      viper.GetString(common.LinkToPdfFlagPageRanges),
+		MediaType:       viper.GetString(common.LinkToPdfFlagMediaType),
 	}
-
-	// 添加.pdf文件后缀
-	return fmt.Sprintf("%s.pdf", fileName), nil
 }
 
-func GetFileNameFromPdfURL(u string) string {
-	return path.Base(u)
+// waitRulesFromConfig 命令行参数作为默认的等待策略, 规则文件中按url匹配的策略优先
+func waitRulesFromConfig() (*util.WaitRules, error) {
+	def := &util.WaitStrategy{
+		Selector:      viper.GetString(common.LinkToPdfFlagWaitSelector),
+		JS:            viper.GetString(common.LinkToPdfFlagWaitJS),
+		NetworkIdle:   viper.GetBool(common.LinkToPdfFlagWaitNetworkIdle),
+		Delay:         viper.GetDuration(common.LinkToPdfFlagWaitDelay),
+		Timeout:       viper.GetDuration(common.LinkToPdfFlagPageTimeout),
+		ScreenshotDir: viper.GetString(common.LinkToPdfFlagScreenshotDir),
+	}
+	if def.ScreenshotDir != "" {
+		if err := utils.CheckAndMkDir(def.ScreenshotDir); err != nil {
+			return nil, errors.Errorf(err, "创建截图目录失败")
+		}
+	}
+	return util.LoadWaitRules(viper.GetString(common.LinkToPdfFlagWaitRules), def)
 }
 
 /*
@@ -348,8 +482,7 @@ func getOutput(output string) (dir, name string, err error) {
 	ok := path.IsAbs(output)
 
 	if ok {
-		ext := path.Ext(output)
-		if ext != "" && strings.EqualFold(common.ExtZip, ext) {
+		if isArchiveName(output) {
 			dir = path.Dir(output)
 			name = path.Base(output)
 		} else {
@@ -363,8 +496,7 @@ func getOutput(output string) (dir, name string, err error) {
 		}
 
 		dir = common.CurrentDir
-		ext := path.Ext(absOutput)
-		if ext != "" && strings.EqualFold(common.ExtZip, ext) {
+		if isArchiveName(absOutput) {
 			name = path.Base(output)
 		} else {
 			dir = absOutput
@@ -440,6 +572,22 @@ func divideLinksIntoGroupV2(links []string, groupCount int) (groups [][]string)
 	return
 }
 
+// divideRecordsIntoGroup 与divideLinksIntoGroupV3相同, 按顺序轮流分到N组中
+func divideRecordsIntoGroup(records []*LinkRecord, groupCount int) [][]*LinkRecord {
+	if groupCount > len(records) {
+		groupCount = len(records)
+	}
+	if groupCount <= 0 {
+		groupCount = 1
+	}
+
+	groups := make([][]*LinkRecord, groupCount)
+	for i, rec := range records {
+		groups[i%groupCount] = append(groups[i%groupCount], rec)
+	}
+	return groups
+}
+
 // divideLinksIntoGroupV3 将一个数组中的元素，均匀的分散到N组中
 func divideLinksIntoGroupV3(links []string, groupCount int) [][]string {
 	count := len(links)
@@ -2,8 +2,17 @@ package linktopdf
 
 import (
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"invtools/pkg/util"
 )
 
 func TestExecute(t *testing.T) {
@@ -350,36 +359,169 @@ func Test_getOutput(t *testing.T) {
 	}
 }
 
-func Test_genFileNameFromURL(t *testing.T) {
-	u := "https://www.puroland.jp/qrticket/e/?p=3000089253600423003004000000930000000000000008011100034056501000010640002020000000000000020190152798"
-	type args struct {
-		u string
+func TestURLRules_FileName(t *testing.T) {
+	rules, err := LoadURLRules("")
+	if err != nil {
+		t.Fatalf("LoadURLRules failed, err:%v", err)
 	}
+
 	tests := []struct {
-		name    string
-		args    args
-		want    string
-		wantErr bool
+		name       string
+		u          string
+		want       string
+		wantAction string
 	}{
 		{
-			name:    "Test_genFileNameFromURL",
-			args:    args{u},
-			wantErr: false,
+			name:       "puroland",
+			u:          "https://www.puroland.jp/qrticket/e/?p=3000089253600423003004000000930000000000000008011100034056501000010640002020000000000000020190152798",
+			want:       "3000089253600423003004000000930000000000000008011100034056501000010640002020000000000000020190152798.pdf",
+			wantAction: ActionAuto,
 		},
 		{
-			name:    "Test_genFileNameFromURL",
-			args:    args{"https://www.baidu.com"},
-			wantErr: false,
+			name:       "skybus",
+			u:          "https://skybus.umd.com.au/skybus/bulk/template/generic/12170/1234567890/",
+			want:       "skybus_1234567890.pdf",
+			wantAction: ActionDownload,
+		},
+		{
+			name:       "pdf",
+			u:          "https://www.xxx.com/upload_voucher/2019/08/01/xxx.pdf",
+			want:       "xxx.pdf",
+			wantAction: ActionDownload,
+		},
+		{
+			name:       "klook",
+			u:          "https://www.xxx.com/zh-CN/voucher/KLK0877301055?token=5005f4db-8cde-4f3b-74b4-09493e6b3739&lang=zh_CN",
+			want:       "5005f4db-8cde-4f3b-74b4-09493e6b3739.pdf",
+			wantAction: ActionAuto,
+		},
+		{
+			name:       "no rule",
+			u:          "https://www.baidu.com",
+			want:       util.ComputeMd5String("https://www.baidu.com") + ".pdf",
+			wantAction: ActionAuto,
+		},
+		{
+			name:       "empty query",
+			u:          "https://www.puroland.jp/qrticket/e/",
+			want:       util.ComputeMd5String("https://www.puroland.jp/qrticket/e/") + ".pdf",
+			wantAction: ActionAuto,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := genFileNameFromURL(tt.args.u)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("genFileNameFromURL() error = %v, wantErr %v", err, tt.wantErr)
-				return
+			got, err := rules.FileName(tt.u)
+			if err != nil {
+				t.Fatalf("FileName() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("FileName() got = %v, want %v", got, tt.want)
+			}
+			if action := rules.Action(tt.u); action != tt.wantAction {
+				t.Errorf("Action() got = %v, want %v", action, tt.wantAction)
+			}
+		})
+	}
+}
+
+func TestLoadURLRules(t *testing.T) {
+	dir, err := ioutil.TempDir("", "linktopdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := path.Join(dir, "url_rules.yaml")
+	content := `
+rules:
+  - name: example
+    host: 'tickets\.example\.com'
+    path: '^/voucher/(?P<id>\d+)'
+    file_name: 'example_{{.Group "id"}}_{{.Query "lang"}}'
+    action: render
+  - name: skybus
+    host: 'skybus\.umd\.com\.au'
+    file_name: 'bus_{{.Segment 0}}'
+`
+	if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rules, err := LoadURLRules(file)
+	if err != nil {
+		t.Fatalf("LoadURLRules failed, err:%v", err)
+	}
+
+	tests := []struct {
+		name       string
+		u          string
+		want       string
+		wantAction string
+	}{
+		{"custom", "https://tickets.example.com/voucher/42?lang=en", "example_42_en.pdf", ActionRender},
+		{"override default", "https://skybus.umd.com.au/skybus/bulk/1/", "bus_skybus.pdf", ActionAuto},
+		{"default", "https://www.xxx.com/a/b.PDF", "b.pdf", ActionDownload},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := rules.FileName(tt.u); got != tt.want {
+				t.Errorf("FileName() got = %v, want %v", got, tt.want)
+			}
+			if action := rules.Action(tt.u); action != tt.wantAction {
+				t.Errorf("Action() got = %v, want %v", action, tt.wantAction)
+			}
+		})
+	}
+}
+
+func TestPrinterPrintFallback(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/voucher", func(w http.ResponseWriter, r *http.Request) {
+		// 只允许浏览器访问的html页面
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("<html><body>please enable javascript</body></html>"))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "printer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	d, err := NewDownloader(nil, time.Second, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		action     string
+		wantRender bool
+	}{
+		{name: "auto falls through to render", action: ActionAuto, wantRender: true},
+		{name: "download fails", action: ActionDownload},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := parseURLRules([]byte("rules:\n  - path: ^/voucher$\n    action: " + tt.action + "\n"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			// 不存在的打印方式, 走到打印时返回 unexpected print type
+			p := &printer{printType: "test", downloader: d, rules: rules}
+			rec := &LinkRecord{URL: srv.URL + "/voucher", FileName: "voucher.pdf"}
+
+			_, err = p.print(rec, dir)
+			if err == nil {
+				t.Fatal("print() want error")
+			}
+			rendered := strings.Contains(err.Error(), "unexpected print type")
+			if rendered != tt.wantRender {
+				t.Errorf("print() rendered = %v, want %v, err:%v", rendered, tt.wantRender, err)
 			}
-			t.Logf("got:%v", got)
 		})
 	}
 }
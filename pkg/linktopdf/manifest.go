@@ -0,0 +1,68 @@
+package linktopdf
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+
+	"invtools/pkg/util"
+	"invtools/utils/errors"
+)
+
+// manifestFileName 保存在pdf目录中, 压缩时一起打包
+const manifestFileName = "manifest.csv"
+
+// writeManifest 按输入顺序记录每个打印成功的链接对应的文件名、md5和大小
+func writeManifest(dir string, records []*LinkRecord, results map[*LinkRecord]*linkResult) (string, error) {
+	file := path.Join(dir, manifestFileName)
+	w := util.NewTableWriter(file)
+	if err := w.DecideWriter(); err != nil {
+		return "", errors.Errorf(err, "创建manifest文件失败")
+	}
+
+	if err := w.WriteRecord([]string{FieldURL, "file", "md5", "size"}); err != nil {
+		return "", errors.Errorf(err, "写入表头失败")
+	}
+
+	for _, rec := range records {
+		res, ok := results[rec]
+		if !ok || res.err != nil || res.duplicateOf != nil {
+			continue
+		}
+
+		sum, size, err := fileMd5AndSize(res.file)
+		if err != nil {
+			return "", errors.Errorf(err, "计算md5失败, file:%s", res.file)
+		}
+		row := []string{rec.URL, path.Base(res.file), sum, strconv.FormatInt(size, 10)}
+		if err := w.WriteRecord(row); err != nil {
+			return "", errors.Errorf(err, "写入manifest失败")
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return "", errors.Errorf(err, "关闭manifest文件失败")
+	}
+	return file, nil
+}
+
+// fileMd5AndSize 复用压缩时校验重复文件的computeMd5
+func fileMd5AndSize(filePath string) (string, int64, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", 0, errors.Errorf(err, "open file failed")
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return "", 0, errors.Errorf(err, "get file stat failed")
+	}
+
+	hs, err := computeMd5(f)
+	if err != nil {
+		return "", 0, errors.Errorf(err, "compute md5 failed")
+	}
+	return fmt.Sprintf("%x", hs), fi.Size(), nil
+}
@@ -0,0 +1,90 @@
+package linktopdf
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"invtools/common"
+	"invtools/pkg/util"
+	"invtools/utils/errors"
+)
+
+// FieldDefault 根据url生成的默认文件名(不带后缀), 在命名模板中使用
+const FieldDefault = "default"
+
+// NameTemplate 根据输入文件中每一行的数据生成pdf文件名, 例如: {{.order_id}}_{{.guest}}.pdf
+type NameTemplate struct {
+	tpl *template.Template
+}
+
+func NewNameTemplate(text string) (*NameTemplate, error) {
+	// 引用了不存在的列时报错, 避免生成"<no value>"的文件名
+	tpl, err := template.New("name").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, errors.Errorf(err, "解析命名模板失败, template:%s", text)
+	}
+	return &NameTemplate{tpl: tpl}, nil
+}
+
+// Execute 可以使用的字段: 每一列的列名, url, row(行号), default(默认文件名def, 不带后缀)
+func (t *NameTemplate) Execute(rec *LinkRecord, def string) (string, error) {
+	data := make(map[string]string, len(rec.Fields)+2)
+	for k, v := range rec.Fields {
+		data[k] = v
+	}
+	data[FieldRow] = strconv.Itoa(rec.Row)
+	data[FieldDefault] = strings.TrimSuffix(def, common.ExtPDF)
+
+	var buf bytes.Buffer
+	if err := t.tpl.Execute(&buf, data); err != nil {
+		return "", errors.Errorf(err, "生成文件名失败, row:%d", rec.Row)
+	}
+
+	name := strings.TrimSpace(buf.String())
+	if strings.EqualFold(path.Ext(name), common.ExtPDF) {
+		name = name[:len(name)-len(common.ExtPDF)]
+	}
+	name = util.SanitizeFileName(name)
+	if name == "" {
+		return "", errors.Errorf(nil, "生成的文件名为空, row:%d", rec.Row)
+	}
+	return name + common.ExtPDF, nil
+}
+
+const (
+	// CollisionSuffix 文件名重复时添加序号: name_2.pdf, name_3.pdf
+	CollisionSuffix = "suffix"
+	// CollisionHash 文件名重复时添加url的md5前8位: name_1a2b3c4d.pdf
+	CollisionHash = "hash"
+)
+
+// nameAllocator 按输入顺序分配文件名, 保证不会互相覆盖
+type nameAllocator struct {
+	strategy string
+	names    *util.NameAllocator
+}
+
+func newNameAllocator(strategy string) (*nameAllocator, error) {
+	switch strategy {
+	case "":
+		strategy = CollisionSuffix
+	case CollisionSuffix, CollisionHash:
+	default:
+		return nil, errors.Errorf(nil, "不支持的文件名冲突处理方式:%s, 支持: suffix/hash", strategy)
+	}
+	return &nameAllocator{strategy: strategy, names: util.NewNameAllocator()}, nil
+}
+
+// allocate name已经被使用时按策略生成新的文件名
+func (a *nameAllocator) allocate(name, u string) string {
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	if a.strategy == CollisionHash && a.names.IsUsed(name) {
+		base = fmt.Sprintf("%s_%s", base, util.ComputeMd5String(u)[:8])
+	}
+	return a.names.Allocate(base, ext)
+}
@@ -0,0 +1,79 @@
+package linktopdf
+
+import "testing"
+
+func TestNameTemplate_Execute(t *testing.T) {
+	rec := &LinkRecord{
+		Row: 2,
+		URL: "https://a.com/voucher/123",
+		Fields: map[string]string{
+			"order_id": "A001",
+			"guest":    "Tom/Jerry",
+			"url":      "https://a.com/voucher/123",
+		},
+	}
+
+	tests := []struct {
+		name    string
+		text    string
+		want    string
+		wantErr bool
+	}{
+		{name: "with pdf suffix", text: "{{.order_id}}_{{.guest}}.pdf", want: "A001_Tom_Jerry.pdf"},
+		{name: "without pdf suffix", text: "{{.order_id}}", want: "A001.pdf"},
+		{name: "row", text: "{{.row}}_{{.order_id}}", want: "2_A001.pdf"},
+		{name: "default", text: "{{.order_id}}_{{.default}}", want: "A001_voucher_123.pdf"},
+		{name: "missing column", text: "{{.hotel}}.pdf", wantErr: true},
+		{name: "empty name", text: " .pdf", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tpl, err := NewNameTemplate(tt.text)
+			if err != nil {
+				t.Fatalf("NewNameTemplate() error = %v", err)
+			}
+			got, err := tpl.Execute(rec, "voucher_123.pdf")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Execute() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_nameAllocator_allocate(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy string
+		names    []string
+		want     []string
+	}{
+		{
+			name:     "suffix",
+			strategy: CollisionSuffix,
+			names:    []string{"a.pdf", "b.pdf", "A.pdf", "a.pdf", "a_2.pdf"},
+			want:     []string{"a.pdf", "b.pdf", "A_2.pdf", "a_3.pdf", "a_2_2.pdf"},
+		},
+		{
+			name:     "hash",
+			strategy: CollisionHash,
+			names:    []string{"a.pdf", "a.pdf"},
+			want:     []string{"a.pdf", "a_0cc175b9.pdf"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := newNameAllocator(tt.strategy)
+			if err != nil {
+				t.Fatalf("newNameAllocator() error = %v", err)
+			}
+			for i, name := range tt.names {
+				if got := a.allocate(name, "a"); got != tt.want[i] {
+					t.Errorf("allocate(%s) = %s, want %s", name, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package linktopdf
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+
+	"invtools/pkg/util"
+	"invtools/utils/errors"
+)
+
+const (
+	// resultsFileSuffix 结果文件: linktopdf_20191020150405_results.csv
+	resultsFileSuffix = "_results.csv"
+
+	resultStatusSuccess   = "success"
+	resultStatusFailed    = "failed"
+	resultStatusDuplicate = "duplicate"
+)
+
+// linkResult 单个链接的处理结果
+type linkResult struct {
+	file        string
+	err         error
+	duplicateOf *LinkRecord // 重复的链接不打印, 指向第一次出现的行
+}
+
+// writeResults 按输入文件的顺序写入每一行对应的pdf文件和状态, 表头为: row, 输入文件的列, file, status, error
+func writeResults(file string, records []*LinkRecord, results map[*LinkRecord]*linkResult) error {
+	w := util.NewTableWriter(file)
+	if err := w.DecideWriter(); err != nil {
+		return errors.Errorf(err, "创建结果文件失败")
+	}
+
+	columns := resultColumns(records)
+	header := append([]string{FieldRow}, columns...)
+	header = append(header, "file", "status", "error")
+	if err := w.WriteRecord(header); err != nil {
+		return errors.Errorf(err, "写入表头失败")
+	}
+
+	for _, rec := range records {
+		row := []string{strconv.Itoa(rec.Row)}
+		for _, c := range columns {
+			row = append(row, rec.Fields[c])
+		}
+
+		status, fileName, reason := resultStatusFailed, "", "未处理"
+		if res, ok := results[rec]; ok {
+			switch {
+			case res.err != nil:
+				// 第一次出现的行失败时, 重复的行也标记为失败并保留原始错误
+				reason = errors.GetInnerMostV2(res.err).Error()
+			case res.duplicateOf != nil:
+				status, fileName, reason = resultStatusDuplicate, path.Base(res.file), fmt.Sprintf("与第%d行重复", res.duplicateOf.Row)
+			default:
+				status, fileName, reason = resultStatusSuccess, path.Base(res.file), ""
+			}
+		}
+		row = append(row, fileName, status, reason)
+
+		if err := w.WriteRecord(row); err != nil {
+			return errors.Errorf(err, "写入结果失败, row:%d", rec.Row)
+		}
+	}
+
+	return w.Close()
+}
+
+// resultColumns 所有行的列名, 按第一次出现的顺序
+func resultColumns(records []*LinkRecord) []string {
+	var (
+		columns []string
+		seen    = make(map[string]struct{})
+	)
+	for _, rec := range records {
+		for _, c := range rec.Columns {
+			if _, ok := seen[c]; !ok {
+				seen[c] = struct{}{}
+				columns = append(columns, c)
+			}
+		}
+	}
+	return columns
+}
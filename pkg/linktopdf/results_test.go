@@ -0,0 +1,62 @@
+package linktopdf
+
+import (
+	"encoding/csv"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	"invtools/utils/errors"
+)
+
+func Test_writeResults(t *testing.T) {
+	dir, err := ioutil.TempDir("", "results")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		ok       = &LinkRecord{Row: 1, URL: "https://a.com/1"}
+		failed   = &LinkRecord{Row: 2, URL: "https://a.com/2"}
+		dupOk    = &LinkRecord{Row: 3, URL: "https://a.com/1"}
+		dupFail  = &LinkRecord{Row: 4, URL: "https://a.com/2"}
+		missing  = &LinkRecord{Row: 5, URL: "https://a.com/5"}
+		printed  = path.Join(dir, "1.pdf")
+		printErr = errors.Errorf(nil, "http状态码:404")
+	)
+	results := map[*LinkRecord]*linkResult{
+		ok:      {file: printed},
+		failed:  {err: printErr},
+		dupOk:   {file: printed, duplicateOf: ok},
+		dupFail: {err: printErr, duplicateOf: failed},
+	}
+
+	file := path.Join(dir, "results.csv")
+	if err := writeResults(file, []*LinkRecord{ok, failed, dupOk, dupFail, missing}, results); err != nil {
+		t.Fatalf("writeResults() error = %v", err)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]string{
+		{"1", "1.pdf", resultStatusSuccess, ""},
+		{"2", "", resultStatusFailed, "http状态码:404"},
+		{"3", "1.pdf", resultStatusDuplicate, "与第1行重复"},
+		{"4", "", resultStatusFailed, "http状态码:404"},
+		{"5", "", resultStatusFailed, "未处理"},
+	}
+	if !reflect.DeepEqual(rows[1:], want) {
+		t.Errorf("writeResults() rows = %v, want %v", rows[1:], want)
+	}
+}
@@ -0,0 +1,252 @@
+package linktopdf
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"invtools/common"
+	"invtools/pkg/util"
+	"invtools/utils/errors"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// ActionAuto 先请求url, 返回pdf时直接保存, 否则打印
+	ActionAuto = "auto"
+	// ActionDownload 只下载, 返回的内容不是pdf时报错
+	ActionDownload = "download"
+	// ActionRender 不请求url, 直接打印
+	ActionRender = "render"
+)
+
+// defaultURLRules 内置的供应商规则, 规则文件中的规则优先匹配
+const defaultURLRules = `
+rules:
+  # https://www.puroland.jp/qrticket/e/?p=xxx, 文件名取参数p
+  - name: puroland
+    host: 'www\.puroland\.jp'
+    file_name: '{{.Query "p"}}'
+  # https://skybus.umd.com.au/skybus/bulk/template/generic/12170/1234567890/, 文件名: skybus_1234567890.pdf
+  - name: skybus
+    host: 'skybus\.umd\.com\.au'
+    file_name: 'skybus_{{.Segment -1}}'
+    action: download
+  # https://www.xxx.com/upload_voucher/2019/08/01/xxx.pdf, 文件名: xxx.pdf
+  - name: pdf
+    path: '(?i)\.pdf$'
+    file_name: '{{.Base}}'
+    action: download
+  # https://www.xxx.com/zh-CN/voucher/KLK0877301055?token=xxx&lang=zh_CN, 文件名取参数token
+  - name: klook
+    path: 'voucher/KLK'
+    file_name: '{{.Query "token"}}'
+`
+
+// URLRule 按host和path匹配url, 决定文件名和下载还是打印. host和path都是正则, 都设置时需要同时匹配
+type URLRule struct {
+	Name     string `yaml:"name"`
+	Host     string `yaml:"host"`
+	Path     string `yaml:"path"`
+	FileName string `yaml:"file_name"` // go模板, 可以使用 .Host .Query .Segment .Base .MD5 .Group, 为空时使用url的md5
+	Action   string `yaml:"action"`    // auto/download/render, 默认auto
+
+	hostRe *regexp.Regexp
+	pathRe *regexp.Regexp
+	tpl    *template.Template
+}
+
+// URLRules 按顺序匹配第一条规则, 都不匹配时文件名为url的md5, 先请求再决定是否打印
+type URLRules struct {
+	Rules []*URLRule `yaml:"rules"`
+}
+
+// LoadURLRules 读取规则文件, 规则文件中的规则排在内置规则之前; file为空时只使用内置规则.
+// 规则文件格式:
+//   rules:
+//     - name: example
+//       host: 'tickets\.example\.com'
+//       path: '^/voucher/(?P<id>\d+)'
+//       file_name: 'example_{{.Group "id"}}'
+//       action: render
+func LoadURLRules(file string) (*URLRules, error) {
+	rules, err := parseURLRules([]byte(defaultURLRules))
+	if err != nil {
+		return nil, errors.Errorf(err, "解析内置url规则失败")
+	}
+	if file == "" {
+		return rules, nil
+	}
+
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.Errorf(err, "读取url规则文件失败, file:%s", file)
+	}
+	custom, err := parseURLRules(b)
+	if err != nil {
+		return nil, errors.Errorf(err, "解析url规则文件失败, file:%s", file)
+	}
+
+	custom.Rules = append(custom.Rules, rules.Rules...)
+	return custom, nil
+}
+
+func parseURLRules(b []byte) (*URLRules, error) {
+	rules := &URLRules{}
+	if err := yaml.Unmarshal(b, rules); err != nil {
+		return nil, errors.Errorf(err, "yaml unmarshal failed")
+	}
+
+	for i, r := range rules.Rules {
+		if r.Host == "" && r.Path == "" {
+			return nil, errors.Errorf(nil, "第%d条url规则没有设置host或path", i+1)
+		}
+
+		var err error
+		if r.Host != "" {
+			if r.hostRe, err = regexp.Compile(r.Host); err != nil {
+				return nil, errors.Errorf(err, "第%d条url规则的host不合法:%s", i+1, r.Host)
+			}
+		}
+		if r.Path != "" {
+			if r.pathRe, err = regexp.Compile(r.Path); err != nil {
+				return nil, errors.Errorf(err, "第%d条url规则的path不合法:%s", i+1, r.Path)
+			}
+		}
+		if r.FileName != "" {
+			if r.tpl, err = template.New(r.Name).Parse(r.FileName); err != nil {
+				return nil, errors.Errorf(err, "第%d条url规则的file_name不合法:%s", i+1, r.FileName)
+			}
+		}
+
+		switch r.Action {
+		case "":
+			r.Action = ActionAuto
+		case ActionAuto, ActionDownload, ActionRender:
+		default:
+			return nil, errors.Errorf(nil, "第%d条url规则的action不合法:%s, 支持: auto/download/render", i+1, r.Action)
+		}
+	}
+	return rules, nil
+}
+
+// Match 返回第一条匹配的规则, 都不匹配时返回nil
+func (r *URLRules) Match(u *url.URL) *URLRule {
+	if r == nil {
+		return nil
+	}
+	for _, rule := range r.Rules {
+		if rule.hostRe != nil && !rule.hostRe.MatchString(u.Host) {
+			continue
+		}
+		if rule.pathRe != nil && !rule.pathRe.MatchString(u.Path) {
+			continue
+		}
+		return rule
+	}
+	return nil
+}
+
+// Action 决定下载还是打印
+func (r *URLRules) Action(u string) string {
+	URL, err := url.Parse(u)
+	if err != nil {
+		return ActionAuto
+	}
+	if rule := r.Match(URL); rule != nil {
+		return rule.Action
+	}
+	return ActionAuto
+}
+
+// FileName 根据匹配的规则生成文件名, 没有匹配的规则或者生成的文件名为空时使用url的md5
+func (r *URLRules) FileName(u string) (string, error) {
+	URL, err := url.Parse(u)
+	if err != nil {
+		return "", errors.Errorf(err, "parse url failed")
+	}
+
+	var fileName string
+	if rule := r.Match(URL); rule != nil && rule.tpl != nil {
+		var buf bytes.Buffer
+		if err := rule.tpl.Execute(&buf, newURLNameData(URL, u, rule.pathRe)); err != nil {
+			return "", errors.Errorf(err, "url规则生成文件名失败, rule:%s", rule.Name)
+		}
+		fileName = util.SanitizeFileName(buf.String())
+	}
+	if fileName == "" {
+		fileName = util.ComputeMd5String(u)
+	}
+
+	// 添加.pdf文件后缀
+	return fileName + common.ExtPDF, nil
+}
+
+// urlNameData url规则中file_name模板的数据
+type urlNameData struct {
+	u      *url.URL
+	raw    string
+	groups map[string]string
+}
+
+func newURLNameData(u *url.URL, raw string, pathRe *regexp.Regexp) *urlNameData {
+	d := &urlNameData{u: u, raw: raw, groups: make(map[string]string)}
+	if pathRe != nil {
+		if m := pathRe.FindStringSubmatch(u.Path); m != nil {
+			for i, name := range pathRe.SubexpNames() {
+				if name != "" {
+					d.groups[name] = m[i]
+				}
+			}
+		}
+	}
+	return d
+}
+
+// Host url的host
+func (d *urlNameData) Host() string {
+	return d.u.Hostname()
+}
+
+// Query url参数
+func (d *urlNameData) Query(key string) string {
+	return d.u.Query().Get(key)
+}
+
+// Segment path中的第i段, 从0开始, 负数表示从后往前数, 忽略空的段
+func (d *urlNameData) Segment(i int) string {
+	var segments []string
+	for _, s := range strings.Split(d.u.Path, "/") {
+		if s != "" {
+			segments = append(segments, s)
+		}
+	}
+	if i < 0 {
+		i += len(segments)
+	}
+	if i < 0 || i >= len(segments) {
+		return ""
+	}
+	return segments[i]
+}
+
+// Base path的最后一段, 去掉后缀
+func (d *urlNameData) Base() string {
+	base := d.Segment(-1)
+	return strings.TrimSuffix(base, path.Ext(base))
+}
+
+// MD5 整个url的md5
+func (d *urlNameData) MD5() string {
+	return util.ComputeMd5String(d.raw)
+}
+
+// Group path正则中命名分组匹配到的内容
+func (d *urlNameData) Group(name string) string {
+	return d.groups[name]
+}
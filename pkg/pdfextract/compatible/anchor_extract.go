@@ -0,0 +1,297 @@
+package compatible
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"invtools/pkg/util"
+	"invtools/utils/errors"
+)
+
+const (
+	anchorDirectionRight = "right" // 读取标签右侧同一行的文字
+	anchorDirectionBelow = "below" // 读取标签下方的文字
+
+	defaultAnchorTolerance = 2.0
+)
+
+// anchorBox 矩形区域, 坐标系与tet一致(原点在页面左下角)
+type anchorBox struct {
+	llx, lly, urx, ury float64
+}
+
+func newAnchorBox(w *util.TetWord) anchorBox {
+	return anchorBox{llx: w.Llx, lly: w.Lly, urx: w.Urx, ury: w.Ury}
+}
+
+func (b anchorBox) union(w *util.TetWord) anchorBox {
+	return anchorBox{
+		llx: math.Min(b.llx, w.Llx),
+		lly: math.Min(b.lly, w.Lly),
+		urx: math.Max(b.urx, w.Urx),
+		ury: math.Max(b.ury, w.Ury),
+	}
+}
+
+// containsCenter 单词的中心点是否在区域内
+func (b anchorBox) containsCenter(w *util.TetWord) bool {
+	cx, cy := (w.Llx+w.Urx)/2, (w.Lly+w.Ury)/2
+	return cx > b.llx && cx < b.urx && cy > b.lly && cy < b.ury
+}
+
+// overlapsX 单词在水平方向上是否与区域有重叠
+func (b anchorBox) overlapsX(llx, urx float64) bool {
+	return llx < b.urx && urx > b.llx
+}
+
+func (se *SingleFileExtractor) extractWithAnchor(cnf *ExtractConfig) (string, error) {
+	if cnf.AnchorText == "" {
+		return "", errors.Errorf(nil, "anchor 标签配置项为空")
+	}
+
+	resource, ok := se.resource[cnf.PageNum]
+	if !ok {
+		return "", errors.Errorf(nil, "配置中的页码不存在,cnf.PageNum:%d, file:%s", cnf.PageNum, se.filePath)
+	}
+
+	if resource.tetPage == nil {
+		f, err := ioutil.TempFile(se.tmpDir, "*.tetml")
+		if err != nil {
+			return "", errors.Errorf(err, "create tmp file failed")
+		}
+		f.Close()
+		defer os.Remove(f.Name())
+
+		pages, err := util.ExtractWordsWithBox(resource.filePath, f.Name())
+		if err != nil {
+			return "", errors.Errorf(err, "tet解析单词坐标失败")
+		}
+		if len(pages) == 0 {
+			return "", errors.Errorf(nil, "tet解析结果中没有页面, file:%s", resource.filePath)
+		}
+		// 拆分后每个文件只有一页; 没有拆分时配置中的页码只会是第1页
+		resource.tetPage = pages[0]
+	}
+
+	text, err := extractByAnchor(resource.tetPage, cnf)
+	se.trace(toolAnchor, text, err)
+	if err != nil {
+		return "", errors.Errorf(err, "锚点解析文字出错")
+	}
+
+	if cnf.RegExp == "" {
+		return text, nil
+	}
+
+	if cnf.compiledRegExp == nil {
+		cnf.compiledRegExp = regexp.MustCompile(cnf.RegExp)
+	}
+
+	res := cnf.compiledRegExp.FindStringSubmatch(text)
+	if len(res) == 2 {
+		return util.StringPurify(res[1]), nil
+	}
+	return "", errors.Errorf(nil, "anchor+正则匹配文字出错")
+}
+
+// anchorMatch 锚点解析的结果: 标签位置, 读取区域, 区域内的单词
+type anchorMatch struct {
+	label, region anchorBox
+	words         []*util.TetWord
+	tolerance     float64
+}
+
+// extractByAnchor 找到标签后读取标签相对区域内的文字
+func extractByAnchor(page *util.TetPage, cnf *ExtractConfig) (string, error) {
+	m, err := matchAnchor(page, cnf)
+	if err != nil {
+		return "", err
+	}
+	return joinWords(m.words, m.tolerance), nil
+}
+
+// matchAnchor 找到标签及标签相对的读取区域
+func matchAnchor(page *util.TetPage, cnf *ExtractConfig) (*anchorMatch, error) {
+	anchors := findAnchors(page.Words, cnf.AnchorText)
+	if len(anchors) == 0 {
+		return nil, errors.Errorf(nil, "页面中没有找到标签:%s", cnf.AnchorText)
+	}
+	// 同一个标签出现多次时取第一个
+	anchor := anchors[0]
+
+	tolerance := cnf.AnchorTolerance
+	if tolerance <= 0 {
+		tolerance = defaultAnchorTolerance
+	}
+
+	pageWidth := page.Width
+	if pageWidth <= 0 {
+		pageWidth = math.MaxFloat64
+	}
+
+	var (
+		words  []*util.TetWord
+		region anchorBox
+	)
+	switch strings.ToLower(cnf.AnchorDirection) {
+	case anchorDirectionRight, "":
+		region = anchorBox{
+			llx: anchor.urx,
+			lly: anchor.lly - tolerance,
+			urx: pageWidth,
+			ury: anchor.ury + tolerance,
+		}
+		if cnf.AnchorDistance > 0 {
+			region.urx = anchor.urx + cnf.AnchorDistance
+		}
+
+		// 同一行右侧最近的下一个标签作为结束位置
+		if cnf.AnchorUntil != "" {
+			for _, until := range findAnchors(page.Words, cnf.AnchorUntil) {
+				cy := (until.lly + until.ury) / 2
+				if until.llx >= anchor.urx && cy > region.lly && cy < region.ury && until.llx < region.urx {
+					region.urx = until.llx
+				}
+			}
+		}
+
+		for _, w := range page.Words {
+			if region.containsCenter(w) {
+				words = append(words, w)
+			}
+		}
+	case anchorDirectionBelow:
+		region = anchorBox{
+			llx: anchor.llx - tolerance,
+			lly: 0,
+			urx: pageWidth,
+			ury: anchor.lly + tolerance,
+		}
+		if cnf.AnchorDistance > 0 {
+			region.lly = anchor.lly - cnf.AnchorDistance
+		}
+
+		// 与标签在同一列, 下方最近的下一个标签作为结束位置
+		column := anchorBox{llx: anchor.llx - tolerance, urx: anchor.urx + tolerance}
+		if cnf.AnchorUntil != "" {
+			for _, until := range findAnchors(page.Words, cnf.AnchorUntil) {
+				if until.ury <= anchor.lly+tolerance && until.ury > region.lly && column.overlapsX(until.llx, until.urx) {
+					region.lly = until.ury
+				}
+			}
+		}
+
+		var candidates []*util.TetWord
+		for _, w := range page.Words {
+			if region.containsCenter(w) {
+				candidates = append(candidates, w)
+			}
+		}
+
+		// 每一行从与标签同列的单词开始, 向右读取连续的单词
+		for _, line := range groupWordsIntoLines(candidates, tolerance) {
+			started := false
+			for i, w := range line {
+				if !started {
+					if !column.overlapsX(w.Llx, w.Urx) {
+						continue
+					}
+					started = true
+				} else if gap := w.Llx - line[i-1].Urx; gap > w.Ury-w.Lly {
+					break
+				}
+				words = append(words, w)
+			}
+		}
+	default:
+		return nil, errors.Errorf(nil, "不支持的锚点方向:%s", cnf.AnchorDirection)
+	}
+
+	return &anchorMatch{label: anchor, region: region, words: words, tolerance: tolerance}, nil
+}
+
+// findAnchors 查找标签文字在页面中出现的所有位置, 标签可以跨多个单词
+func findAnchors(words []*util.TetWord, label string) []anchorBox {
+	target := normalizeAnchorText(label)
+	if target == "" {
+		return nil
+	}
+
+	var boxes []anchorBox
+	for i := 0; i < len(words); i++ {
+		var (
+			joined string
+			box    = newAnchorBox(words[i])
+		)
+		for j := i; j < len(words); j++ {
+			if j > i {
+				box = box.union(words[j])
+			}
+			joined += normalizeAnchorText(words[j].Text)
+
+			if len(joined) >= len(target) {
+				// 允许标签后面紧跟标点, 比如 "Booking No.:"
+				if strings.HasPrefix(joined, target) && strings.TrimFunc(joined[len(target):], unicode.IsPunct) == "" {
+					boxes = append(boxes, box)
+				}
+				break
+			}
+			if !strings.HasPrefix(target, joined) {
+				break
+			}
+		}
+	}
+
+	return boxes
+}
+
+// normalizeAnchorText 去掉空白并转小写, 避免pdf中多余的空格影响匹配
+func normalizeAnchorText(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), ""))
+}
+
+// groupWordsIntoLines 按行分组, 行内按从左到右排序
+func groupWordsIntoLines(words []*util.TetWord, tolerance float64) [][]*util.TetWord {
+	sorted := make([]*util.TetWord, len(words))
+	copy(sorted, words)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Lly+sorted[i].Ury > sorted[j].Lly+sorted[j].Ury
+	})
+
+	var (
+		lines  [][]*util.TetWord
+		lineCy float64
+	)
+	for _, w := range sorted {
+		cy := (w.Lly + w.Ury) / 2
+		if len(lines) == 0 || math.Abs(lineCy-cy) > math.Max((w.Ury-w.Lly)/2, tolerance) {
+			lines = append(lines, []*util.TetWord{w})
+			lineCy = cy
+			continue
+		}
+		lines[len(lines)-1] = append(lines[len(lines)-1], w)
+	}
+
+	for _, line := range lines {
+		sort.SliceStable(line, func(i, j int) bool {
+			return line[i].Llx < line[j].Llx
+		})
+	}
+	return lines
+}
+
+// joinWords 按阅读顺序拼接单词
+func joinWords(words []*util.TetWord, tolerance float64) string {
+	var texts []string
+	for _, line := range groupWordsIntoLines(words, tolerance) {
+		for _, w := range line {
+			texts = append(texts, w.Text)
+		}
+	}
+	return strings.TrimSpace(strings.Join(texts, " "))
+}
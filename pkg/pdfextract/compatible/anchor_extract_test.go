@@ -0,0 +1,89 @@
+package compatible
+
+import (
+	"testing"
+
+	"invtools/pkg/util"
+)
+
+func Test_extractByAnchor(t *testing.T) {
+	// Booking No.: ABC123      Date: 2020-05-01
+	// Guest Name
+	// John Smith   Adult x2
+	// Address
+	page := &util.TetPage{
+		Number: 1,
+		Width:  595,
+		Height: 842,
+		Words: []*util.TetWord{
+			{Text: "Booking", Llx: 40, Lly: 700, Urx: 80, Ury: 710},
+			{Text: "No.:", Llx: 83, Lly: 700, Urx: 100, Ury: 710},
+			{Text: "ABC123", Llx: 105, Lly: 700, Urx: 150, Ury: 710},
+			{Text: "Date:", Llx: 300, Lly: 700, Urx: 325, Ury: 710},
+			{Text: "2020-05-01", Llx: 330, Lly: 700, Urx: 380, Ury: 710},
+			{Text: "Guest", Llx: 40, Lly: 680, Urx: 65, Ury: 690},
+			{Text: "Name", Llx: 68, Lly: 680, Urx: 92, Ury: 690},
+			{Text: "John", Llx: 41, Lly: 665, Urx: 60, Ury: 675},
+			{Text: "Smith", Llx: 63, Lly: 665, Urx: 120, Ury: 675},
+			{Text: "Adult", Llx: 300, Lly: 665, Urx: 325, Ury: 675},
+			{Text: "x2", Llx: 328, Lly: 665, Urx: 338, Ury: 675},
+			{Text: "Address", Llx: 40, Lly: 640, Urx: 75, Ury: 650},
+			{Text: "Tokyo", Llx: 40, Lly: 625, Urx: 70, Ury: 635},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		cnf     *ExtractConfig
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "Test_extractByAnchor_right_to_edge",
+			cnf:  &ExtractConfig{AnchorText: "Booking No", AnchorDirection: "right"},
+			want: "ABC123 Date: 2020-05-01",
+		},
+		{
+			name: "Test_extractByAnchor_right_until",
+			cnf:  &ExtractConfig{AnchorText: "booking no.", AnchorDirection: "right", AnchorUntil: "Date"},
+			want: "ABC123",
+		},
+		{
+			name: "Test_extractByAnchor_right_distance",
+			cnf:  &ExtractConfig{AnchorText: "Date", AnchorDistance: 60},
+			want: "2020-05-01",
+		},
+		{
+			name: "Test_extractByAnchor_below_until",
+			cnf:  &ExtractConfig{AnchorText: "Guest Name", AnchorDirection: "below", AnchorUntil: "Address"},
+			want: "John Smith",
+		},
+		{
+			name: "Test_extractByAnchor_below_distance",
+			cnf:  &ExtractConfig{AnchorText: "Address", AnchorDirection: "below", AnchorDistance: 20},
+			want: "Tokyo",
+		},
+		{
+			name:    "Test_extractByAnchor_label_not_found",
+			cnf:     &ExtractConfig{AnchorText: "Order No"},
+			wantErr: true,
+		},
+		{
+			name:    "Test_extractByAnchor_label_is_prefix_of_word",
+			cnf:     &ExtractConfig{AnchorText: "Add"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := extractByAnchor(page, tt.cnf)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("extractByAnchor() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("extractByAnchor() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
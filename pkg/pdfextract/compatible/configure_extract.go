@@ -29,6 +29,7 @@ import (
 	"github.com/makiuchi-d/gozxing/qrcode"
 	"github.com/otiai10/gosseract"
 	"github.com/skratchdot/open-golang/open"
+	"gopkg.in/yaml.v2"
 )
 
 func (e *Extractor) executeWithConf() error {
@@ -184,45 +185,68 @@ const (
 var defaultTextExtractTools = []string{txtToolXpdf, txtToolUnipdf, txtToolOcr}
 
 type ExtractConfig struct {
-	FieldName string `json:"field_name"`
-	PageNum   int    `json:"page_num"`
+	FieldName string `json:"field_name" yaml:"field_name"`
+	PageNum   int    `json:"page_num" yaml:"page_num"`
 	// tet,正则匹配文字(ocr转文字/pdf转文字)，条码扫描(pdf解析出图片/图片切割)
-	// 枚举值: tet/reg/scan
-	ExtractMethod    string   `json:"extract_method"`
-	TextExtractTools []string `json:"text_extract_tool"` // [unipdf,pdftotext,ocr]
-	TetCoordinates   []string `json:"tet_coordinates"`
-	CropCoordinates  []int    `json:"crop_coordinates"` // [minX, minY, maxX, maxY]
-	RegExp           string   `json:"reg_exp"`
-	compiledRegExp   *regexp.Regexp            // 编译后的正则表达式
-	CodeType         string `json:"code_type"` // qrcode, barcode128
+	// 枚举值: tet/reg/scan/anchor
+	ExtractMethod    string         `json:"extract_method" yaml:"extract_method"`
+	TextExtractTools []string       `json:"text_extract_tool" yaml:"text_extract_tool"` // [unipdf,pdftotext,ocr]
+	TetCoordinates   []string       `json:"tet_coordinates" yaml:"tet_coordinates"`
+	CropCoordinates  []int          `json:"crop_coordinates" yaml:"crop_coordinates"` // [minX, minY, maxX, maxY]
+	RegExp           string         `json:"reg_exp" yaml:"reg_exp"`
+	compiledRegExp   *regexp.Regexp // 编译后的正则表达式
+	CodeType         string         `json:"code_type" yaml:"code_type"` // qrcode, barcode128
+
+	// 锚点解析: 先根据文字定位标签, 再读取标签相对位置的文字
+	AnchorText      string  `json:"anchor_text" yaml:"anchor_text"`           // 标签文字, 比如 "Booking No."
+	AnchorDirection string  `json:"anchor_direction" yaml:"anchor_direction"` // 读取方向: right/below
+	AnchorDistance  float64 `json:"anchor_distance" yaml:"anchor_distance"`   // 读取范围(point), 0表示读到页面边缘
+	AnchorTolerance float64 `json:"anchor_tolerance" yaml:"anchor_tolerance"` // 与标签对齐的容差(point)
+	AnchorUntil     string  `json:"anchor_until" yaml:"anchor_until"`         // 读到下一个标签为止
 }
 
 func (e *Extractor) parseConf() error {
 	if e.Config != nil {
 		return nil
 	}
-	if !utils.CheckFileIsExist(e.withCnf) {
-		return errors.Errorf(nil, "config file not exists")
+	cnf, err := LoadExtractConfig(e.withCnf)
+	if err != nil {
+		return err
+	}
+
+	e.Config = cnf
+	return nil
+}
+
+// LoadExtractConfig 读取配置文件, 支持json和yaml格式
+func LoadExtractConfig(file string) ([]*ExtractConfig, error) {
+	if !utils.CheckFileIsExist(file) {
+		return nil, errors.Errorf(nil, "config file not exists")
 	}
-	b, err := ioutil.ReadFile(e.withCnf)
+	b, err := ioutil.ReadFile(file)
 	if err != nil {
-		return errors.Errorf(err, "read config file error,file:%s", e.withCnf)
+		return nil, errors.Errorf(err, "read config file error,file:%s", file)
 	}
 
 	var cnf []*ExtractConfig
-	err = json.Unmarshal(b, &cnf)
+	switch strings.ToLower(path.Ext(file)) {
+	case common.ExtYaml, common.ExtYml:
+		err = yaml.Unmarshal(b, &cnf)
+	default:
+		err = json.Unmarshal(b, &cnf)
+	}
 	if err != nil {
-		return errors.Errorf(err, "unmarshal config data failed")
+		return nil, errors.Errorf(err, "unmarshal config data failed")
 	}
 
-	e.Config = cnf
-	return nil
+	return cnf, nil
 }
 
 const (
-	ExtractMethodTET  = "tet"  // 使用tet坐标匹配
-	ExtractMethodReg  = "reg"  // 使用正则匹配：解析text进行匹配/ocr转文字进行匹配
-	ExtractMethodScan = "scan" // 使用扫描：解析图片进行扫描/切割图片进行扫描
+	ExtractMethodTET    = "tet"    // 使用tet坐标匹配
+	ExtractMethodReg    = "reg"    // 使用正则匹配：解析text进行匹配/ocr转文字进行匹配
+	ExtractMethodScan   = "scan"   // 使用扫描：解析图片进行扫描/切割图片进行扫描
+	ExtractMethodAnchor = "anchor" // 使用锚点：根据标签文字的位置读取相对区域的文字
 )
 
 func (e *Extractor) extractWithConf(filePath string) (*Result, error) {
@@ -251,20 +275,7 @@ func (e *Extractor) extractWithConf(filePath string) (*Result, error) {
 
 	for i := 0; i < len(e.Config); i++ {
 		cnf := e.Config[i]
-		var (
-			err   error
-			value string
-		)
-		switch strings.ToLower(cnf.ExtractMethod) {
-		case ExtractMethodTET:
-			value, err = se.extractWithTET(cnf)
-		case ExtractMethodReg:
-			value, err = se.extractWithRegV2(cnf)
-		case ExtractMethodScan:
-			value, err = se.extractWithScan(cnf)
-		default:
-			return nil, errors.Errorf(nil, "配置项中的ExtractMethod不合法")
-		}
+		value, err := se.extractField(cnf)
 		if err != nil {
 			return nil, errors.Errorf(err, "解析过程出错")
 		}
@@ -275,6 +286,22 @@ func (e *Extractor) extractWithConf(filePath string) (*Result, error) {
 	return result, nil
 }
 
+// extractField 按配置项中的ExtractMethod解析单个字段
+func (se *SingleFileExtractor) extractField(cnf *ExtractConfig) (string, error) {
+	switch strings.ToLower(cnf.ExtractMethod) {
+	case ExtractMethodTET:
+		return se.extractWithTET(cnf)
+	case ExtractMethodReg:
+		return se.extractWithRegV2(cnf)
+	case ExtractMethodScan:
+		return se.extractWithScan(cnf)
+	case ExtractMethodAnchor:
+		return se.extractWithAnchor(cnf)
+	default:
+		return "", errors.Errorf(nil, "配置项中的ExtractMethod不合法")
+	}
+}
+
 // SingleFileExtractor 单个文件解析器
 type SingleFileExtractor struct {
 	filePath   string
@@ -285,17 +312,19 @@ type SingleFileExtractor struct {
 	PageNumber int    // 页数
 	extractor  *Extractor
 	resource   map[int]*PageResource
+	explain    *fieldExplain // 不为nil时记录当前字段每个工具的尝试结果
 }
 
 // PageResource 每一页pdf的资源
 type PageResource struct {
-	filePath            string   // 拆分后的pdf文件保存位置
-	ocrImage            []byte   // ocr扫描得到的图片
-	ocrText             string   // ocr解析出来的文字
-	extractedText       string   // unidoc解析出来的文字
-	extractedImages     [][]byte // unidoc解析出来的图片
-	extractedImageFiles []string // unidoc解析出来的图片文件
-	croppedImages       [][]byte // 切割出来的图片
+	filePath            string        // 拆分后的pdf文件保存位置
+	ocrImage            []byte        // ocr扫描得到的图片
+	ocrText             string        // ocr解析出来的文字
+	extractedText       string        // unidoc解析出来的文字
+	extractedImages     [][]byte      // unidoc解析出来的图片
+	extractedImageFiles []string      // unidoc解析出来的图片文件
+	croppedImages       [][]byte      // 切割出来的图片
+	tetPage             *util.TetPage // tet解析出来的单词及坐标
 }
 
 func (se *SingleFileExtractor) initPageResource() error {
@@ -359,8 +388,10 @@ func (se *SingleFileExtractor) extractWithScan(cnf *ExtractConfig) (string, erro
 		// 先从pdf中解析出图片资源
 		imagesFiles, err := util.NewUniPdf().ExtractImagesIntoFiles(resource.filePath, se.tmpJpegDir)
 		if err != nil {
+			se.trace(toolUnipdfImages, "", err)
 			imagesFiles, err = pdfcpu.PdfToPng(resource.filePath, se.tmpJpegDir)
 			if err != nil {
+				se.trace(toolPdfcpuImages, "", err)
 				logger.Errorf("使用pdfcpu解析图片也出错,err:%+v", err)
 			}
 		}
@@ -397,15 +428,18 @@ func (se *SingleFileExtractor) extractWithScan(cnf *ExtractConfig) (string, erro
 		}
 		result, err := gozxingReader.Decode(bmp, nil)
 		if err != nil {
+			se.trace(fmt.Sprintf("%s[%s]", toolScanImage, path.Base(imgFile)), "", err)
 			// 出错继续
 			continue
 		}
+		se.trace(fmt.Sprintf("%s[%s]", toolScanImage, path.Base(imgFile)), result.String(), nil)
 
 		// 匹配到则返回
 		return result.String(), nil
 	}
 
 	if len(cnf.CropCoordinates) == 0 {
+		se.trace(toolScanCrop, "", errors.Errorf(nil, "没有配置crop_coordinates, 跳过"))
 		return "", nil
 	}
 	// todo: 解析图片没成功的话，使用图片切割
@@ -431,8 +465,9 @@ func (se *SingleFileExtractor) extractWithScan(cnf *ExtractConfig) (string, erro
 	case common.CodeTypeBarcode128:
 		codeScanRes, err = util.Barcode128Scan(croppedPngFile)
 	default:
-		errors.Errorf(nil, "暂不支持的code类型:%s", cnf.CodeType)
+		err = errors.Errorf(nil, "暂不支持的code类型:%s", cnf.CodeType)
 	}
+	se.trace(toolScanCrop, codeScanRes, err)
 	return codeScanRes, nil
 }
 
@@ -552,6 +587,7 @@ func (se *SingleFileExtractor) extractWithRegV2(cnf *ExtractConfig) (string, err
 		case txtToolOcr:
 			text, err = se.extractWithRegByOcr(cnf)
 		}
+		se.trace(tool, text, err)
 		if err != nil {
 			continue
 		}
@@ -647,6 +683,7 @@ func (se *SingleFileExtractor) extractWithTET(cnf *ExtractConfig) (string, error
 
 	f, err := ioutil.TempFile(common.CurrentDir, "*.txt")
 	if err != nil {
+		se.trace(toolTet, "", err)
 		return "", nil
 		// todo: 处理error
 		//return nil, errors.Errorf(err, "create tmp file failed")
@@ -656,6 +693,7 @@ func (se *SingleFileExtractor) extractWithTET(cnf *ExtractConfig) (string, error
 	defer os.Remove(f.Name())
 
 	text, err := util.ExtractTextByCoordinate(resource.filePath, strings.Join(cnf.TetCoordinates, " "), f.Name())
+	se.trace(toolTet, text, err)
 	if err != nil {
 		return "", nil
 		// todo: 处理error
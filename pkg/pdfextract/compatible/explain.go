@@ -0,0 +1,274 @@
+package compatible
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"path"
+	"strconv"
+	"strings"
+
+	"invtools/common"
+	"invtools/pkg/util"
+	"invtools/pkg/util/xpdf"
+	"invtools/utils"
+	"invtools/utils/errors"
+
+	"github.com/skratchdot/open-golang/open"
+)
+
+const (
+	explainCmdName = "explain"
+
+	toolTet          = "tet"
+	toolAnchor       = "anchor"
+	toolUnipdfImages = "unipdf_images" // unipdf解析pdf中的图片
+	toolPdfcpuImages = "pdfcpu_images" // pdfcpu解析pdf中的图片
+	toolScanImage    = "scan_image"    // 扫描pdf中的图片
+	toolScanCrop     = "scan_crop"     // 扫描裁剪后的图片
+)
+
+var (
+	explainRegionColor  = color.NRGBA{R: 30, G: 110, B: 255, A: 255} // 解析区域
+	explainLabelColor   = color.NRGBA{R: 255, G: 140, B: 0, A: 255}  // 锚点标签
+	explainMatchColor   = color.NRGBA{R: 0, G: 200, B: 80, A: 90}    // 匹配到的文字
+	explainBarcodeColor = color.NRGBA{R: 230, G: 0, B: 0, A: 255}    // 扫描到的二维码/条形码
+)
+
+// explainAttempt 字段解析过程中某个工具的一次尝试
+type explainAttempt struct {
+	tool  string
+	value string
+	err   error
+}
+
+// fieldExplain 单个字段的解析过程
+type fieldExplain struct {
+	index    int
+	cnf      *ExtractConfig
+	value    string
+	err      error
+	attempts []explainAttempt
+}
+
+// trace 记录当前字段某个工具的尝试结果, 只在explain模式下生效
+func (se *SingleFileExtractor) trace(tool, value string, err error) {
+	if se.explain == nil {
+		return
+	}
+	se.explain.attempts = append(se.explain.attempts, explainAttempt{tool: tool, value: value, err: err})
+}
+
+// Explain 使用配置文件解析单个pdf, 打印每个字段尝试过的工具及失败原因,
+// 并生成一份标注了解析区域、匹配文字和条码位置的pdf
+func (e *Extractor) Explain(input, output string) error {
+	if !utils.CheckFileIsExist(input) || strings.ToLower(path.Ext(input)) != common.ExtPDF {
+		return errors.Errorf(nil, "input 不是一个pdf文件, input:%s", input)
+	}
+	if e.withCnf == "" {
+		return errors.Errorf(nil, "explain 模式需要指定配置文件")
+	}
+	if err := e.parseConf(); err != nil {
+		return errors.Errorf(err, "获取配置文件失败")
+	}
+
+	e.RegisterCleanUpFunc()
+	defer e.cleanTmpDir()
+
+	se := &SingleFileExtractor{
+		filePath:  input,
+		extractor: e,
+	}
+	if err := se.initPageResource(); err != nil {
+		return errors.Errorf(err, "初始化pageResource失败")
+	}
+
+	var explains []*fieldExplain
+	for i, cnf := range e.Config {
+		se.explain = &fieldExplain{index: i + 1, cnf: cnf}
+		se.explain.value, se.explain.err = se.extractField(cnf)
+		explains = append(explains, se.explain)
+	}
+	se.explain = nil
+
+	printExplains(explains)
+
+	if err := se.annotate(explains, output); err != nil {
+		return errors.Errorf(err, "生成标注pdf失败")
+	}
+	fmt.Printf("[%s] 标注后的pdf: %s\n", explainCmdName, output)
+
+	open.Run(path.Dir(output))
+	return nil
+}
+
+func printExplains(explains []*fieldExplain) {
+	for _, fe := range explains {
+		status := fmt.Sprintf("%q", fe.value)
+		if fe.err != nil {
+			status = fmt.Sprintf("失败, %s", explainReason(fe.err))
+		} else if fe.value == "" {
+			status = "结果为空"
+		}
+		fmt.Printf("[%s] #%d %s (%s, page %d): %s\n", explainCmdName,
+			fe.index, fe.cnf.FieldName, fe.cnf.ExtractMethod, fe.cnf.PageNum, status)
+
+		for _, a := range fe.attempts {
+			switch {
+			case a.err != nil:
+				fmt.Printf("    - %s: 失败, %s\n", a.tool, explainReason(a.err))
+			case a.value == "":
+				fmt.Printf("    - %s: 结果为空\n", a.tool)
+			default:
+				fmt.Printf("    - %s: 成功, %q\n", a.tool, a.value)
+			}
+		}
+	}
+}
+
+// explainReason 只取最里层的错误信息, 完整的调用栈太长
+func explainReason(err error) string {
+	return strings.TrimSpace(errors.GetInnerMostV2(err).Error())
+}
+
+// annotate 渲染每一页并标注每个字段, 生成新的pdf
+func (se *SingleFileExtractor) annotate(explains []*fieldExplain, output string) error {
+	pages, err := util.ExtractWordsWithBox(se.filePath, path.Join(se.tmpDir, "explain.tetml"))
+	if err != nil {
+		return errors.Errorf(err, "tet解析单词坐标失败")
+	}
+
+	pngFiles, err := xpdf.PdfToPngV2(se.filePath, se.tmpPngDir, explainCmdName)
+	if err != nil {
+		return errors.Errorf(err, "pdf转图片失败")
+	}
+
+	var imagePages []*util.ImagePage
+	for idx, page := range pages {
+		if idx >= len(pngFiles) {
+			break
+		}
+
+		img, err := util.LoadImage(pngFiles[idx])
+		if err != nil {
+			return errors.Errorf(err, "加载图片失败")
+		}
+		scale, err := util.NewPageScale(page.Width, page.Height, img.Bounds())
+		if err != nil {
+			return errors.Errorf(err, "计算页面坐标换算失败, page:%d", page.Number)
+		}
+
+		dst := util.DrawCoordinateGrid(img, scale, 0)
+		for _, fe := range explains {
+			if fe.cnf.PageNum == page.Number {
+				annotateField(dst, page, scale, fe)
+			}
+		}
+
+		imagePages = append(imagePages, &util.ImagePage{Image: dst, Width: page.Width, Height: page.Height})
+	}
+
+	return util.NewUniPdf().ImagesToPdf(imagePages, output)
+}
+
+// annotateField 画出字段的解析区域(蓝色), 锚点标签(橙色), 匹配到的文字(绿色高亮), 条码位置(红色)
+func annotateField(dst *image.NRGBA, page *util.TetPage, scale *util.PageScale, fe *fieldExplain) {
+	var (
+		cnf    = fe.cnf
+		labels []image.Rectangle
+	)
+
+	highlight := func(words []*util.TetWord) {
+		for _, w := range words {
+			r := scale.ToPixelRect(w.Llx, w.Lly, w.Urx, w.Ury)
+			util.FillRect(dst, r, explainMatchColor)
+			labels = append(labels, r)
+		}
+	}
+	outline := func(r image.Rectangle, c color.NRGBA) {
+		util.DrawRect(dst, r, c, 2)
+		labels = append(labels, r)
+	}
+
+	switch strings.ToLower(cnf.ExtractMethod) {
+	case ExtractMethodTET:
+		for _, box := range parseTetCoordinates(cnf.TetCoordinates) {
+			outline(scale.ToPixelRect(box.llx, box.lly, box.urx, box.ury), explainRegionColor)
+			if fe.value == "" {
+				continue
+			}
+			var words []*util.TetWord
+			for _, w := range page.Words {
+				if box.containsCenter(w) {
+					words = append(words, w)
+				}
+			}
+			highlight(words)
+		}
+	case ExtractMethodReg:
+		if fe.value != "" {
+			highlight(findValueWords(page.Words, fe.value))
+		}
+	case ExtractMethodAnchor:
+		m, err := matchAnchor(page, cnf)
+		if err != nil {
+			break
+		}
+		outline(scale.ToPixelRect(m.label.llx, m.label.lly, m.label.urx, m.label.ury), explainLabelColor)
+		outline(scale.ToPixelRect(m.region.llx, m.region.lly, m.region.urx, m.region.ury), explainRegionColor)
+		highlight(m.words)
+	case ExtractMethodScan:
+		// 在渲染后的页面上重新定位条码, pdf中的图片坐标无法直接换算
+		area := image.Image(dst)
+		if len(cnf.CropCoordinates) == 4 {
+			c := cnf.CropCoordinates
+			crop := image.Rect(c[0], c[1], c[2], c[3])
+			outline(crop, explainRegionColor)
+			area = dst.SubImage(crop)
+		}
+		if _, box, err := util.LocateCode(area, cnf.CodeType); err == nil {
+			outline(box, explainBarcodeColor)
+		}
+	}
+
+	if len(labels) == 0 {
+		return
+	}
+	// 在第一个标注的左上方写上字段序号, 与终端输出对应
+	text := fmt.Sprintf("#%d %s", fe.index, cnf.FieldName)
+	util.DrawText(dst, labels[0].Min.X, labels[0].Min.Y-3, text, explainRegionColor)
+}
+
+// parseTetCoordinates tet_coordinates 每4个数字为一个区域: llx lly urx ury
+func parseTetCoordinates(coordinates []string) []anchorBox {
+	var nums []float64
+	for _, v := range strings.Fields(strings.Join(coordinates, " ")) {
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil
+		}
+		nums = append(nums, n)
+	}
+
+	var boxes []anchorBox
+	for i := 0; i+3 < len(nums); i += 4 {
+		boxes = append(boxes, anchorBox{llx: nums[i], lly: nums[i+1], urx: nums[i+2], ury: nums[i+3]})
+	}
+	return boxes
+}
+
+// findValueWords 找到解析结果在页面中对应的单词
+func findValueWords(words []*util.TetWord, value string) []*util.TetWord {
+	boxes := findAnchors(words, value)
+	if len(boxes) == 0 {
+		return nil
+	}
+
+	var matched []*util.TetWord
+	for _, w := range words {
+		if boxes[0].containsCenter(w) {
+			matched = append(matched, w)
+		}
+	}
+	return matched
+}
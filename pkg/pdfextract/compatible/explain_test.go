@@ -0,0 +1,59 @@
+package compatible
+
+import (
+	"reflect"
+	"testing"
+
+	"invtools/pkg/util"
+)
+
+func Test_parseTetCoordinates(t *testing.T) {
+	tests := []struct {
+		name        string
+		coordinates []string
+		want        []anchorBox
+	}{
+		{
+			name:        "Test_parseTetCoordinates_one",
+			coordinates: []string{"40 700 150 710"},
+			want:        []anchorBox{{llx: 40, lly: 700, urx: 150, ury: 710}},
+		},
+		{
+			name:        "Test_parseTetCoordinates_split",
+			coordinates: []string{"40", "700", "150.5", "710", "300 700 380 710"},
+			want: []anchorBox{
+				{llx: 40, lly: 700, urx: 150.5, ury: 710},
+				{llx: 300, lly: 700, urx: 380, ury: 710},
+			},
+		},
+		{
+			name:        "Test_parseTetCoordinates_invalid",
+			coordinates: []string{"40 700 abc 710"},
+			want:        nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseTetCoordinates(tt.coordinates); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseTetCoordinates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_findValueWords(t *testing.T) {
+	words := []*util.TetWord{
+		{Text: "Total:", Llx: 40, Lly: 700, Urx: 70, Ury: 710},
+		{Text: "JPY", Llx: 75, Lly: 700, Urx: 95, Ury: 710},
+		{Text: "12,000", Llx: 98, Lly: 700, Urx: 130, Ury: 710},
+	}
+
+	got := findValueWords(words, "JPY 12,000")
+	if len(got) != 2 || got[0].Text != "JPY" || got[1].Text != "12,000" {
+		t.Errorf("findValueWords() = %v, want [JPY 12,000]", got)
+	}
+
+	if got := findValueWords(words, "USD"); got != nil {
+		t.Errorf("findValueWords() = %v, want nil", got)
+	}
+}
@@ -0,0 +1,301 @@
+package compatible
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"invtools/common"
+	"invtools/pkg/util"
+	"invtools/utils"
+	"invtools/utils/errors"
+
+	"github.com/gosuri/uiprogress"
+)
+
+const (
+	templateTestCmdName = "template test"
+
+	// ExpectedFileSuffix 期望值文件的后缀, 与pdf放在同一目录: voucher.pdf.expected.json
+	ExpectedFileSuffix = ".expected.json"
+)
+
+// TemplateTester 使用期望值文件(golden file)对配置模板做回归测试
+type TemplateTester struct {
+	template, inputDir string
+	concurrency        int
+}
+
+// NewTemplateTester instance an new TemplateTester
+func NewTemplateTester(template, inputDir string, concurrency int) *TemplateTester {
+	return &TemplateTester{
+		template:    template,
+		inputDir:    inputDir,
+		concurrency: concurrency,
+	}
+}
+
+// FieldDiff 单个字段的对比结果
+type FieldDiff struct {
+	FieldName string
+	Expected  string
+	Got       string
+	Pass      bool
+}
+
+// GoldenResult 单个文件的对比结果
+type GoldenResult struct {
+	Filename string
+	Err      error // extractWithConf 出错时整个文件的字段都视为空
+	Diffs    []*FieldDiff
+}
+
+// GoldenReport 所有文件的对比结果
+type GoldenReport struct {
+	Results []*GoldenResult
+	Skipped []string // 没有期望值文件的pdf
+
+	Passed, Failed                             int // 字段数
+	TruePositive, FalsePositive, FalseNegative int
+}
+
+// Regressed 有任意一个字段不符合期望值即为回归
+func (r *GoldenReport) Regressed() bool {
+	return r.Failed > 0
+}
+
+// Precision 解析出来的非空值中正确的比例
+func (r *GoldenReport) Precision() float64 {
+	if r.TruePositive+r.FalsePositive == 0 {
+		return 1
+	}
+	return float64(r.TruePositive) / float64(r.TruePositive+r.FalsePositive)
+}
+
+// Recall 期望的非空值中被正确解析出来的比例
+func (r *GoldenReport) Recall() float64 {
+	if r.TruePositive+r.FalseNegative == 0 {
+		return 1
+	}
+	return float64(r.TruePositive) / float64(r.TruePositive+r.FalseNegative)
+}
+
+// Validate .
+func (t *TemplateTester) Validate() error {
+	if t == nil {
+		return errors.Errorf(nil, "receiver is nil")
+	}
+	if !utils.CheckFileIsExist(t.template) {
+		return errors.Errorf(nil, "配置模板不存在, template:%s", t.template)
+	}
+	if !utils.CheckDirIsExist(t.inputDir) {
+		return errors.Errorf(nil, "input directory not exists")
+	}
+	if t.concurrency <= 0 {
+		t.concurrency = 1
+	}
+	return nil
+}
+
+// Run 解析目录下所有带期望值文件的pdf, 与期望值逐个字段对比
+func (t *TemplateTester) Run() (*GoldenReport, error) {
+	if err := t.Validate(); err != nil {
+		return nil, errors.Errorf(err, "参数校验失败")
+	}
+
+	e := &Extractor{withCnf: t.template}
+	if err := e.parseConf(); err != nil {
+		return nil, errors.Errorf(err, "获取配置文件失败")
+	}
+	e.RegisterCleanUpFunc()
+	defer e.cleanTmpDir()
+
+	files, err := util.ReadDirFilesV3(t.inputDir, common.ExtPDF)
+	if err != nil {
+		return nil, errors.Errorf(err, "read input directory failed")
+	}
+
+	var (
+		report = &GoldenReport{}
+		cases  []string
+	)
+	for _, f := range files {
+		if utils.CheckFileIsExist(f + ExpectedFileSuffix) {
+			cases = append(cases, f)
+		} else {
+			report.Skipped = append(report.Skipped, f)
+		}
+	}
+	if len(cases) == 0 {
+		return nil, errors.Errorf(nil, "目录中没有带期望值文件(*%s)的pdf", ExpectedFileSuffix)
+	}
+	fmt.Printf("[%s] 一共%d个pdf有期望值文件, 即将开始解析...\n", templateTestCmdName, len(cases))
+
+	var (
+		wg      sync.WaitGroup
+		st      = time.Now()
+		resultm sync.Map
+	)
+
+	groups := util.DivideSliceIntoGroup(cases, t.concurrency)
+	uiprogress.Start()
+	wg.Add(len(groups))
+
+	for i := 0; i < len(groups); i++ {
+		var (
+			ctx = context.Background()
+			grp = groups[i]
+			cnt = len(grp)
+		)
+		go utils.HandlePanicV2(ctx, func(i interface{}) {
+			ggrp := *i.(*[]string)
+			defer wg.Done()
+
+			bar := uiprogress.AddBar(cnt).AppendCompleted().PrependElapsed()
+			bar.PrependFunc(func(b *uiprogress.Bar) string {
+				return fmt.Sprintf("processing: %d/%d", b.Current(), cnt)
+			})
+
+			for bar.Incr() {
+				f := ggrp[bar.Current()-1]
+				resultm.Store(f, t.testFile(e, f))
+			}
+		})(&grp)
+	}
+
+	wg.Wait()
+	uiprogress.Stop()
+
+	resultm.Range(func(key, value interface{}) bool {
+		report.Results = append(report.Results, value.(*GoldenResult))
+		return true
+	})
+	sort.Slice(report.Results, func(i, j int) bool {
+		return report.Results[i].Filename < report.Results[j].Filename
+	})
+	report.summarize()
+
+	fmt.Printf("[%s] 解析完成, 总耗时:%s\n", templateTestCmdName, time.Since(st))
+	return report, nil
+}
+
+// testFile 解析单个pdf并与期望值对比
+func (t *TemplateTester) testFile(e *Extractor, file string) *GoldenResult {
+	res := &GoldenResult{Filename: file}
+
+	expected, err := loadExpected(file + ExpectedFileSuffix)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+
+	got := map[string]string{}
+	data, err := e.extractWithConf(file)
+	if err != nil {
+		res.Err = err
+	} else {
+		got = data.Data
+	}
+
+	var fields []string
+	for _, cnf := range e.Config {
+		fields = append(fields, cnf.FieldName)
+	}
+	res.Diffs = compareFields(fields, expected, got)
+	return res
+}
+
+// loadExpected 读取期望值文件, 格式为 {"field_name": "value"}
+func loadExpected(file string) (map[string]string, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.Errorf(err, "read expected file failed, file:%s", file)
+	}
+
+	var expected map[string]string
+	if err := json.Unmarshal(b, &expected); err != nil {
+		return nil, errors.Errorf(err, "unmarshal expected file failed, file:%s", file)
+	}
+	return expected, nil
+}
+
+// compareFields 按配置中的字段顺序对比, 期望值文件中没有的字段不参与对比
+func compareFields(fields []string, expected, got map[string]string) []*FieldDiff {
+	var diffs []*FieldDiff
+	for _, field := range fields {
+		want, ok := expected[field]
+		if !ok {
+			continue
+		}
+		diffs = append(diffs, &FieldDiff{
+			FieldName: field,
+			Expected:  want,
+			Got:       got[field],
+			Pass:      want == got[field],
+		})
+	}
+	return diffs
+}
+
+func (r *GoldenReport) summarize() {
+	for _, res := range r.Results {
+		for _, d := range res.Diffs {
+			if d.Pass {
+				r.Passed++
+			} else {
+				r.Failed++
+			}
+
+			switch {
+			case d.Pass && d.Expected != "":
+				r.TruePositive++
+			case d.Pass:
+				// 期望为空, 解析结果也为空
+			case d.Got != "" && d.Expected != "":
+				// 解析出了错误的值, 既是误报也是漏报
+				r.FalsePositive++
+				r.FalseNegative++
+			case d.Got != "":
+				r.FalsePositive++
+			default:
+				r.FalseNegative++
+			}
+		}
+		if res.Err != nil && len(res.Diffs) == 0 {
+			// 期望值文件读取失败
+			r.Failed++
+		}
+	}
+}
+
+// Print 打印每个文件每个字段的对比结果及汇总
+func (r *GoldenReport) Print(inputDir string) {
+	for _, res := range r.Results {
+		name := res.Filename
+		if rel, err := filepath.Rel(inputDir, res.Filename); err == nil {
+			name = rel
+		}
+		fmt.Printf("[%s] %s\n", templateTestCmdName, name)
+		if res.Err != nil {
+			fmt.Printf("    ERROR %s\n", explainReason(res.Err))
+		}
+		for _, d := range res.Diffs {
+			if d.Pass {
+				fmt.Printf("    PASS  %s: %q\n", d.FieldName, d.Got)
+			} else {
+				fmt.Printf("    FAIL  %s: expected %q, got %q\n", d.FieldName, d.Expected, d.Got)
+			}
+		}
+	}
+
+	if len(r.Skipped) > 0 {
+		fmt.Printf("[%s] %d个pdf没有期望值文件, 已跳过\n", templateTestCmdName, len(r.Skipped))
+	}
+	fmt.Printf("[%s] 文件:%d, 字段通过:%d, 失败:%d, precision:%.2f%%, recall:%.2f%%\n",
+		templateTestCmdName, len(r.Results), r.Passed, r.Failed, r.Precision()*100, r.Recall()*100)
+}
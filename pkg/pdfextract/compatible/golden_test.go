@@ -0,0 +1,55 @@
+package compatible
+
+import (
+	"testing"
+)
+
+func TestGoldenReport_summarize(t *testing.T) {
+	fields := []string{"booking_no", "date", "amount", "remark", "not_expected"}
+	expected := map[string]string{
+		"booking_no": "ABC123",
+		"date":       "2020-05-01",
+		"amount":     "12,000",
+		"remark":     "",
+	}
+	got := map[string]string{
+		"booking_no":   "ABC123",
+		"date":         "2020-05-0l",
+		"remark":       "N/A",
+		"not_expected": "x",
+	}
+
+	diffs := compareFields(fields, expected, got)
+	if len(diffs) != 4 {
+		t.Fatalf("compareFields() got %d diffs, want 4", len(diffs))
+	}
+
+	report := &GoldenReport{Results: []*GoldenResult{{Filename: "voucher.pdf", Diffs: diffs}}}
+	report.summarize()
+
+	tests := []struct {
+		name      string
+		got, want int
+	}{
+		{name: "passed", got: report.Passed, want: 1},
+		{name: "failed", got: report.Failed, want: 3},
+		{name: "true_positive", got: report.TruePositive, want: 1},
+		{name: "false_positive", got: report.FalsePositive, want: 2},
+		{name: "false_negative", got: report.FalseNegative, want: 2},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("GoldenReport.%s = %d, want %d", tt.name, tt.got, tt.want)
+		}
+	}
+
+	if !report.Regressed() {
+		t.Errorf("GoldenReport.Regressed() = false, want true")
+	}
+	if p := report.Precision(); p < 0.333 || p > 0.334 {
+		t.Errorf("GoldenReport.Precision() = %v, want 1/3", p)
+	}
+	if r := report.Recall(); r < 0.333 || r > 0.334 {
+		t.Errorf("GoldenReport.Recall() = %v, want 1/3", r)
+	}
+}
@@ -0,0 +1,332 @@
+package compatible
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path"
+	"strings"
+	"unicode"
+
+	"invtools/common"
+	"invtools/pkg/util"
+	"invtools/pkg/util/xpdf"
+	"invtools/utils"
+	"invtools/utils/errors"
+
+	"github.com/skratchdot/open-golang/open"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	inspectCmdName = "inspect"
+
+	TemplateFormatJSON = "json"
+	TemplateFormatYaml = "yaml"
+
+	DefaultGridStep = 50.0
+)
+
+// Inspector 用于辅助编写配置文件:
+// 1. 渲染每一页为带坐标网格的png图片
+// 2. 导出每个单词及其坐标
+// 3. 根据 "标签: 值" 生成一份初始的配置模板
+type Inspector struct {
+	input, outputDir, format string
+	gridStep                 float64
+}
+
+// NewInspector instance an new Inspector
+func NewInspector(input, outputDir, format string, gridStep float64) *Inspector {
+	return &Inspector{
+		input:     input,
+		outputDir: outputDir,
+		format:    format,
+		gridStep:  gridStep,
+	}
+}
+
+// Validate .
+func (i *Inspector) Validate() error {
+	if i == nil {
+		return errors.Errorf(nil, "receiver is nil")
+	}
+
+	if !utils.CheckFileIsExist(i.input) || strings.ToLower(path.Ext(i.input)) != common.ExtPDF {
+		return errors.Errorf(nil, "input 不是一个pdf文件, input:%s", i.input)
+	}
+
+	if i.format != TemplateFormatJSON && i.format != TemplateFormatYaml {
+		return errors.Errorf(nil, "不支持的模板格式:%s", i.format)
+	}
+
+	if err := utils.CheckAndMkDir(i.outputDir); err != nil {
+		return errors.Errorf(err, "创建目录失败,目录:%s", i.outputDir)
+	}
+
+	return nil
+}
+
+func (i *Inspector) Do() error {
+	if err := i.Validate(); err != nil {
+		return errors.Errorf(err, "参数校验失败")
+	}
+
+	tmpDir := path.Join(i.outputDir, fmt.Sprintf("%s_%s", tmpDirName, utils.GetUUIDString()))
+	if err := utils.CheckAndMkDir(tmpDir); err != nil {
+		return errors.Errorf(err, "创建临时目录失败")
+	}
+	defer utils.RmAll(tmpDir)
+
+	pages, err := util.ExtractWordsWithBox(i.input, path.Join(tmpDir, "words.tetml"))
+	if err != nil {
+		return errors.Errorf(err, "tet解析单词坐标失败")
+	}
+
+	pngFiles, err := xpdf.PdfToPngV2(i.input, tmpDir, "page")
+	if err != nil {
+		return errors.Errorf(err, "pdf转图片失败")
+	}
+
+	var (
+		name   = util.GetPureFileName(i.input)
+		scales = make(map[int]*util.PageScale)
+	)
+	for idx, page := range pages {
+		if idx >= len(pngFiles) {
+			break
+		}
+
+		img, err := util.LoadImage(pngFiles[idx])
+		if err != nil {
+			return errors.Errorf(err, "加载图片失败")
+		}
+
+		scale, err := util.NewPageScale(page.Width, page.Height, img.Bounds())
+		if err != nil {
+			return errors.Errorf(err, "计算页面坐标换算失败, page:%d", page.Number)
+		}
+		scales[page.Number] = scale
+
+		pageFile := path.Join(i.outputDir, fmt.Sprintf("%s_page_%d%s", name, page.Number, common.ExtPng))
+		if err := util.SaveImage(pageFile, util.DrawCoordinateGrid(img, scale, i.gridStep)); err != nil {
+			return errors.Errorf(err, "保存页面图片失败")
+		}
+	}
+
+	wordsFile := path.Join(i.outputDir, fmt.Sprintf("%s_words%s", name, common.ExtCsv))
+	if err := writeWords(wordsFile, pages, scales); err != nil {
+		return errors.Errorf(err, "导出单词坐标失败")
+	}
+
+	configs := detectTemplateFields(pages)
+	templateFile := path.Join(i.outputDir, fmt.Sprintf("%s_template.%s", name, i.format))
+	if err := writeTemplate(templateFile, i.format, configs); err != nil {
+		return errors.Errorf(err, "生成配置模板失败")
+	}
+
+	fmt.Printf("[%s] 一共%d页, 识别到%d个 \"标签: 值\" 字段\n", inspectCmdName, len(pages), len(configs))
+	fmt.Printf("[%s] 网格标注格式为 point/pixel, point对应tet_coordinates和anchor_distance, pixel对应crop_coordinates\n", inspectCmdName)
+	fmt.Printf("[%s] 单词坐标: %s\n", inspectCmdName, wordsFile)
+	fmt.Printf("[%s] 配置模板: %s\n", inspectCmdName, templateFile)
+
+	open.Run(i.outputDir)
+	return nil
+}
+
+// writeWords 导出所有单词及其坐标, 同时给出对应的图片裁剪坐标
+func writeWords(file string, pages []*util.TetPage, scales map[int]*util.PageScale) error {
+	w := util.NewTableWriter(file)
+	if err := w.DecideWriter(); err != nil {
+		return errors.Errorf(err, "创建file writer 失败,文件:%s", file)
+	}
+	defer w.Close()
+
+	header := []string{"page", "text", "llx", "lly", "urx", "ury", "crop_min_x", "crop_min_y", "crop_max_x", "crop_max_y"}
+	if err := w.WriteRecord(header); err != nil {
+		return errors.Errorf(err, "write file header failed")
+	}
+
+	for _, page := range pages {
+		scale := scales[page.Number]
+		for _, word := range page.Words {
+			record := []string{
+				fmt.Sprintf("%d", page.Number),
+				word.Text,
+				fmt.Sprintf("%.2f", word.Llx),
+				fmt.Sprintf("%.2f", word.Lly),
+				fmt.Sprintf("%.2f", word.Urx),
+				fmt.Sprintf("%.2f", word.Ury),
+			}
+			if scale != nil {
+				r := scale.ToPixelRect(word.Llx, word.Lly, word.Urx, word.Ury)
+				record = append(record,
+					fmt.Sprintf("%d", r.Min.X), fmt.Sprintf("%d", r.Min.Y),
+					fmt.Sprintf("%d", r.Max.X), fmt.Sprintf("%d", r.Max.Y))
+			}
+			if err := w.WriteRecord(record); err != nil {
+				return errors.Errorf(err, "写入一行数据到output文件失败")
+			}
+		}
+	}
+
+	return nil
+}
+
+func writeTemplate(file, format string, configs []*ExtractConfig) error {
+	var (
+		b   []byte
+		err error
+	)
+	switch format {
+	case TemplateFormatYaml:
+		b, err = yaml.Marshal(configs)
+	default:
+		b, err = json.MarshalIndent(configs, "", "  ")
+	}
+	if err != nil {
+		return errors.Errorf(err, "marshal template failed")
+	}
+
+	if err := ioutil.WriteFile(file, b, 0644); err != nil {
+		return errors.Errorf(err, "write template file failed, file:%s", file)
+	}
+	return nil
+}
+
+// labelValue 同一行中的 "标签: 值"
+type labelValue struct {
+	label    string
+	labelBox anchorBox
+	values   []*util.TetWord
+}
+
+// detectTemplateFields 根据 "标签: 值" 生成锚点解析的配置, 每一对生成一个字段
+func detectTemplateFields(pages []*util.TetPage) []*ExtractConfig {
+	var (
+		configs []*ExtractConfig
+		names   = make(map[string]int)
+	)
+	for _, page := range pages {
+		lines := groupWordsIntoLines(page.Words, defaultAnchorTolerance)
+		for li, line := range lines {
+			pairs := detectLabelValuePairs(line)
+			for pi, pair := range pairs {
+				cnf := &ExtractConfig{
+					FieldName:       uniqueFieldName(names, pair.label),
+					PageNum:         page.Number,
+					ExtractMethod:   ExtractMethodAnchor,
+					AnchorText:      pair.label,
+					AnchorDirection: anchorDirectionRight,
+				}
+				if pi+1 < len(pairs) {
+					cnf.AnchorUntil = pairs[pi+1].label
+				}
+
+				values := pair.values
+				if len(values) == 0 {
+					// 标签单独一行时, 值在下一行
+					if li+1 >= len(lines) || len(detectLabelValuePairs(lines[li+1])) > 0 {
+						continue
+					}
+					values = lines[li+1]
+					cnf.AnchorDirection = anchorDirectionBelow
+				}
+
+				box := newAnchorBox(values[0])
+				for _, v := range values[1:] {
+					box = box.union(v)
+				}
+				if cnf.AnchorDirection == anchorDirectionBelow {
+					// 只读取下一行
+					cnf.AnchorDistance = math.Ceil(pair.labelBox.lly - box.lly + defaultAnchorTolerance)
+				}
+				cnf.TetCoordinates = []string{fmt.Sprintf("%.2f %.2f %.2f %.2f", box.llx, box.lly, box.urx, box.ury)}
+
+				configs = append(configs, cnf)
+			}
+		}
+	}
+	return configs
+}
+
+// detectLabelValuePairs 按单词间距把一行拆成多个片段, 以冒号结尾的单词作为标签的结束,
+// 片段中标签后面的单词作为值; 没有标签的片段作为前一个标签的值
+func detectLabelValuePairs(line []*util.TetWord) []labelValue {
+	var (
+		pairs  []labelValue
+		chunks [][]*util.TetWord
+	)
+	for i, w := range line {
+		if i == 0 || w.Llx-line[i-1].Urx > 1.5*(w.Ury-w.Lly) {
+			chunks = append(chunks, []*util.TetWord{w})
+			continue
+		}
+		chunks[len(chunks)-1] = append(chunks[len(chunks)-1], w)
+	}
+
+	for _, chunk := range chunks {
+		idx := -1
+		for i, w := range chunk {
+			if strings.HasSuffix(w.Text, ":") || strings.HasSuffix(w.Text, "：") {
+				idx = i
+				break
+			}
+		}
+
+		if idx < 0 {
+			if len(pairs) > 0 && len(pairs[len(pairs)-1].values) == 0 {
+				pairs[len(pairs)-1].values = chunk
+			}
+			continue
+		}
+
+		label := strings.TrimRight(joinTexts(chunk[:idx+1]), ":： ")
+		if label == "" {
+			continue
+		}
+
+		labelBox := newAnchorBox(chunk[0])
+		for _, w := range chunk[1 : idx+1] {
+			labelBox = labelBox.union(w)
+		}
+		pairs = append(pairs, labelValue{label: label, labelBox: labelBox, values: chunk[idx+1:]})
+	}
+
+	return pairs
+}
+
+func joinTexts(words []*util.TetWord) string {
+	var texts []string
+	for _, w := range words {
+		texts = append(texts, w.Text)
+	}
+	return strings.Join(texts, " ")
+}
+
+// uniqueFieldName 标签转换为字段名, 比如 "Booking No." => booking_no, 重复的加上序号
+func uniqueFieldName(names map[string]int, label string) string {
+	var b strings.Builder
+	underscore := false
+	for _, r := range strings.ToLower(label) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			underscore = false
+		} else if !underscore && b.Len() > 0 {
+			b.WriteRune('_')
+			underscore = true
+		}
+	}
+
+	name := strings.TrimSuffix(b.String(), "_")
+	if name == "" {
+		name = "field"
+	}
+
+	names[name]++
+	if n := names[name]; n > 1 {
+		return fmt.Sprintf("%s_%d", name, n)
+	}
+	return name
+}
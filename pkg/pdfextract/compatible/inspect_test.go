@@ -0,0 +1,85 @@
+package compatible
+
+import (
+	"testing"
+
+	"invtools/pkg/util"
+)
+
+func Test_detectTemplateFields(t *testing.T) {
+	// Booking No.: ABC123      Date: 2020-05-01
+	// Guest Name:
+	// John Smith
+	pages := []*util.TetPage{
+		{
+			Number: 1,
+			Width:  595,
+			Height: 842,
+			Words: []*util.TetWord{
+				{Text: "Booking", Llx: 40, Lly: 700, Urx: 80, Ury: 710},
+				{Text: "No.:", Llx: 83, Lly: 700, Urx: 100, Ury: 710},
+				{Text: "ABC123", Llx: 105, Lly: 700, Urx: 150, Ury: 710},
+				{Text: "Date:", Llx: 300, Lly: 700, Urx: 325, Ury: 710},
+				{Text: "2020-05-01", Llx: 330, Lly: 700, Urx: 380, Ury: 710},
+				{Text: "Guest", Llx: 40, Lly: 680, Urx: 65, Ury: 690},
+				{Text: "Name:", Llx: 68, Lly: 680, Urx: 95, Ury: 690},
+				{Text: "John", Llx: 41, Lly: 665, Urx: 60, Ury: 675},
+				{Text: "Smith", Llx: 63, Lly: 665, Urx: 120, Ury: 675},
+			},
+		},
+	}
+
+	configs := detectTemplateFields(pages)
+	if len(configs) != 3 {
+		t.Fatalf("detectTemplateFields() got %d configs, want 3", len(configs))
+	}
+
+	tests := []struct {
+		fieldName, anchorText, direction, until string
+	}{
+		{"booking_no", "Booking No.", anchorDirectionRight, "Date"},
+		{"date", "Date", anchorDirectionRight, ""},
+		{"guest_name", "Guest Name", anchorDirectionBelow, ""},
+	}
+	for i, tt := range tests {
+		got := configs[i]
+		if got.FieldName != tt.fieldName || got.AnchorText != tt.anchorText ||
+			got.AnchorDirection != tt.direction || got.AnchorUntil != tt.until {
+			t.Errorf("detectTemplateFields()[%d] got = %+v, want %+v", i, got, tt)
+		}
+	}
+
+	// 生成的配置应当能用锚点解析出对应的值
+	wants := []string{"ABC123", "2020-05-01", "John Smith"}
+	for i, want := range wants {
+		got, err := extractByAnchor(pages[0], configs[i])
+		if err != nil {
+			t.Errorf("extractByAnchor() error = %v", err)
+			continue
+		}
+		if got != want {
+			t.Errorf("extractByAnchor() got = %v, want %v", got, want)
+		}
+	}
+}
+
+func Test_uniqueFieldName(t *testing.T) {
+	names := make(map[string]int)
+	tests := []struct {
+		name  string
+		label string
+		want  string
+	}{
+		{name: "Test_uniqueFieldName_punct", label: "Booking No.", want: "booking_no"},
+		{name: "Test_uniqueFieldName_duplicate", label: "Booking  No", want: "booking_no_2"},
+		{name: "Test_uniqueFieldName_chinese", label: "订单号", want: "订单号"},
+		{name: "Test_uniqueFieldName_empty", label: "#", want: "field"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := uniqueFieldName(names, tt.label); got != tt.want {
+				t.Errorf("uniqueFieldName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
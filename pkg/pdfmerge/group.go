@@ -0,0 +1,170 @@
+package pdfmerge
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"invtools/common"
+	"invtools/pkg/util"
+	"invtools/utils"
+	"invtools/utils/errors"
+)
+
+const (
+	// csvColumnFile csv映射文件中的文件名列, 可以是文件名或者完整路径
+	csvColumnFile = "file"
+	// csvColumnGroup csv映射文件中的分组列, 同一个分组的文件合并成一个pdf
+	csvColumnGroup = "group"
+)
+
+// fileGroup 合并成一个pdf的文件, 按输入顺序排列
+type fileGroup struct {
+	key   string
+	files []string
+}
+
+// collectFiles 按输入顺序展开输入: 目录下的pdf按文件名自然排序, .txt为文件列表(每行一个路径, #开头为注释)
+func collectFiles(inputs []string) ([]string, error) {
+	var files []string
+	for _, input := range inputs {
+		switch {
+		case utils.CheckDirIsExist(input):
+			fs, err := util.ReadDirFiles(input, common.ExtPDF)
+			if err != nil {
+				return nil, errors.Errorf(err, "读取目录下的pdf文件失败, dir:%s", input)
+			}
+			sort.SliceStable(fs, func(i, j int) bool {
+				return util.NaturalLess(path.Base(fs[i]), path.Base(fs[j]))
+			})
+			files = append(files, fs...)
+		case strings.ToLower(path.Ext(input)) == common.ExtTxt:
+			fs, err := readFileList(input)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, fs...)
+		case utils.CheckFileIsExist(input):
+			files = append(files, input)
+		default:
+			return nil, errors.Errorf(nil, "输入文件不存在:%s", input)
+		}
+	}
+	if len(files) == 0 {
+		return nil, errors.Errorf(nil, "没有找到需要合并的pdf文件")
+	}
+	return files, nil
+}
+
+// readFileList 读取文件列表, 相对路径相对于列表文件所在的目录
+func readFileList(list string) ([]string, error) {
+	f, err := os.Open(list)
+	if err != nil {
+		return nil, errors.Errorf(err, "open file list failed, file:%s", list)
+	}
+	defer f.Close()
+
+	var (
+		files []string
+		dir   = filepath.Dir(list)
+	)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !filepath.IsAbs(line) {
+			line = filepath.Join(dir, line)
+		}
+		if !utils.CheckFileIsExist(line) {
+			return nil, errors.Errorf(nil, "文件列表中的文件不存在:%s", line)
+		}
+		files = append(files, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Errorf(err, "read file list failed, file:%s", list)
+	}
+	return files, nil
+}
+
+// groupByRegexp 按文件名匹配正则分组, 有分组时取第一个分组, 否则取整个匹配; 没有匹配的文件返回在skipped中
+func groupByRegexp(files []string, re *regexp.Regexp) (groups []*fileGroup, skipped []string) {
+	return groupBy(files, func(file string) string {
+		m := re.FindStringSubmatch(path.Base(file))
+		switch {
+		case m == nil:
+			return ""
+		case len(m) > 1:
+			return strings.TrimSpace(m[1])
+		}
+		return strings.TrimSpace(m[0])
+	})
+}
+
+// groupByMapping 按csv映射文件分组, 文件名或完整路径匹配都可以; 没有映射的文件返回在skipped中
+func groupByMapping(files []string, mapping map[string]string) (groups []*fileGroup, skipped []string) {
+	return groupBy(files, func(file string) string {
+		if key, ok := mapping[file]; ok {
+			return key
+		}
+		return mapping[path.Base(file)]
+	})
+}
+
+// groupBy 分组按第一个文件出现的顺序排列, key为空的文件跳过
+func groupBy(files []string, keyFunc func(file string) string) (groups []*fileGroup, skipped []string) {
+	index := make(map[string]*fileGroup)
+	for _, file := range files {
+		key := keyFunc(file)
+		if key == "" {
+			skipped = append(skipped, file)
+			continue
+		}
+		g, ok := index[key]
+		if !ok {
+			g = &fileGroup{key: key}
+			index[key] = g
+			groups = append(groups, g)
+		}
+		g.files = append(g.files, file)
+	}
+	return groups, skipped
+}
+
+// loadMapping 读取csv映射文件, 第一行为表头, 需要包含file和group列
+func loadMapping(file string) (map[string]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, errors.Errorf(err, "open mapping file failed, file:%s", file)
+	}
+	defer f.Close()
+
+	return readMapping(f)
+}
+
+func readMapping(r io.Reader) (map[string]string, error) {
+	table, err := util.ReadCsvTable(r, csvColumnFile, csvColumnGroup)
+	if err != nil {
+		return nil, errors.Errorf(err, "读取映射文件失败")
+	}
+
+	mapping := make(map[string]string)
+	for i, row := range table.Rows {
+		name := strings.TrimSpace(table.Value(row, csvColumnFile))
+		key := strings.TrimSpace(table.Value(row, csvColumnGroup))
+		if name == "" || key == "" {
+			continue
+		}
+		if old, ok := mapping[name]; ok && old != key {
+			return nil, errors.Errorf(nil, "映射文件第%d行的文件%s已经映射到分组%s", table.Line(i), name, old)
+		}
+		mapping[name] = key
+	}
+	return mapping, nil
+}
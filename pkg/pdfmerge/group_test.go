@@ -0,0 +1,75 @@
+package pdfmerge
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func Test_groupByRegexp(t *testing.T) {
+	files := []string{
+		"/in/B100_guest1.pdf",
+		"/in/A200_guest1.pdf",
+		"/in/B100_guest2.pdf",
+		"/in/terms.pdf",
+	}
+	groups, skipped := groupByRegexp(files, regexp.MustCompile(`^(\w+?)_guest`))
+
+	want := []*fileGroup{
+		{key: "B100", files: []string{"/in/B100_guest1.pdf", "/in/B100_guest2.pdf"}},
+		{key: "A200", files: []string{"/in/A200_guest1.pdf"}},
+	}
+	if !reflect.DeepEqual(groups, want) {
+		t.Errorf("groupByRegexp() groups = %+v, want %+v", groups, want)
+	}
+	if !reflect.DeepEqual(skipped, []string{"/in/terms.pdf"}) {
+		t.Errorf("groupByRegexp() skipped = %v", skipped)
+	}
+}
+
+func Test_readMapping(t *testing.T) {
+	tests := []struct {
+		name    string
+		csv     string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "columns in any order",
+			csv:  "\ufeffGroup,File\nB100,a.pdf\nB100,b.pdf\n,c.pdf\nA200,/in/d.pdf\n",
+			want: map[string]string{"a.pdf": "B100", "b.pdf": "B100", "/in/d.pdf": "A200"},
+		},
+		{name: "missing column", csv: "file,booking\na.pdf,B100\n", wantErr: true},
+		{name: "conflict", csv: "file,group\na.pdf,B100\na.pdf,A200\n", wantErr: true},
+		{name: "empty", csv: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readMapping(strings.NewReader(tt.csv))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readMapping() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readMapping() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_groupByMapping(t *testing.T) {
+	files := []string{"/in/a.pdf", "/in/b.pdf", "/in/c.pdf", "/in/d.pdf"}
+	mapping := map[string]string{"a.pdf": "B100", "/in/d.pdf": "B100", "b.pdf": "A200"}
+	groups, skipped := groupByMapping(files, mapping)
+
+	want := []*fileGroup{
+		{key: "B100", files: []string{"/in/a.pdf", "/in/d.pdf"}},
+		{key: "A200", files: []string{"/in/b.pdf"}},
+	}
+	if !reflect.DeepEqual(groups, want) {
+		t.Errorf("groupByMapping() groups = %+v, want %+v", groups, want)
+	}
+	if !reflect.DeepEqual(skipped, []string{"/in/c.pdf"}) {
+		t.Errorf("groupByMapping() skipped = %v", skipped)
+	}
+}
@@ -0,0 +1,262 @@
+package pdfmerge
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"invtools/common"
+	"invtools/pkg/util"
+	"invtools/utils"
+	"invtools/utils/errors"
+
+	"github.com/gosuri/uiprogress"
+	"github.com/skratchdot/open-golang/open"
+	unicore "github.com/unidoc/unipdf/v3/core"
+	unipdf "github.com/unidoc/unipdf/v3/model"
+)
+
+const (
+	cmdName = "pdfmerge"
+
+	// defaultOutputName 不分组并且输出路径是目录时合并后的文件名
+	defaultOutputName = "merged.pdf"
+)
+
+// Options 合并选项
+type Options struct {
+	Password  string         // 输入文件的密码, 没有密码的文件不受影响
+	GroupRe   *regexp.Regexp // 按文件名分组, 有分组时取第一个分组
+	GroupCsv  string         // 按csv映射文件分组, 列: file,group
+	Bookmarks bool           // 每个输入文件生成一个书签, 标题为文件名
+}
+
+type Merger struct {
+	inputs []string
+	output string
+	opts   *Options
+}
+
+// NewPdfMerger inputs按顺序合并, 可以是pdf文件、目录或者文件列表(.txt);
+// 分组时output为目录, 每个分组一个文件; 不分组时output以.pdf结尾则为输出文件, 否则为目录
+func NewPdfMerger(inputs []string, output string, opts *Options) *Merger {
+	return &Merger{
+		inputs: inputs,
+		output: output,
+		opts:   opts,
+	}
+}
+
+func (m *Merger) grouped() bool {
+	return m.opts.GroupRe != nil || m.opts.GroupCsv != ""
+}
+
+func (m *Merger) validate() error {
+	if len(m.inputs) == 0 {
+		return errors.Errorf(nil, "没有输入文件")
+	}
+	if m.opts.GroupRe != nil && m.opts.GroupCsv != "" {
+		return errors.Errorf(nil, "按正则分组和按csv分组只能选择一种")
+	}
+	if m.opts.GroupCsv != "" && !utils.CheckFileIsExist(m.opts.GroupCsv) {
+		return errors.Errorf(nil, "映射文件不存在:%s", m.opts.GroupCsv)
+	}
+
+	dir := m.output
+	if !m.grouped() && strings.ToLower(path.Ext(m.output)) == common.ExtPDF {
+		dir = path.Dir(m.output)
+	}
+	if err := utils.CheckAndMkDir(dir); err != nil {
+		return errors.Errorf(err, "创建目录失败,目录:%s", dir)
+	}
+	return nil
+}
+
+func (m *Merger) Do() error {
+	if err := m.validate(); err != nil {
+		return err
+	}
+
+	return m.execute()
+}
+
+func (m *Merger) execute() error {
+	files, err := collectFiles(m.inputs)
+	if err != nil {
+		return err
+	}
+
+	groups, skipped, err := m.group(files)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("[%s] 一共得到%d个文件, 分成%d组, 即将开始合并操作...\n", cmdName, len(files), len(groups))
+	if len(skipped) > 0 {
+		fmt.Printf("[%s] 以下%d个文件没有匹配到分组, 不参与合并:%s\n", cmdName, len(skipped), skipped)
+	}
+
+	uiprogress.Start()
+	var (
+		st           = time.Now()
+		successFiles []string
+		failedGroups []string
+		cnt          = len(groups)
+	)
+
+	bar := uiprogress.AddBar(cnt).AppendCompleted().PrependElapsed()
+	bar.PrependFunc(func(b *uiprogress.Bar) string {
+		return fmt.Sprintf("processing: %d/%d", b.Current(), cnt)
+	})
+	for bar.Incr() {
+		g := groups[bar.Current()-1]
+		output := m.outputFile(g)
+		if err := mergeFiles(g.files, output, m.opts.Password, m.opts.Bookmarks); err != nil {
+			failedGroups = append(failedGroups, fmt.Sprintf("group:%s, err:%v", g.key, err))
+		} else {
+			successFiles = append(successFiles, output)
+		}
+	}
+	uiprogress.Stop()
+
+	fmt.Printf("[%s] 本次合并操作, 一共成功%d个pdf, 失败%d个, 总耗时:%s\n", cmdName, len(successFiles), len(failedGroups), time.Since(st))
+	fmt.Printf("[%s] 合并后的文件: %s\n", cmdName, successFiles)
+	if len(failedGroups) > 0 {
+		fmt.Printf("失败分组:%s", failedGroups)
+	}
+
+	if len(successFiles) > 0 {
+		open.Run(path.Dir(successFiles[0]))
+	}
+	return nil
+}
+
+// group 不分组时所有文件合并成一个pdf
+func (m *Merger) group(files []string) ([]*fileGroup, []string, error) {
+	switch {
+	case m.opts.GroupRe != nil:
+		groups, skipped := groupByRegexp(files, m.opts.GroupRe)
+		return groups, skipped, nil
+	case m.opts.GroupCsv != "":
+		mapping, err := loadMapping(m.opts.GroupCsv)
+		if err != nil {
+			return nil, nil, err
+		}
+		groups, skipped := groupByMapping(files, mapping)
+		return groups, skipped, nil
+	}
+	return []*fileGroup{{files: files}}, nil, nil
+}
+
+// outputFile 分组时为 output/分组名.pdf
+func (m *Merger) outputFile(g *fileGroup) string {
+	if !m.grouped() {
+		if strings.ToLower(path.Ext(m.output)) == common.ExtPDF {
+			return m.output
+		}
+		return path.Join(m.output, defaultOutputName)
+	}
+
+	name := util.SanitizeFileName(g.key)
+	if name == "" {
+		name = util.ComputeMd5String(g.key)
+	}
+	return path.Join(m.output, name+common.ExtPDF)
+}
+
+// mergeFiles 按顺序把files的所有页面写入output, bookmarks为true时每个文件生成一个指向其第一页的书签
+func mergeFiles(files []string, output, password string, bookmarks bool) error {
+	pdfWriter := unipdf.NewPdfWriter()
+	outline := unipdf.NewPdfOutline()
+	var (
+		items []*unipdf.PdfOutlineItem
+		// 写入完成前保持文件打开, 页面中的对象可能在写入时才读取
+		opened []*os.File
+	)
+	defer func() {
+		for _, f := range opened {
+			f.Close()
+		}
+	}()
+
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return errors.Errorf(err, "open file failed, file:%s", file)
+		}
+		opened = append(opened, f)
+
+		pdfReader, err := util.NewPdfReader(f, password)
+		if err != nil {
+			return errors.Errorf(err, "读取pdf失败, file:%s", file)
+		}
+
+		numPages, err := pdfReader.GetNumPages()
+		if err != nil {
+			return errors.Errorf(err, "获取页数失败, file:%s", file)
+		}
+		if numPages == 0 {
+			continue
+		}
+
+		var first *unipdf.PdfPage
+		for i := 1; i <= numPages; i++ {
+			page, err := pdfReader.GetPage(i)
+			if err != nil {
+				return errors.Errorf(err, "pdfReader.GetPage 失败, file:%s, page:%d", file, i)
+			}
+			if err := pdfWriter.AddPage(page); err != nil {
+				return errors.Errorf(err, "pdfWriter.AddPage failed, file:%s, page:%d", file, i)
+			}
+			if first == nil {
+				first = page
+			}
+		}
+
+		if bookmarks {
+			item := unipdf.NewPdfOutlineItem()
+			item.Title = makeTextString(util.GetPureFileName(file))
+			item.Dest = unicore.MakeArray(first.GetContainingPdfObject(), unicore.MakeName("Fit"))
+			item.Parent = &outline.PdfOutlineTreeNode
+			if len(items) > 0 {
+				prev := items[len(items)-1]
+				prev.Next = &item.PdfOutlineTreeNode
+				item.Prev = &prev.PdfOutlineTreeNode
+			}
+			items = append(items, item)
+		}
+	}
+
+	if len(items) > 0 {
+		count := int64(len(items))
+		outline.First = &items[0].PdfOutlineTreeNode
+		outline.Last = &items[len(items)-1].PdfOutlineTreeNode
+		outline.Count = &count
+		pdfWriter.AddOutlineTree(&outline.PdfOutlineTreeNode)
+	}
+
+	fWrite, err := os.Create(output)
+	if err != nil {
+		return errors.Errorf(err, "创建文件失败, file:%s", output)
+	}
+	defer fWrite.Close()
+
+	if err := pdfWriter.Write(fWrite); err != nil {
+		return errors.Errorf(err, "写入pdf失败, file:%s", output)
+	}
+	return nil
+}
+
+// makeTextString 书签标题含有非ASCII字符(例如中文文件名)时需要使用UTF-16BE编码
+func makeTextString(s string) *unicore.PdfObjectString {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return unicore.MakeEncodedString(s, true)
+		}
+	}
+	return unicore.MakeString(s)
+}
@@ -0,0 +1,62 @@
+package pdfmerge
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	"invtools/pkg/internal/testpdf"
+	"invtools/pkg/util"
+)
+
+// writeTestPdf 生成pages页的pdf
+func Test_mergeFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pdfmerge_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for name, texts := range map[string][]string{
+		"voucher_2.pdf":  {"voucher", "voucher"},
+		"voucher_10.pdf": {"voucher"},
+		"条款.pdf":         {"voucher", "voucher", "voucher"},
+	} {
+		testpdf.Write(t, path.Join(dir, name), texts...)
+	}
+	files, err := collectFiles([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := path.Join(dir, "out", defaultOutputName)
+	if err := os.MkdirAll(path.Dir(output), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := mergeFiles(files, output, "", true); err != nil {
+		t.Fatalf("mergeFiles() error = %v", err)
+	}
+
+	bookmarks, err := util.NewUniPdf().Bookmarks(output, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []util.Bookmark{
+		{Title: "voucher_2", Page: 1, Level: 1},
+		{Title: "voucher_10", Page: 3, Level: 1},
+		{Title: "条款", Page: 4, Level: 1},
+	}
+	if !reflect.DeepEqual(bookmarks, want) {
+		t.Errorf("bookmarks = %+v, want %+v", bookmarks, want)
+	}
+
+	texts, err := util.NewUniPdf().ExtractTextWithPages(output, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(texts) != 6 {
+		t.Errorf("merged pages = %d, want 6", len(texts))
+	}
+}
@@ -0,0 +1,404 @@
+package pdfpage
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+	"strings"
+
+	"invtools/pkg/util"
+	"invtools/utils/errors"
+
+	unicreator "github.com/unidoc/unipdf/v3/creator"
+	unipdf "github.com/unidoc/unipdf/v3/model"
+)
+
+const (
+	// pointsPerMillimeter 1mm = 72/25.4 point
+	pointsPerMillimeter = 72 / 25.4
+
+	// orderReverse 倒序排列所有页面
+	orderReverse = "reverse"
+)
+
+// document 一个pdf文件及其所有页面
+type document struct {
+	file, password string
+	pages          []*unipdf.PdfPage
+}
+
+// Operation 页面操作, 返回按顺序写入输出文件的页面
+type Operation interface {
+	// Validate 校验参数, 页码是否超出总页数在处理每个文件时校验
+	Validate() error
+	Apply(doc *document) ([]*unipdf.PdfPage, error)
+}
+
+// RotateOp 顺时针旋转页面, Angle为90的倍数, 可以为负数; Pages为空时旋转所有页面
+type RotateOp struct {
+	Angle int
+	Pages string
+}
+
+func (o *RotateOp) Validate() error {
+	if o.Angle%90 != 0 {
+		return errors.Errorf(nil, "旋转角度必须是90的倍数:%d", o.Angle)
+	}
+	return validateRanges(o.Pages)
+}
+
+func (o *RotateOp) Apply(doc *document) ([]*unipdf.PdfPage, error) {
+	selected, err := selectPages(o.Pages, len(doc.pages))
+	if err != nil {
+		return nil, err
+	}
+	for i, page := range doc.pages {
+		if selected[i+1] {
+			angle := rotateAngle(page.Rotate, o.Angle)
+			page.Rotate = &angle
+		}
+	}
+	return doc.pages, nil
+}
+
+// rotateAngle 在原来的旋转角度上叠加angle, 结果为0/90/180/270
+func rotateAngle(old *int64, angle int) int64 {
+	var r int64
+	if old != nil {
+		r = *old
+	}
+	r = (r + int64(angle)) % 360
+	if r < 0 {
+		r += 360
+	}
+	return r
+}
+
+// DeleteOp 删除Pages中的页面, Blank为true时同时删除没有文字也没有图片的页面
+type DeleteOp struct {
+	Pages string
+	Blank bool
+}
+
+func (o *DeleteOp) Validate() error {
+	if strings.TrimSpace(o.Pages) == "" && !o.Blank {
+		return errors.Errorf(nil, "需要指定删除的页码范围或者删除空白页")
+	}
+	return validateRanges(o.Pages)
+}
+
+func (o *DeleteOp) Apply(doc *document) ([]*unipdf.PdfPage, error) {
+	deleted := make(map[int]bool)
+	if strings.TrimSpace(o.Pages) != "" {
+		pages, err := util.ParsePageRanges(util.ReplacePageRangeEnd(o.Pages, len(doc.pages)), len(doc.pages))
+		if err != nil {
+			return nil, errors.Errorf(err, "页码范围不合法:%s", o.Pages)
+		}
+		for _, p := range pages {
+			deleted[p] = true
+		}
+	}
+
+	if o.Blank {
+		contents, err := util.NewUniPdf().PageContents(doc.file, doc.password)
+		if err != nil {
+			return nil, errors.Errorf(err, "提取页面文字失败")
+		}
+		if len(contents) != len(doc.pages) {
+			return nil, errors.Errorf(nil, "提取文字的页数(%d)与总页数(%d)不一致", len(contents), len(doc.pages))
+		}
+		for i, c := range contents {
+			if c.IsBlank() {
+				deleted[i+1] = true
+			}
+		}
+	}
+
+	kept := keptPages(len(doc.pages), deleted)
+	if len(kept) == 0 {
+		return nil, errors.Errorf(nil, "删除后没有剩余页面")
+	}
+	return pickPages(doc.pages, kept), nil
+}
+
+// keptPages 没有被删除的页码, 按原顺序排列
+func keptPages(count int, deleted map[int]bool) []int {
+	var kept []int
+	for i := 1; i <= count; i++ {
+		if !deleted[i] {
+			kept = append(kept, i)
+		}
+	}
+	return kept
+}
+
+// ReorderOp 按Order排列页面, 例如 3,1-2,end; 没有列出的页面不写入, 同一页可以出现多次.
+// Order为reverse时倒序排列所有页面
+type ReorderOp struct {
+	Order string
+}
+
+func (o *ReorderOp) Validate() error {
+	if strings.TrimSpace(o.Order) == "" {
+		return errors.Errorf(nil, "需要指定页面顺序")
+	}
+	if strings.EqualFold(strings.TrimSpace(o.Order), orderReverse) {
+		return nil
+	}
+	return validateRanges(o.Order)
+}
+
+func (o *ReorderOp) Apply(doc *document) ([]*unipdf.PdfPage, error) {
+	order, err := reorderPages(o.Order, len(doc.pages))
+	if err != nil {
+		return nil, err
+	}
+	return pickPages(doc.pages, order), nil
+}
+
+// reorderPages 按order计算新的页码顺序
+func reorderPages(order string, count int) ([]int, error) {
+	if strings.EqualFold(strings.TrimSpace(order), orderReverse) {
+		pages := make([]int, 0, count)
+		for i := count; i >= 1; i-- {
+			pages = append(pages, i)
+		}
+		return pages, nil
+	}
+
+	pages, err := util.ParsePageRanges(util.ReplacePageRangeEnd(order, count), count)
+	if err != nil {
+		return nil, errors.Errorf(err, "页面顺序不合法:%s", order)
+	}
+	return pages, nil
+}
+
+// CropOp 从页面四周裁掉Margins, 单位mm, 格式与css的margin相同: "10"、"10,20"或"10,20,10,20"(上,右,下,左);
+// 只修改CropBox, 页面内容不受影响. Pages为空时裁剪所有页面
+type CropOp struct {
+	Margins string
+	Pages   string
+}
+
+func (o *CropOp) Validate() error {
+	if _, err := parseMargins(o.Margins); err != nil {
+		return err
+	}
+	return validateRanges(o.Pages)
+}
+
+func (o *CropOp) Apply(doc *document) ([]*unipdf.PdfPage, error) {
+	margins, err := parseMargins(o.Margins)
+	if err != nil {
+		return nil, err
+	}
+	selected, err := selectPages(o.Pages, len(doc.pages))
+	if err != nil {
+		return nil, err
+	}
+
+	for i, page := range doc.pages {
+		if !selected[i+1] {
+			continue
+		}
+		// 已经裁剪过的页面在原来的CropBox上继续裁剪
+		box := page.CropBox
+		if box == nil {
+			if box, err = page.GetMediaBox(); err != nil {
+				return nil, errors.Errorf(err, "获取页面大小失败, page:%d", i+1)
+			}
+		}
+		cropped, err := cropBox(box, margins)
+		if err != nil {
+			return nil, errors.Errorf(err, "裁剪页面失败, page:%d", i+1)
+		}
+		page.CropBox = cropped
+	}
+	return doc.pages, nil
+}
+
+// parseMargins 解析为上,右,下,左四个边距, 单位mm
+func parseMargins(s string) ([4]float64, error) {
+	var (
+		margins [4]float64
+		values  []float64
+	)
+	for _, part := range strings.Split(s, ",") {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || v < 0 {
+			return margins, errors.Errorf(err, "裁剪边距不合法:%s", s)
+		}
+		values = append(values, v)
+	}
+
+	switch len(values) {
+	case 1:
+		margins = [4]float64{values[0], values[0], values[0], values[0]}
+	case 2:
+		margins = [4]float64{values[0], values[1], values[0], values[1]}
+	case 4:
+		copy(margins[:], values)
+	default:
+		return margins, errors.Errorf(nil, "裁剪边距需要1个、2个或4个值:%s", s)
+	}
+	return margins, nil
+}
+
+// cropBox 从box四周裁掉margins(上,右,下,左, 单位mm)
+func cropBox(box *unipdf.PdfRectangle, margins [4]float64) (*unipdf.PdfRectangle, error) {
+	top, right, bottom, left := margins[0]*pointsPerMillimeter, margins[1]*pointsPerMillimeter,
+		margins[2]*pointsPerMillimeter, margins[3]*pointsPerMillimeter
+	cropped := &unipdf.PdfRectangle{
+		Llx: box.Llx + left,
+		Lly: box.Lly + bottom,
+		Urx: box.Urx - right,
+		Ury: box.Ury - top,
+	}
+	if cropped.Urx <= cropped.Llx || cropped.Ury <= cropped.Lly {
+		return nil, errors.Errorf(nil, "裁剪边距超出页面大小")
+	}
+	return cropped, nil
+}
+
+// NupOp 把N页拼到一张纸上打印, 支持2和4; 纸张大小取第一页的大小,
+// 2-up时纸张横竖与原页面相反, 每页等比缩放后居中, 按从左到右、从上到下的顺序排列.
+// 页面的Rotate和CropBox不会生效, 需要先拼版再旋转/裁剪
+type NupOp struct {
+	N int
+}
+
+func (o *NupOp) Validate() error {
+	if o.N != 2 && o.N != 4 {
+		return errors.Errorf(nil, "只支持2-up和4-up:%d", o.N)
+	}
+	return nil
+}
+
+func (o *NupOp) Apply(doc *document) ([]*unipdf.PdfPage, error) {
+	if len(doc.pages) == 0 {
+		return doc.pages, nil
+	}
+	mbox, err := doc.pages[0].GetMediaBox()
+	if err != nil {
+		return nil, errors.Errorf(err, "获取页面大小失败")
+	}
+	layout := nupLayout(o.N, mbox.Urx-mbox.Llx, mbox.Ury-mbox.Lly)
+
+	c := unicreator.New()
+	c.SetPageSize(unicreator.PageSize{layout.width, layout.height})
+	for i, page := range doc.pages {
+		if i%o.N == 0 {
+			c.NewPage()
+		}
+
+		blk, err := unicreator.NewBlockFromPage(page)
+		if err != nil {
+			return nil, errors.Errorf(err, "读取页面内容失败, page:%d", i+1)
+		}
+		cell := i % o.N
+		x, y, scale := layout.place(cell, blk.Width(), blk.Height())
+		blk.Scale(scale, scale)
+		blk.SetPos(x, y)
+		if err := c.Draw(blk); err != nil {
+			return nil, errors.Errorf(err, "绘制页面失败, page:%d", i+1)
+		}
+	}
+
+	// creator不能直接返回生成的页面, 写入内存后重新读取
+	var buf bytes.Buffer
+	if err := c.Write(&buf); err != nil {
+		return nil, errors.Errorf(err, "写入拼版后的pdf失败")
+	}
+	r, err := util.NewPdfReader(bytes.NewReader(buf.Bytes()), "")
+	if err != nil {
+		return nil, errors.Errorf(err, "读取拼版后的pdf失败")
+	}
+	numPages, err := r.GetNumPages()
+	if err != nil {
+		return nil, errors.Errorf(err, "获取页数失败")
+	}
+	pages := make([]*unipdf.PdfPage, 0, numPages)
+	for i := 1; i <= numPages; i++ {
+		page, err := r.GetPage(i)
+		if err != nil {
+			return nil, errors.Errorf(err, "pdfReader.GetPage 失败, page:%d", i)
+		}
+		pages = append(pages, page)
+	}
+	return pages, nil
+}
+
+// nupSheet 拼版后的纸张大小以及每个格子的行列数
+type nupSheet struct {
+	width, height float64
+	cols, rows    int
+}
+
+// nupLayout 4-up使用原页面大小分成2x2; 2-up竖版页面左右排列在横版纸张上, 横版页面上下排列在竖版纸张上
+func nupLayout(n int, w, h float64) nupSheet {
+	if n == 4 {
+		return nupSheet{width: w, height: h, cols: 2, rows: 2}
+	}
+	if w <= h {
+		return nupSheet{width: h, height: w, cols: 2, rows: 1}
+	}
+	return nupSheet{width: h, height: w, cols: 1, rows: 2}
+}
+
+// place 计算第cell个格子中页面的左上角位置(creator坐标, 原点在左上角)以及缩放比例
+func (s nupSheet) place(cell int, w, h float64) (x, y, scale float64) {
+	cellW, cellH := s.width/float64(s.cols), s.height/float64(s.rows)
+	scale = math.Min(cellW/w, cellH/h)
+	col, row := cell%s.cols, cell/s.cols
+	x = float64(col)*cellW + (cellW-w*scale)/2
+	y = float64(row)*cellH + (cellH-h*scale)/2
+	return x, y, scale
+}
+
+// validateRanges 只校验页码范围的格式, 为空时表示所有页面
+func validateRanges(ranges string) error {
+	if strings.TrimSpace(ranges) == "" {
+		return nil
+	}
+	if _, err := util.ParsePageRanges(util.ReplacePageRangeEnd(ranges, 0), 0); err != nil {
+		return errors.Errorf(err, "页码范围不合法:%s", ranges)
+	}
+	return nil
+}
+
+// selectPages ranges为空时选中所有页面
+func selectPages(ranges string, count int) (map[int]bool, error) {
+	selected := make(map[int]bool)
+	if strings.TrimSpace(ranges) == "" {
+		for i := 1; i <= count; i++ {
+			selected[i] = true
+		}
+		return selected, nil
+	}
+
+	pages, err := util.ParsePageRanges(util.ReplacePageRangeEnd(ranges, count), count)
+	if err != nil {
+		return nil, errors.Errorf(err, "页码范围不合法:%s", ranges)
+	}
+	for _, p := range pages {
+		selected[p] = true
+	}
+	return selected, nil
+}
+
+// pickPages 按页码取出页面, 重复出现的页面使用副本, 同一个页面对象不能写入两次
+func pickPages(pages []*unipdf.PdfPage, nums []int) []*unipdf.PdfPage {
+	var (
+		picked = make([]*unipdf.PdfPage, 0, len(nums))
+		seen   = make(map[int]bool)
+	)
+	for _, n := range nums {
+		page := pages[n-1]
+		if seen[n] {
+			page = page.Duplicate()
+		}
+		seen[n] = true
+		picked = append(picked, page)
+	}
+	return picked
+}
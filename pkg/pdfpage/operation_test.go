@@ -0,0 +1,161 @@
+package pdfpage
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	unipdf "github.com/unidoc/unipdf/v3/model"
+)
+
+func Test_rotateAngle(t *testing.T) {
+	var (
+		r90  int64 = 90
+		r270 int64 = 270
+	)
+	tests := []struct {
+		name  string
+		old   *int64
+		angle int
+		want  int64
+	}{
+		{name: "no rotate", old: nil, angle: 90, want: 90},
+		{name: "accumulate", old: &r90, angle: 180, want: 270},
+		{name: "wrap around", old: &r270, angle: 180, want: 90},
+		{name: "counterclockwise", old: nil, angle: -90, want: 270},
+		{name: "full turn", old: &r90, angle: 360, want: 90},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rotateAngle(tt.old, tt.angle); got != tt.want {
+				t.Errorf("rotateAngle() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_keptPages(t *testing.T) {
+	tests := []struct {
+		name    string
+		count   int
+		deleted map[int]bool
+		want    []int
+	}{
+		{name: "some", count: 5, deleted: map[int]bool{2: true, 4: true}, want: []int{1, 3, 5}},
+		{name: "none", count: 2, deleted: map[int]bool{}, want: []int{1, 2}},
+		{name: "all", count: 2, deleted: map[int]bool{1: true, 2: true}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := keptPages(tt.count, tt.deleted); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("keptPages() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_reorderPages(t *testing.T) {
+	tests := []struct {
+		name    string
+		order   string
+		count   int
+		want    []int
+		wantErr bool
+	}{
+		{name: "move to front", order: "3,1-2", count: 3, want: []int{3, 1, 2}},
+		{name: "with end", order: "end,2-end", count: 4, want: []int{4, 2, 3, 4}},
+		{name: "reverse", order: " Reverse ", count: 3, want: []int{3, 2, 1}},
+		{name: "drop pages", order: "2", count: 3, want: []int{2}},
+		{name: "out of range", order: "1,5", count: 3, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := reorderPages(tt.order, tt.count)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("reorderPages() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("reorderPages() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseMargins(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    [4]float64
+		wantErr bool
+	}{
+		{name: "all", s: "10", want: [4]float64{10, 10, 10, 10}},
+		{name: "vertical horizontal", s: "10, 5.5", want: [4]float64{10, 5.5, 10, 5.5}},
+		{name: "four sides", s: "1,2,3,4", want: [4]float64{1, 2, 3, 4}},
+		{name: "three values", s: "1,2,3", wantErr: true},
+		{name: "negative", s: "-1", wantErr: true},
+		{name: "empty", s: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseMargins(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseMargins() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseMargins() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_cropBox(t *testing.T) {
+	box := &unipdf.PdfRectangle{Llx: 0, Lly: 0, Urx: 100, Ury: 200}
+	mm := pointsPerMillimeter
+
+	got, err := cropBox(box, [4]float64{10, 5, 0, 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &unipdf.PdfRectangle{Llx: 5 * mm, Lly: 0, Urx: 100 - 5*mm, Ury: 200 - 10*mm}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("cropBox() = %+v, want %+v", got, want)
+	}
+
+	if _, err := cropBox(box, [4]float64{0, 20, 0, 20}); err == nil {
+		t.Errorf("cropBox() expected error when margins exceed the page")
+	}
+}
+
+func Test_nupSheet_place(t *testing.T) {
+	const a4w, a4h = 595.0, 842.0
+	tests := []struct {
+		name      string
+		n         int
+		w, h      float64
+		cell      int
+		wantSheet nupSheet
+		wantX     float64
+		wantY     float64
+	}{
+		{name: "2-up portrait left", n: 2, w: a4w, h: a4h, cell: 0, wantSheet: nupSheet{width: a4h, height: a4w, cols: 2, rows: 1}, wantX: (a4h/2 - a4w*a4w/a4h) / 2, wantY: 0},
+		{name: "2-up portrait right", n: 2, w: a4w, h: a4h, cell: 1, wantSheet: nupSheet{width: a4h, height: a4w, cols: 2, rows: 1}, wantX: a4h/2 + (a4h/2-a4w*a4w/a4h)/2, wantY: 0},
+		{name: "2-up landscape bottom", n: 2, w: a4h, h: a4w, cell: 1, wantSheet: nupSheet{width: a4w, height: a4h, cols: 1, rows: 2}, wantX: 0, wantY: a4h/2 + (a4h/2-a4w*a4w/a4h)/2},
+		{name: "4-up last", n: 4, w: a4w, h: a4h, cell: 3, wantSheet: nupSheet{width: a4w, height: a4h, cols: 2, rows: 2}, wantX: a4w / 2, wantY: a4h / 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sheet := nupLayout(tt.n, tt.w, tt.h)
+			if sheet != tt.wantSheet {
+				t.Fatalf("nupLayout() = %+v, want %+v", sheet, tt.wantSheet)
+			}
+			x, y, scale := sheet.place(tt.cell, tt.w, tt.h)
+			if math.Abs(x-tt.wantX) > 1e-6 || math.Abs(y-tt.wantY) > 1e-6 {
+				t.Errorf("place() = (%v, %v), want (%v, %v)", x, y, tt.wantX, tt.wantY)
+			}
+			cellW, cellH := sheet.width/float64(sheet.cols), sheet.height/float64(sheet.rows)
+			if tt.w*scale > cellW+1e-6 || tt.h*scale > cellH+1e-6 {
+				t.Errorf("place() scale %v does not fit the cell", scale)
+			}
+		})
+	}
+}
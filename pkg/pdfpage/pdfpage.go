@@ -0,0 +1,180 @@
+package pdfpage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"invtools/common"
+	"invtools/pkg/util"
+	"invtools/utils"
+	"invtools/utils/errors"
+
+	"github.com/gosuri/uiprogress"
+	"github.com/skratchdot/open-golang/open"
+	unipdf "github.com/unidoc/unipdf/v3/model"
+)
+
+const cmdName = "pdfpage"
+
+type Processor struct {
+	input, output, password string
+	op                      Operation
+	concurrency             int
+}
+
+// NewPdfPageProcessor input为pdf文件或者目录, 处理后的文件以原文件名保存在output目录下
+func NewPdfPageProcessor(input, output, password string, op Operation, concurrency int) *Processor {
+	return &Processor{
+		input:       input,
+		output:      output,
+		password:    password,
+		op:          op,
+		concurrency: concurrency,
+	}
+}
+
+func (p *Processor) validate() error {
+	if err := p.op.Validate(); err != nil {
+		return err
+	}
+
+	if !utils.CheckFileIsExist(p.input) {
+		return errors.Errorf(nil, "输入路径不存在:%s", p.input)
+	}
+	if path.Clean(p.input) == path.Clean(p.output) {
+		return errors.Errorf(nil, "输出目录不能与输入目录相同")
+	}
+
+	if err := utils.CheckAndMkDir(p.output); err != nil {
+		return errors.Errorf(err, "创建目录失败,目录:%s", p.output)
+	}
+	return nil
+}
+
+func (p *Processor) Do() error {
+	if err := p.validate(); err != nil {
+		return err
+	}
+
+	return p.execute()
+}
+
+func (p *Processor) execute() error {
+	files := []string{p.input}
+	if utils.CheckDirIsExist(p.input) {
+		var err error
+		if files, err = util.ReadDirFiles(p.input, common.ExtPDF); err != nil {
+			return errors.Errorf(err, "读取input路径下的pdf文件失败")
+		}
+	}
+
+	fmt.Printf("[%s] 扫描路径后一共得到%d个文件,即将开始处理...\n", cmdName, len(files))
+
+	groups := util.DivideSliceIntoGroup(files, p.concurrency)
+
+	uiprogress.Start()
+	var (
+		wg           sync.WaitGroup
+		mu           sync.Mutex
+		st           = time.Now()
+		successFiles []string
+		failedFiles  []string
+	)
+
+	wg.Add(len(groups))
+	for _, value := range groups {
+		ctx := context.Background()
+		grp := value
+		cnt := len(grp)
+		go utils.HandlePanicV2(ctx, func(i interface{}) {
+			grp := *i.(*[]string)
+			defer wg.Done()
+
+			bar := uiprogress.AddBar(cnt).AppendCompleted().PrependElapsed()
+			bar.PrependFunc(func(b *uiprogress.Bar) string {
+				return fmt.Sprintf("processing: %d/%d", b.Current(), cnt)
+			})
+
+			for bar.Incr() {
+				f := grp[bar.Current()-1]
+				err := p.process(f, path.Join(p.output, path.Base(f)))
+				mu.Lock()
+				if err != nil {
+					failedFiles = append(failedFiles, fmt.Sprintf("file:%s, err:%v", f, err))
+				} else {
+					successFiles = append(successFiles, f)
+				}
+				mu.Unlock()
+			}
+		})(&grp)
+	}
+
+	wg.Wait()
+	uiprogress.Stop()
+
+	fmt.Printf("[%s] 本次处理, 一共成功%d个pdf, 失败%d个, 总耗时:%s\n", cmdName, len(successFiles), len(failedFiles), time.Since(st))
+	fmt.Printf("[%s] 处理后的文件保存目录是: %s\n", cmdName, p.output)
+	if len(failedFiles) > 0 {
+		fmt.Printf("失败文件:%s", failedFiles)
+	}
+
+	open.Run(p.output)
+
+	return nil
+}
+
+// process 读取filePath的所有页面, 执行页面操作后写入output
+func (p *Processor) process(filePath, output string) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return errors.Errorf(err, "open file failed, file:%s", filePath)
+	}
+	// 写入完成前保持文件打开, 页面中的对象可能在写入时才读取
+	defer f.Close()
+
+	pdfReader, err := util.NewPdfReader(f, p.password)
+	if err != nil {
+		return errors.Errorf(err, "读取pdf失败")
+	}
+
+	numPages, err := pdfReader.GetNumPages()
+	if err != nil {
+		return errors.Errorf(err, "获取页数失败")
+	}
+
+	doc := &document{file: filePath, password: p.password}
+	for i := 1; i <= numPages; i++ {
+		page, err := pdfReader.GetPage(i)
+		if err != nil {
+			return errors.Errorf(err, "pdfReader.GetPage 失败, page:%d", i)
+		}
+		doc.pages = append(doc.pages, page)
+	}
+
+	pages, err := p.op.Apply(doc)
+	if err != nil {
+		return err
+	}
+
+	pdfWriter := unipdf.NewPdfWriter()
+	for i, page := range pages {
+		if err := pdfWriter.AddPage(page); err != nil {
+			return errors.Errorf(err, "pdfWriter.AddPage failed, page:%d", i+1)
+		}
+	}
+
+	fWrite, err := os.Create(output)
+	if err != nil {
+		return errors.Errorf(err, "创建文件失败, file:%s", output)
+	}
+	defer fWrite.Close()
+
+	if err := pdfWriter.Write(fWrite); err != nil {
+		return errors.Errorf(err, "写入pdf失败, file:%s", output)
+	}
+	return nil
+}
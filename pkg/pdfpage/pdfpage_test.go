@@ -0,0 +1,140 @@
+package pdfpage
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"invtools/pkg/internal/testpdf"
+	"invtools/pkg/util"
+
+	unipdf "github.com/unidoc/unipdf/v3/model"
+)
+
+// writeTestPdf 生成A4的pdf, texts中为空字符串的页面为空白页
+// readTestPdf 读取所有页面, 调用方检查完页面后关闭返回的文件
+func readTestPdf(t *testing.T, file string) ([]*unipdf.PdfPage, *os.File) {
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := util.NewPdfReader(f, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	numPages, err := r.GetNumPages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pages []*unipdf.PdfPage
+	for i := 1; i <= numPages; i++ {
+		page, err := r.GetPage(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages = append(pages, page)
+	}
+	return pages, f
+}
+
+func TestProcessor_process(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pdfpage_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	input := path.Join(dir, "voucher.pdf")
+	testpdf.Write(t, input, "page 1", "", "page 3", "page 4", "")
+
+	tests := []struct {
+		name  string
+		op    Operation
+		check func(t *testing.T, pages []*unipdf.PdfPage)
+	}{
+		{
+			name: "delete blank and range",
+			op:   &DeleteOp{Pages: "end,1", Blank: true},
+			check: func(t *testing.T, pages []*unipdf.PdfPage) {
+				if len(pages) != 2 {
+					t.Errorf("got %d pages, want 2", len(pages))
+				}
+			},
+		},
+		{
+			name: "rotate",
+			op:   &RotateOp{Angle: -90, Pages: "2-end"},
+			check: func(t *testing.T, pages []*unipdf.PdfPage) {
+				if len(pages) != 5 {
+					t.Fatalf("got %d pages, want 5", len(pages))
+				}
+				if pages[0].Rotate != nil && *pages[0].Rotate != 0 {
+					t.Errorf("page 1 rotate = %d, want 0", *pages[0].Rotate)
+				}
+				if pages[1].Rotate == nil || *pages[1].Rotate != 270 {
+					t.Errorf("page 2 rotate = %v, want 270", pages[1].Rotate)
+				}
+			},
+		},
+		{
+			name: "reorder with duplicate",
+			op:   &ReorderOp{Order: "3,1,3"},
+			check: func(t *testing.T, pages []*unipdf.PdfPage) {
+				if len(pages) != 3 {
+					t.Errorf("got %d pages, want 3", len(pages))
+				}
+			},
+		},
+		{
+			name: "crop",
+			op:   &CropOp{Margins: "10"},
+			check: func(t *testing.T, pages []*unipdf.PdfPage) {
+				if pages[0].CropBox == nil || pages[0].CropBox.Llx <= 0 {
+					t.Errorf("page 1 crop box = %+v, want cropped", pages[0].CropBox)
+				}
+			},
+		},
+		{
+			name: "4-up",
+			op:   &NupOp{N: 4},
+			check: func(t *testing.T, pages []*unipdf.PdfPage) {
+				if len(pages) != 2 {
+					t.Fatalf("got %d sheets, want 2", len(pages))
+				}
+			},
+		},
+		{
+			name: "2-up",
+			op:   &NupOp{N: 2},
+			check: func(t *testing.T, pages []*unipdf.PdfPage) {
+				if len(pages) != 3 {
+					t.Fatalf("got %d sheets, want 3", len(pages))
+				}
+				mbox, err := pages[0].GetMediaBox()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if mbox.Urx-mbox.Llx <= mbox.Ury-mbox.Lly {
+					t.Errorf("2-up sheet %+v, want landscape", mbox)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.op.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			output := path.Join(dir, "out.pdf")
+			p := NewPdfPageProcessor(input, dir, "", tt.op, 1)
+			if err := p.process(input, output); err != nil {
+				t.Fatalf("process() error = %v", err)
+			}
+			pages, f := readTestPdf(t, output)
+			defer f.Close()
+			tt.check(t, pages)
+		})
+	}
+}
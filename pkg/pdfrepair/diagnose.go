@@ -0,0 +1,145 @@
+package pdfrepair
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+
+	"invtools/pkg/util"
+	"invtools/pkg/util/mupdf"
+	"invtools/utils/errors"
+)
+
+const (
+	// StatusHealthy 原文件可以正常解析, mupdf重写时也没有警告
+	StatusHealthy = "healthy"
+	// StatusRepaired 原文件解析失败或者mupdf有警告, 修复后的文件可以正常解析
+	StatusRepaired = "repaired"
+	// StatusUnrecoverable mupdf重写失败或者修复后的文件仍然无法解析
+	StatusUnrecoverable = "unrecoverable"
+
+	// maxWarnings 说明中最多显示的mupdf警告数
+	maxWarnings = 3
+)
+
+// health 用unipdf解析pdf、统计页数并提取文字的结果
+type health struct {
+	pages int
+	chars int // 提取到的文字数, 扫描件为0
+	err   error
+}
+
+// checkHealth 受损的pdf可能让unipdf panic, 当作解析失败处理
+func checkHealth(file string) (h *health) {
+	h = &health{}
+	defer func() {
+		if r := recover(); r != nil {
+			h.err = errors.Errorf(nil, "解析pdf时panic:%v", r)
+		}
+	}()
+
+	texts, err := util.NewUniPdf().ExtractTextWithPages(file, "", nil)
+	if err != nil {
+		h.err = err
+		return h
+	}
+	h.pages = len(texts)
+	for _, text := range texts {
+		h.chars += utf8.RuneCountInString(strings.TrimSpace(text))
+	}
+	if h.pages == 0 {
+		h.err = errors.Errorf(nil, "没有页面")
+	}
+	return h
+}
+
+// report 一个文件修复前后的检查结果
+type report struct {
+	file          string
+	before, after *health
+	clean         *mupdf.CleanResult
+	cleanErr      error
+}
+
+func (r *report) status() string {
+	if r.cleanErr != nil || r.after == nil || r.after.err != nil {
+		return StatusUnrecoverable
+	}
+	if r.before.err == nil && (r.clean == nil || len(r.clean.Warnings) == 0) {
+		return StatusHealthy
+	}
+	return StatusRepaired
+}
+
+// detail 修复前的错误、mupdf的警告和错误、修复后的错误以及页数的变化
+func (r *report) detail() string {
+	var parts []string
+	if r.before.err != nil {
+		parts = append(parts, fmt.Sprintf("修复前:%v", oneLine(r.before.err)))
+	}
+	if r.clean != nil && len(r.clean.Warnings) > 0 {
+		warnings := r.clean.Warnings
+		if len(warnings) > maxWarnings {
+			warnings = append(warnings[:maxWarnings:maxWarnings], fmt.Sprintf("...共%d条", len(r.clean.Warnings)))
+		}
+		parts = append(parts, "mupdf警告:"+strings.Join(warnings, "; "))
+	}
+	if r.cleanErr != nil {
+		parts = append(parts, fmt.Sprintf("mupdf:%v", oneLine(r.cleanErr)))
+	}
+	if r.after != nil {
+		if r.after.err != nil {
+			parts = append(parts, fmt.Sprintf("修复后:%v", oneLine(r.after.err)))
+		} else if r.before.err == nil && r.before.pages != r.after.pages {
+			parts = append(parts, fmt.Sprintf("页数由%d变为%d", r.before.pages, r.after.pages))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// pages 修复前/修复后的页数, 解析失败时为 -
+func (r *report) pages() string {
+	count := func(h *health) string {
+		if h == nil || h.err != nil {
+			return "-"
+		}
+		return fmt.Sprint(h.pages)
+	}
+	return count(r.before) + "/" + count(r.after)
+}
+
+// chars 修复后提取到的文字数, 修复后无法解析时为修复前的文字数
+func (r *report) chars() string {
+	for _, h := range []*health{r.after, r.before} {
+		if h != nil && h.err == nil {
+			return fmt.Sprint(h.chars)
+		}
+	}
+	return "-"
+}
+
+// writeSummary 输出每个文件的检查结果和各状态的文件数
+func writeSummary(w io.Writer, reports []*report) error {
+	counts := make(map[string]int)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "文件\t状态\t页数(修复前/后)\t文字数\t说明")
+	for _, r := range reports {
+		status := r.status()
+		counts[status]++
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.file, status, r.pages(), r.chars(), r.detail())
+	}
+	if err := tw.Flush(); err != nil {
+		return errors.Errorf(err, "输出检查结果失败")
+	}
+
+	_, err := fmt.Fprintf(w, "\n%s:%d, %s:%d, %s:%d\n",
+		StatusHealthy, counts[StatusHealthy], StatusRepaired, counts[StatusRepaired], StatusUnrecoverable, counts[StatusUnrecoverable])
+	return err
+}
+
+// oneLine 错误信息中的换行会打乱表格
+func oneLine(err error) string {
+	return strings.Join(strings.Fields(err.Error()), " ")
+}
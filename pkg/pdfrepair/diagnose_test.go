@@ -0,0 +1,97 @@
+package pdfrepair
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"invtools/pkg/internal/testpdf"
+	"invtools/pkg/util/mupdf"
+	"invtools/utils/errors"
+)
+
+// writeTestPdf 生成A4的pdf, 每个text一页
+func Test_checkHealth(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pdfrepair_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	good := path.Join(dir, "good.pdf")
+	testpdf.Write(t, good, "page 1", "page 2")
+	h := checkHealth(good)
+	if h.err != nil || h.pages != 2 || h.chars != len("page 1")*2 {
+		t.Errorf("checkHealth() got = %+v", h)
+	}
+
+	broken := path.Join(dir, "broken.pdf")
+	if err := ioutil.WriteFile(broken, []byte("not a pdf"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if h := checkHealth(broken); h.err == nil {
+		t.Errorf("checkHealth() want error for broken pdf, got = %+v", h)
+	}
+}
+
+func Test_report_status(t *testing.T) {
+	ok := &health{pages: 1, chars: 10}
+	bad := &health{err: errors.Errorf(nil, "invalid xref")}
+	warned := &mupdf.CleanResult{Warnings: []string{"trying to repair broken xref"}}
+
+	tests := []struct {
+		name   string
+		report report
+		want   string
+	}{
+		{"healthy", report{before: ok, clean: &mupdf.CleanResult{}, after: ok}, StatusHealthy},
+		{"mupdf warnings", report{before: ok, clean: warned, after: ok}, StatusRepaired},
+		{"unipdf failed before", report{before: bad, clean: &mupdf.CleanResult{}, after: ok}, StatusRepaired},
+		{"mupdf failed", report{before: bad, clean: &mupdf.CleanResult{Code: 1}, cleanErr: errors.Errorf(nil, "cannot open")}, StatusUnrecoverable},
+		{"unipdf failed after", report{before: bad, clean: warned, after: bad}, StatusUnrecoverable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.report.status(); got != tt.want {
+				t.Errorf("status() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_writeSummary(t *testing.T) {
+	reports := []*report{
+		{file: "a.pdf", before: &health{pages: 2, chars: 12}, clean: &mupdf.CleanResult{}, after: &health{pages: 2, chars: 12}},
+		{
+			file:   "b.pdf",
+			before: &health{err: errors.Errorf(nil, "invalid\nxref")},
+			clean:  &mupdf.CleanResult{Warnings: []string{"w1", "w2", "w3", "w4"}},
+			after:  &health{pages: 1},
+		},
+		{file: "c.pdf", before: &health{err: errors.Errorf(nil, "eof")}, cleanErr: errors.Errorf(nil, "cannot open")},
+	}
+
+	var buf bytes.Buffer
+	if err := writeSummary(&buf, reports); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	lines := strings.Split(out, "\n")
+	if !strings.Contains(lines[1], "healthy") || !strings.Contains(lines[1], "2/2") {
+		t.Errorf("line a.pdf = %q", lines[1])
+	}
+	if !strings.Contains(lines[2], "repaired") || !strings.Contains(lines[2], "-/1") ||
+		!strings.Contains(lines[2], "invalid xref") || !strings.Contains(lines[2], "w1; w2; w3; ...共4条") {
+		t.Errorf("line b.pdf = %q", lines[2])
+	}
+	if !strings.Contains(lines[3], "unrecoverable") || !strings.Contains(lines[3], "-/-") {
+		t.Errorf("line c.pdf = %q", lines[3])
+	}
+	if !strings.Contains(out, "healthy:1, repaired:1, unrecoverable:1") {
+		t.Errorf("summary = %q", out)
+	}
+}
@@ -96,18 +96,46 @@ func (r *PdfRepair) Do() error {
 		return nil
 	}
 
+	var reports []*report
 	for i := 0; i < len(files); i++ {
 		infile := files[i]
 		outfile := path.Join(r.outputDir, path.Base(infile))
 		fmt.Printf("[%s] [%d/%d]start fix file: %s\n", cmdName, i+1, len(files), path.Base(infile))
-		err := mupdf.PdfRepair(infile, outfile)
-		if err != nil {
-			fmt.Printf("[%s] 修复文件:%s发生错误,err:%s\n", cmdName, path.Base(infile), err.Error())
-			continue
+		rep := repair(infile, outfile)
+		if rel, err := filepath.Rel(r.inputDir, infile); err == nil {
+			rep.file = rel
 		}
+		reports = append(reports, rep)
+	}
+
+	fmt.Println()
+	if err := writeSummary(os.Stdout, reports); err != nil {
+		return err
 	}
 
 	fmt.Printf("\n[%s] 批量修复完毕, 修复后的文件保存目录是: %s\n", cmdName, r.outputDir)
 	open.Run(path.Dir(r.outputDir))
 	return nil
 }
+
+// cleanFile mupdf重写pdf, 测试时替换
+var cleanFile = mupdf.PdfRepair
+
+// repair 修复前后分别用unipdf检查, 无法修复时删除mupdf写出的文件
+func repair(infile, outfile string) *report {
+	r := &report{file: infile, before: checkHealth(infile)}
+
+	r.clean, r.cleanErr = cleanFile(infile, outfile)
+	if r.cleanErr == nil {
+		if utils.CheckFileIsExist(outfile) {
+			r.after = checkHealth(outfile)
+		} else {
+			r.cleanErr = errors.Errorf(nil, "mupdf没有写出修复后的文件")
+		}
+	}
+
+	if r.status() == StatusUnrecoverable {
+		_ = os.Remove(outfile)
+	}
+	return r
+}
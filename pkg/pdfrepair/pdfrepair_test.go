@@ -0,0 +1,89 @@
+package pdfrepair
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"invtools/pkg/internal/testpdf"
+	"invtools/pkg/util/mupdf"
+	"invtools/utils"
+	"invtools/utils/errors"
+)
+
+func Test_repair(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pdfrepair_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(f func(string, string) (*mupdf.CleanResult, error)) { cleanFile = f }(cleanFile)
+
+	input := path.Join(dir, "voucher.pdf")
+	testpdf.Write(t, input, "page 1")
+	data, err := ioutil.ReadFile(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		clean      func(infile, outfile string) (*mupdf.CleanResult, error)
+		want       string
+		wantOutput bool
+	}{
+		{
+			name: "healthy",
+			clean: func(infile, outfile string) (*mupdf.CleanResult, error) {
+				return &mupdf.CleanResult{}, ioutil.WriteFile(outfile, data, 0644)
+			},
+			want:       StatusHealthy,
+			wantOutput: true,
+		},
+		{
+			name: "repaired",
+			clean: func(infile, outfile string) (*mupdf.CleanResult, error) {
+				return &mupdf.CleanResult{Warnings: []string{"trying to repair broken xref"}}, ioutil.WriteFile(outfile, data, 0644)
+			},
+			want:       StatusRepaired,
+			wantOutput: true,
+		},
+		{
+			name: "mupdf failed",
+			clean: func(infile, outfile string) (*mupdf.CleanResult, error) {
+				return &mupdf.CleanResult{Code: 1}, errors.Errorf(nil, "cannot open document")
+			},
+			want: StatusUnrecoverable,
+		},
+		{
+			name: "no output",
+			clean: func(infile, outfile string) (*mupdf.CleanResult, error) {
+				return &mupdf.CleanResult{}, nil
+			},
+			want: StatusUnrecoverable,
+		},
+		{
+			name: "broken output",
+			clean: func(infile, outfile string) (*mupdf.CleanResult, error) {
+				return &mupdf.CleanResult{}, ioutil.WriteFile(outfile, []byte("not a pdf"), 0644)
+			},
+			want: StatusUnrecoverable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cleanFile = tt.clean
+			output := path.Join(dir, "repaired.pdf")
+			defer os.Remove(output)
+
+			r := repair(input, output)
+			if got := r.status(); got != tt.want {
+				t.Errorf("status() = %s, want %s, detail:%s", got, tt.want, r.detail())
+			}
+			if got := utils.CheckFileIsExist(output); got != tt.wantOutput {
+				t.Errorf("output exists = %v, want %v", got, tt.wantOutput)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package pdfsecure
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"invtools/pkg/util"
+	"invtools/utils/errors"
+)
+
+const (
+	// csvColumnFile csv密码文件中的文件名列, 可以是文件名或者完整路径
+	csvColumnFile = "file"
+	// csvColumnPassword csv密码文件中的密码列
+	csvColumnPassword = "password"
+)
+
+// loadPasswordCsv 读取每个文件的密码, 第一行为表头, 需要包含file和password列
+func loadPasswordCsv(file string) (map[string]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, errors.Errorf(err, "open password csv failed, file:%s", file)
+	}
+	defer f.Close()
+
+	return readPasswordCsv(f)
+}
+
+// readPasswordCsv 密码不去掉首尾空格; 同一个文件出现多次时使用最后一个密码
+func readPasswordCsv(r io.Reader) (map[string]string, error) {
+	table, err := util.ReadCsvTable(r, csvColumnFile, csvColumnPassword)
+	if err != nil {
+		return nil, errors.Errorf(err, "读取密码文件失败")
+	}
+
+	passwords := make(map[string]string)
+	for _, row := range table.Rows {
+		name := strings.TrimSpace(table.Value(row, csvColumnFile))
+		if name == "" {
+			continue
+		}
+		passwords[name] = table.Value(row, csvColumnPassword)
+	}
+	return passwords, nil
+}
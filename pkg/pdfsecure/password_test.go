@@ -0,0 +1,40 @@
+package pdfsecure
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_readPasswordCsv(t *testing.T) {
+	tests := []struct {
+		name    string
+		csv     string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "excel bom",
+			csv:  "\ufeffFile,Password\nvoucher_1.pdf,abc\n/data/voucher_2.pdf, with space \n,ignored\n",
+			want: map[string]string{"voucher_1.pdf": "abc", "/data/voucher_2.pdf": " with space "},
+		},
+		{
+			name: "extra columns",
+			csv:  "booking,password,file\nB1,abc,voucher_1.pdf\nB2\n",
+			want: map[string]string{"voucher_1.pdf": "abc"},
+		},
+		{name: "missing column", csv: "file,group\nvoucher_1.pdf,a\n", wantErr: true},
+		{name: "empty", csv: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readPasswordCsv(strings.NewReader(tt.csv))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readPasswordCsv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readPasswordCsv() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,397 @@
+package pdfsecure
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"invtools/common"
+	"invtools/pkg/util"
+	"invtools/utils"
+	"invtools/utils/errors"
+
+	"github.com/gosuri/uiprogress"
+	"github.com/skratchdot/open-golang/open"
+	unicore "github.com/unidoc/unipdf/v3/core"
+	unisecurity "github.com/unidoc/unipdf/v3/core/security"
+	unipdf "github.com/unidoc/unipdf/v3/model"
+)
+
+const (
+	cmdName = "pdfsecure"
+
+	// ActionStatus 只检查每个文件的加密状态, 不写入文件
+	ActionStatus = "status"
+	// ActionDecrypt 去掉密码和权限限制
+	ActionDecrypt = "decrypt"
+	// ActionEncrypt 使用AES-256加密, 设置打开密码、权限密码和权限
+	ActionEncrypt = "encrypt"
+)
+
+// Options 加密/解密选项, 除了Password和PasswordCsv, 还会尝试全局密码列表(--password_list)
+type Options struct {
+	Password    string // 输入文件的密码, 在密码列表之前尝试
+	PasswordCsv string // 每个文件的密码, 列: file,password, 在Password之前尝试
+
+	UserPassword  string // encrypt: 打开文件的密码, 为空时不需要密码就能打开, 但是受权限限制
+	OwnerPassword string // encrypt: 修改权限的密码
+	AllowPrint    bool   // encrypt: 允许打印
+	AllowCopy     bool   // encrypt: 允许复制文字和图片
+}
+
+// Validate 加密时必须指定owner密码, 否则任何人都可以去掉权限限制
+func (o *Options) Validate(action string) error {
+	switch action {
+	case ActionStatus, ActionDecrypt:
+	case ActionEncrypt:
+		if o.OwnerPassword == "" {
+			return errors.Errorf(nil, "加密时需要指定owner密码")
+		}
+	default:
+		return errors.Errorf(nil, "不支持的操作:%s, 支持: status/decrypt/encrypt", action)
+	}
+
+	if o.PasswordCsv != "" && !utils.CheckFileIsExist(o.PasswordCsv) {
+		return errors.Errorf(nil, "密码文件不存在:%s", o.PasswordCsv)
+	}
+	return nil
+}
+
+// permissions encrypt时授予的权限, 始终允许填写表单和无障碍读取
+func (o *Options) permissions() unisecurity.Permissions {
+	perms := unisecurity.PermFillForms | unisecurity.PermDisabilityExtract
+	if o.AllowPrint {
+		perms |= unisecurity.PermPrinting | unisecurity.PermFullPrintQuality
+	}
+	if o.AllowCopy {
+		perms |= unisecurity.PermExtractGraphics
+	}
+	return perms
+}
+
+type Securer struct {
+	input, output string
+	action        string
+	opts          *Options
+	concurrency   int
+
+	// filePasswords PasswordCsv中每个文件的密码
+	filePasswords map[string]string
+}
+
+// NewPdfSecurer input为pdf文件或者目录, 处理后的文件以原文件名保存在output目录下; status不写入文件
+func NewPdfSecurer(input, output, action string, opts *Options, concurrency int) *Securer {
+	return &Securer{
+		input:       input,
+		output:      output,
+		action:      action,
+		opts:        opts,
+		concurrency: concurrency,
+	}
+}
+
+func (s *Securer) validate() error {
+	if err := s.opts.Validate(s.action); err != nil {
+		return err
+	}
+
+	if !utils.CheckFileIsExist(s.input) {
+		return errors.Errorf(nil, "输入路径不存在:%s", s.input)
+	}
+
+	if s.opts.PasswordCsv != "" {
+		passwords, err := loadPasswordCsv(s.opts.PasswordCsv)
+		if err != nil {
+			return err
+		}
+		s.filePasswords = passwords
+	}
+
+	if s.action == ActionStatus {
+		return nil
+	}
+	if path.Clean(s.input) == path.Clean(s.output) {
+		return errors.Errorf(nil, "输出目录不能与输入目录相同")
+	}
+	if err := utils.CheckAndMkDir(s.output); err != nil {
+		return errors.Errorf(err, "创建目录失败,目录:%s", s.output)
+	}
+	return nil
+}
+
+func (s *Securer) Do() error {
+	if err := s.validate(); err != nil {
+		return err
+	}
+
+	return s.execute()
+}
+
+func (s *Securer) execute() error {
+	files := []string{s.input}
+	if utils.CheckDirIsExist(s.input) {
+		var err error
+		if files, err = util.ReadDirFiles(s.input, common.ExtPDF); err != nil {
+			return errors.Errorf(err, "读取input路径下的pdf文件失败")
+		}
+	}
+
+	fmt.Printf("[%s] 扫描路径后一共得到%d个文件,即将开始处理...\n", cmdName, len(files))
+
+	groups := util.DivideSliceIntoGroup(files, s.concurrency)
+
+	uiprogress.Start()
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		st       = time.Now()
+		statuses = make(map[string]*fileStatus)
+	)
+
+	wg.Add(len(groups))
+	for _, value := range groups {
+		ctx := context.Background()
+		grp := value
+		cnt := len(grp)
+		go utils.HandlePanicV2(ctx, func(i interface{}) {
+			grp := *i.(*[]string)
+			defer wg.Done()
+
+			bar := uiprogress.AddBar(cnt).AppendCompleted().PrependElapsed()
+			bar.PrependFunc(func(b *uiprogress.Bar) string {
+				return fmt.Sprintf("processing: %d/%d", b.Current(), cnt)
+			})
+
+			for bar.Incr() {
+				f := grp[bar.Current()-1]
+				status := s.process(f, path.Join(s.output, path.Base(f)))
+				mu.Lock()
+				statuses[f] = status
+				mu.Unlock()
+			}
+		})(&grp)
+	}
+
+	wg.Wait()
+	uiprogress.Stop()
+
+	// 按文件顺序输出每个文件的加密状态
+	var failed int
+	for _, f := range files {
+		status := statuses[f]
+		if status == nil {
+			// 处理时panic, 没有得到结果
+			status = &fileStatus{file: f, err: errors.Errorf(nil, "处理失败")}
+		}
+		if status.err != nil {
+			failed++
+		}
+		fmt.Printf("[%s] %s\n", cmdName, status)
+	}
+
+	fmt.Printf("[%s] 本次%s操作, 一共成功%d个pdf, 失败%d个, 总耗时:%s\n", cmdName, s.action, len(files)-failed, failed, time.Since(st))
+	if s.action != ActionStatus {
+		fmt.Printf("[%s] 处理后的文件保存目录是: %s\n", cmdName, s.output)
+		open.Run(s.output)
+	}
+	return nil
+}
+
+// passwords 打开filePath时在全局密码列表之前尝试的密码
+func (s *Securer) passwords(filePath string) []string {
+	var passwords []string
+	if p, ok := s.filePasswords[filePath]; ok {
+		passwords = append(passwords, p)
+	} else if p, ok := s.filePasswords[path.Base(filePath)]; ok {
+		passwords = append(passwords, p)
+	}
+	return append(passwords, s.opts.Password)
+}
+
+// process 检查加密状态, 然后按action写入output; 返回的状态是处理之前的状态
+func (s *Securer) process(filePath, output string) *fileStatus {
+	status := &fileStatus{file: filePath}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		status.err = errors.Errorf(err, "open file failed")
+		return status
+	}
+	// 写入完成前保持文件打开, 页面中的对象可能在写入时才读取
+	defer f.Close()
+
+	pdfReader, err := unipdf.NewPdfReader(f)
+	if err != nil {
+		status.err = errors.Errorf(err, "读取pdf失败")
+		return status
+	}
+	if err := status.inspect(pdfReader, s.passwords(filePath)); err != nil {
+		status.err = err
+		return status
+	}
+
+	switch s.action {
+	case ActionDecrypt:
+		status.err = writeDocument(pdfReader, output, nil)
+	case ActionEncrypt:
+		status.err = writeDocument(pdfReader, output, s.opts)
+	}
+	return status
+}
+
+// writeDocument 复制所有页面、书签和表单到output, opts不为nil时使用AES-256加密
+func writeDocument(r *unipdf.PdfReader, output string, opts *Options) error {
+	numPages, err := r.GetNumPages()
+	if err != nil {
+		return errors.Errorf(err, "获取页数失败")
+	}
+
+	pdfWriter := unipdf.NewPdfWriter()
+	for i := 1; i <= numPages; i++ {
+		page, err := r.GetPage(i)
+		if err != nil {
+			return errors.Errorf(err, "pdfReader.GetPage 失败, page:%d", i)
+		}
+		if err := pdfWriter.AddPage(page); err != nil {
+			return errors.Errorf(err, "pdfWriter.AddPage failed, page:%d", i)
+		}
+	}
+	if outline := r.GetOutlineTree(); outline != nil {
+		pdfWriter.AddOutlineTree(outline)
+	}
+	if r.AcroForm != nil {
+		if err := pdfWriter.SetForms(r.AcroForm); err != nil {
+			return errors.Errorf(err, "复制表单失败")
+		}
+	}
+
+	if opts != nil {
+		encryptOpts := &unipdf.EncryptOptions{
+			Permissions: opts.permissions(),
+			Algorithm:   unipdf.AES_256bit,
+		}
+		if err := pdfWriter.Encrypt([]byte(opts.UserPassword), []byte(opts.OwnerPassword), encryptOpts); err != nil {
+			return errors.Errorf(err, "加密失败")
+		}
+	}
+
+	fWrite, err := os.Create(output)
+	if err != nil {
+		return errors.Errorf(err, "创建文件失败, file:%s", output)
+	}
+	defer fWrite.Close()
+
+	if err := pdfWriter.Write(fWrite); err != nil {
+		return errors.Errorf(err, "写入pdf失败, file:%s", output)
+	}
+	return nil
+}
+
+// fileStatus 一个文件的加密状态
+type fileStatus struct {
+	file      string
+	encrypted bool
+	method    string                  // 加密算法, 例如 AES-256
+	perms     unisecurity.Permissions // 没有owner密码时的权限
+	noUser    bool                    // 没有打开密码(只有owner密码)
+	owner     bool                    // 使用owner密码打开, 可以去掉所有限制
+	err       error
+}
+
+// inspect 读取加密信息, 并依次尝试passwords和全局密码列表解密
+func (s *fileStatus) inspect(r *unipdf.PdfReader, passwords []string) error {
+	encrypted, err := r.IsEncrypted()
+	if err != nil {
+		return errors.Errorf(err, "check IsEncrypted failed")
+	}
+	if !encrypted {
+		return nil
+	}
+
+	s.encrypted = true
+	trailer, err := r.GetTrailer()
+	if err != nil {
+		return errors.Errorf(err, "读取pdf trailer失败")
+	}
+	if dict, ok := unicore.GetDict(trailer.Get("Encrypt")); ok {
+		s.method, s.perms = encryptInfo(dict)
+	}
+	if ok, _, err := r.CheckAccessRights(nil); err == nil && ok {
+		s.noUser = true
+	}
+
+	password, err := util.DecryptPdfReader(r, passwords...)
+	if err != nil {
+		return err
+	}
+	if _, perms, err := r.CheckAccessRights([]byte(password)); err == nil && perms == unisecurity.PermOwner {
+		s.owner = true
+	}
+	return nil
+}
+
+// encryptInfo 根据Encrypt字典的V和CF得到加密算法, P为权限
+func encryptInfo(dict *unicore.PdfObjectDictionary) (string, unisecurity.Permissions) {
+	var perms unisecurity.Permissions
+	if p, ok := unicore.GetIntVal(dict.Get("P")); ok {
+		perms = unisecurity.Permissions(uint32(int32(p)))
+	}
+
+	v, _ := unicore.GetIntVal(dict.Get("V"))
+	switch v {
+	case 1:
+		return "RC4-40", perms
+	case 2:
+		length, ok := unicore.GetIntVal(dict.Get("Length"))
+		if !ok {
+			length = 40
+		}
+		return fmt.Sprintf("RC4-%d", length), perms
+	case 4:
+		if cf, ok := unicore.GetDict(dict.Get("CF")); ok {
+			if std, ok := unicore.GetDict(cf.Get("StdCF")); ok {
+				if name, ok := unicore.GetNameVal(std.Get("CFM")); ok && name == "AESV2" {
+					return "AES-128", perms
+				}
+			}
+		}
+		return "RC4-128", perms
+	case 5:
+		return "AES-256", perms
+	}
+	return fmt.Sprintf("unknown(V=%d)", v), perms
+}
+
+func (s *fileStatus) String() string {
+	var parts []string
+	if !s.encrypted {
+		parts = append(parts, "未加密")
+	} else {
+		parts = append(parts, fmt.Sprintf("已加密(%s)", s.method))
+		if s.noUser {
+			parts = append(parts, "不需要密码就能打开")
+		}
+		var allowed []string
+		if s.perms.Allowed(unisecurity.PermPrinting) {
+			allowed = append(allowed, "打印")
+		}
+		if s.perms.Allowed(unisecurity.PermExtractGraphics) {
+			allowed = append(allowed, "复制")
+		}
+		if len(allowed) == 0 {
+			allowed = append(allowed, "无")
+		}
+		parts = append(parts, "权限:"+strings.Join(allowed, "/"))
+		if s.owner {
+			parts = append(parts, "已使用owner密码打开")
+		}
+	}
+	if s.err != nil {
+		parts = append(parts, fmt.Sprintf("失败:%v", s.err))
+	}
+	return fmt.Sprintf("file:%s, %s", s.file, strings.Join(parts, ", "))
+}
@@ -0,0 +1,67 @@
+package pdfsecure
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"invtools/pkg/internal/testpdf"
+	"invtools/pkg/util"
+)
+
+func TestSecurer_process(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pdfsecure_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer util.SetPasswordList(nil)
+
+	plain := path.Join(dir, "voucher.pdf")
+	testpdf.Write(t, plain, "voucher")
+
+	// 加密
+	encrypted := path.Join(dir, "encrypted.pdf")
+	opts := &Options{UserPassword: "user", OwnerPassword: "owner", AllowPrint: true}
+	status := NewPdfSecurer(plain, dir, ActionEncrypt, opts, 1).process(plain, encrypted)
+	if status.err != nil || status.encrypted {
+		t.Fatalf("encrypt status = %s", status)
+	}
+
+	// 没有密码时无法打开
+	status = NewPdfSecurer(encrypted, dir, ActionStatus, &Options{}, 1).process(encrypted, "")
+	if status.err == nil || !status.encrypted || status.method != "AES-256" {
+		t.Errorf("status without password = %s, want AES-256 and error", status)
+	}
+
+	// 使用csv中的user密码
+	s := NewPdfSecurer(encrypted, dir, ActionStatus, &Options{}, 1)
+	s.filePasswords = map[string]string{"encrypted.pdf": "user"}
+	status = s.process(encrypted, "")
+	if status.err != nil || status.owner || status.noUser {
+		t.Errorf("status with user password = %s", status)
+	}
+	if !strings.Contains(status.String(), "权限:打印") || strings.Contains(status.String(), "复制") {
+		t.Errorf("status with user password = %s, want print only", status)
+	}
+
+	// 使用密码列表中的owner密码解密
+	util.SetPasswordList([]string{"wrong", "owner"})
+	decrypted := path.Join(dir, "decrypted.pdf")
+	status = NewPdfSecurer(encrypted, dir, ActionDecrypt, &Options{}, 1).process(encrypted, decrypted)
+	if status.err != nil || !status.owner {
+		t.Fatalf("decrypt status = %s", status)
+	}
+
+	util.SetPasswordList(nil)
+	status = NewPdfSecurer(decrypted, dir, ActionStatus, &Options{}, 1).process(decrypted, "")
+	if status.err != nil || status.encrypted {
+		t.Errorf("decrypted status = %s, want not encrypted", status)
+	}
+	text, err := util.NewUniPdf().ExtractText(decrypted, "", nil)
+	if err != nil || !strings.Contains(text, "voucher") {
+		t.Errorf("decrypted text = %q, err = %v", text, err)
+	}
+}
@@ -19,45 +19,41 @@ import (
 	"github.com/gosuri/uiprogress"
 	rscPdf "github.com/rsc.io/pdf"
 	"github.com/skratchdot/open-golang/open"
-	"github.com/unidoc/unidoc/common/license"
-	pdf "github.com/unidoc/unidoc/pdf/model"
+	unipdf "github.com/unidoc/unipdf/v3/model"
 )
 
 const (
 	cmdName = "pdfsplit"
+
+	// maxCodeNameLength 按识别内容命名时文件名的最大字节数
+	maxCodeNameLength = 200
 )
 
 type Splitter struct {
 	input, output, password string
-	perPage, concurrency    int
-}
-
-func init() {
-	const unidocLicenseKey = `
------BEGIN UNIDOC LICENSE KEY-----
-eyJsaWNlbnNlX2lkIjoiYjIxYTQzOWQtM2NmYS00NmVjLTRjZmUtYTQ1NzkwMjY2NDEwIiwiY3VzdG9tZXJfaWQiOiIxM2VmZDM1MS1mYmQxLTRlNDctNzUzZS1jMzZlZWEzNzVlYWQiLCJjdXN0b21lcl9uYW1lIjoiS2xvb2sgVHJhdiIsImN1c3RvbWVyX2VtYWlsIjoiaXRAa2xvb2suY29tIiwidGllciI6ImJ1c2luZXNzIiwiY3JlYXRlZF9hdCI6MTU0NTk4OTA0MSwiZXhwaXJlc19hdCI6MCwiY3JlYXRvcl9uYW1lIjoiVW5pRG9jIFN1cHBvcnQiLCJjcmVhdG9yX2VtYWlsIjoic3VwcG9ydEB1bmlkb2MuaW8ifQ==
-+
-PcQ6I/T42jVYx+3wIRqLw1sbj4sXIhTnZ/plDfNy1Njyp+6Cw4ria/K5NW0qKJHQeKBN3yJbK2siHFHiOnmtVkx74SI193YgnUKSwDkPncCkLMr4cdko64rb4PG7ClrU85dTCcqw5KVrlod+pVtIjQhLYfkmCBnJ3geGKBAN7qkT+ImktL94p9iS/gRqi3Dj02YLIuBKyHoDJBWKvc2Ae3duSvyLWt1LckMT1qfOfyWH24D6KCdPodE/YzEyaYKkkatpMvznfWhqQmkbNZeMqMuLkmjNMgBElF+6hxKbzS6NsY0HNsm2jM4h6iclN7E5zMDnHS5hOpPPjEHE4fQiBg==
------END UNIDOC LICENSE KEY-----`
+	spec                    *SplitSpec
+	concurrency             int
 
-	err := license.SetLicenseKey(unidocLicenseKey)
-	if err != nil {
-		fmt.Println("PDF Error loading license, err:", err)
-		os.Exit(1)
-	}
+	// 按二维码/条形码命名时已经使用的文件名, 多个文件并发拆分到同一个目录
+	names *util.NameAllocator
 }
 
-func NewPdfSplitter(input, output, password string, perPage, concurrency int) *Splitter {
+func NewPdfSplitter(input, output, password string, spec *SplitSpec, concurrency int) *Splitter {
 	return &Splitter{
 		input:       input,
 		output:      output,
 		password:    password,
-		perPage:     perPage,
+		spec:        spec,
 		concurrency: concurrency,
+		names:       util.NewNameAllocator(),
 	}
 }
 
 func (s *Splitter) validate() error {
+	if err := s.spec.Validate(); err != nil {
+		return err
+	}
+
 	f, err := os.Open(s.input)
 	if err != nil {
 		return errors.Errorf(err, "open input directory failed")
@@ -157,27 +153,15 @@ func (s *Splitter) split(filePath string) error {
 	}
 	defer f.Close()
 
-	pdfReader, err := pdf.NewPdfReader(f)
+	pdfReader, err := unipdf.NewPdfReader(f)
 	if err != nil {
 		return err
 	}
 
-	isEncrypted, err := pdfReader.IsEncrypted()
-	if err != nil {
+	if _, err := util.DecryptPdfReader(pdfReader, s.password); err != nil {
 		return err
 	}
 
-	if isEncrypted {
-		if s.password == "" {
-			_, err = pdfReader.Decrypt([]byte(""))
-		} else {
-			_, err = pdfReader.Decrypt([]byte(s.password))
-		}
-		if err != nil {
-			return errors.Errorf(err, "解密失败")
-		}
-	}
-
 	fi, err := f.Stat()
 	if err != nil {
 		return errors.Errorf(err, "get file stat failed")
@@ -203,17 +187,14 @@ func (s *Splitter) split(filePath string) error {
 		numPages = rscNumPages
 	}
 
-	if numPages%s.perPage != 0 {
-		return errors.Errorf(nil, "文件只有%d页，无法按照平均每%d页进行拆分,file:%s", numPages)
+	segments, err := s.spec.segments(filePath, s.password, numPages)
+	if err != nil {
+		return err
 	}
 
-	n := 0
-	for i := 1; i <= numPages; i += s.perPage {
-		pageFrom, pageTo := i, i-1+s.perPage
-
-		pdfWriter := pdf.NewPdfWriter()
-		n++
-		for i := pageFrom; i <= pageTo; i++ {
+	for n, seg := range segments {
+		pdfWriter := unipdf.NewPdfWriter()
+		for i := seg.from; i <= seg.to; i++ {
 			pageNum := i
 
 			page, err := pdfReader.GetPage(pageNum)
@@ -226,7 +207,11 @@ func (s *Splitter) split(filePath string) error {
 				return err
 			}
 		}
-		fWrite, err := os.Create(getSubFileName(s.output, filePath, n))
+		subFileName := getSubFileName(s.output, filePath, n+1, seg.title)
+		if name := s.codeFileName(seg.name); name != "" {
+			subFileName = name
+		}
+		fWrite, err := os.Create(subFileName)
 		if err != nil {
 			return err
 		}
@@ -242,9 +227,27 @@ func (s *Splitter) split(filePath string) error {
 	return nil
 }
 
-func getSubFileName(dir, filePath string, n int) string {
+// getSubFileName 拆分后的文件名为 原文件名_序号.pdf, 有书签标题时为 原文件名_序号_标题.pdf
+func getSubFileName(dir, filePath string, n int, title string) string {
 	fileName := path.Base(filePath)
 	ext := path.Ext(fileName)
 	name := strings.TrimSuffix(fileName, ext)
+	if title = util.SanitizeFileName(title); title != "" {
+		return fmt.Sprintf("%s/%s_%d_%s%s", dir, name, n, title, ext)
+	}
 	return fmt.Sprintf("%s/%s_%d%s", dir, name, n, ext)
 }
+
+// codeFileName 使用识别到的内容作为文件名, 重名时依次添加_2, _3后缀; 内容为空时返回空
+func (s *Splitter) codeFileName(value string) string {
+	name := util.SanitizeFileName(value)
+	if name == "" {
+		return ""
+	}
+	// 文件名长度有限制, 内容过长时(例如整个url)使用md5
+	if len(name) > maxCodeNameLength {
+		name = util.ComputeMd5String(name)
+	}
+
+	return path.Join(s.output, s.names.Allocate(name, common.ExtPDF))
+}
@@ -0,0 +1,49 @@
+package pdfsplit
+
+import (
+	"strings"
+	"testing"
+
+	"invtools/pkg/util"
+)
+
+func Test_getSubFileName(t *testing.T) {
+	tests := []struct {
+		name  string
+		n     int
+		title string
+		want  string
+	}{
+		{name: "no title", n: 1, want: "/out/voucher_1.pdf"},
+		{name: "title", n: 2, title: "Day 1: Tokyo/Osaka", want: "/out/voucher_2_Day 1_ Tokyo_Osaka.pdf"},
+		{name: "blank title", n: 3, title: " . ", want: "/out/voucher_3.pdf"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getSubFileName("/out", "/in/voucher.pdf", tt.n, tt.title); got != tt.want {
+				t.Errorf("getSubFileName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitter_codeFileName(t *testing.T) {
+	s := NewPdfSplitter("/in", "/out", "", &SplitSpec{Mode: ModeCode}, 1)
+	long := strings.Repeat("x", maxCodeNameLength+1)
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "ABC123", want: "/out/ABC123.pdf"},
+		{value: "abc123", want: "/out/abc123_2.pdf"},
+		{value: "ABC123", want: "/out/ABC123_3.pdf"},
+		{value: "a/b", want: "/out/a_b.pdf"},
+		{value: "  ", want: ""},
+		{value: long, want: "/out/" + util.ComputeMd5String(long) + ".pdf"},
+	}
+	for _, tt := range tests {
+		if got := s.codeFileName(tt.value); got != tt.want {
+			t.Errorf("codeFileName(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package pdfsplit
+
+import (
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+
+	"invtools/common"
+	"invtools/pkg/util"
+	"invtools/pkg/util/xpdf"
+	"invtools/utils"
+	"invtools/utils/errors"
+)
+
+const (
+	// ModePerPage 每N页拆分一次, 最后不足N页的部分单独一个文件
+	ModePerPage = "perpage"
+	// ModeRanges 按页码范围拆分, 例如 1-3,4,5-end, 每一段一个文件
+	ModeRanges = "ranges"
+	// ModeBookmark 按书签拆分, 每个书签到下一个书签之前的页面为一个文件
+	ModeBookmark = "bookmark"
+	// ModeBlank 以空白页为分隔拆分, 空白页不写入拆分后的文件
+	ModeBlank = "blank"
+	// ModeRegexp 页面文字匹配正则时开始一个新文件, 例如每张凭证的第一页都有 Booking Reference
+	ModeRegexp = "regexp"
+	// ModeCode 识别每一页的二维码/条形码, 连续的相同内容的页面为一个文件, 文件名取识别到的内容
+	ModeCode = "code"
+)
+
+// SplitSpec 拆分规则
+type SplitSpec struct {
+	Mode    string
+	PerPage int            // perpage: 每N页拆分一次
+	Ranges  string         // ranges: 页码范围, end表示最后一页
+	Level   int            // bookmark: 按第几级书签拆分, 默认1
+	Pattern *regexp.Regexp // regexp: 开始新文件的页面需要匹配的正则
+
+	CodeType    string         // code: qrcode/barcode128, 默认qrcode
+	CodePattern *regexp.Regexp // code: 从识别到的内容中提取文件名, 有分组时取第一个分组, 为空时使用全部内容
+}
+
+// Validate 校验拆分规则, Mode为空时按每N页拆分
+func (s *SplitSpec) Validate() error {
+	switch s.Mode {
+	case "":
+		s.Mode = ModePerPage
+	case ModePerPage, ModeRanges, ModeBookmark, ModeBlank, ModeRegexp, ModeCode:
+	default:
+		return errors.Errorf(nil, "不支持的拆分方式:%s, 支持: perpage/ranges/bookmark/blank/regexp/code", s.Mode)
+	}
+
+	switch s.Mode {
+	case ModePerPage:
+		if s.PerPage < 1 {
+			return errors.Errorf(nil, "每次拆分的页数必须大于0")
+		}
+	case ModeRanges:
+		if strings.TrimSpace(s.Ranges) == "" {
+			return errors.Errorf(nil, "按页码范围拆分时需要指定页码范围")
+		}
+		// 只校验格式, 页码是否超出总页数在拆分每个文件时校验
+		if _, err := rangeSegments(s.Ranges, 0); err != nil {
+			return err
+		}
+	case ModeBookmark:
+		if s.Level == 0 {
+			s.Level = 1
+		}
+		if s.Level < 0 {
+			return errors.Errorf(nil, "书签级别必须大于0")
+		}
+	case ModeRegexp:
+		if s.Pattern == nil {
+			return errors.Errorf(nil, "按正则拆分时需要指定正则")
+		}
+	case ModeCode:
+		switch s.CodeType {
+		case "":
+			s.CodeType = common.CodeTypeQRCode
+		case common.CodeTypeQRCode, common.CodeTypeBarcode128:
+		default:
+			return errors.Errorf(nil, "不支持的code类型:%s, 支持: qrcode/barcode128", s.CodeType)
+		}
+	}
+	return nil
+}
+
+// segment 拆分后的一个文件包含的页面, 页码从1开始, 包含from和to
+type segment struct {
+	from, to int
+	title    string // 按书签拆分时为书签标题, 用于文件名
+	name     string // 按二维码/条形码拆分时识别到的内容, 不为空时直接作为文件名
+}
+
+// segments 根据拆分规则计算每个拆分文件的页码范围
+func (s *SplitSpec) segments(filePath, password string, numPages int) ([]segment, error) {
+	switch s.Mode {
+	case ModeRanges:
+		return rangeSegments(s.Ranges, numPages)
+	case ModeBookmark:
+		bookmarks, err := util.NewUniPdf().Bookmarks(filePath, password)
+		if err != nil {
+			return nil, errors.Errorf(err, "读取书签失败")
+		}
+		segs := bookmarkSegments(bookmarks, s.Level, numPages)
+		if len(segs) == 0 {
+			return nil, errors.Errorf(nil, "没有找到第%d级书签", s.Level)
+		}
+		return segs, nil
+	case ModeBlank, ModeRegexp:
+		contents, err := util.NewUniPdf().PageContents(filePath, password)
+		if err != nil {
+			return nil, errors.Errorf(err, "提取页面文字失败")
+		}
+		if len(contents) != numPages {
+			return nil, errors.Errorf(nil, "提取文字的页数(%d)与总页数(%d)不一致", len(contents), numPages)
+		}
+		if s.Mode == ModeBlank {
+			return blankSegments(contents), nil
+		}
+		return patternSegments(contents, s.Pattern), nil
+	case ModeCode:
+		codes, err := scanPageCodes(filePath, password, s.CodeType, numPages)
+		if err != nil {
+			return nil, err
+		}
+		values := make([]string, len(codes))
+		for i, code := range codes {
+			values[i] = codeValue(code, s.CodePattern)
+		}
+		return codeSegments(values), nil
+	}
+	return perPageSegments(numPages, s.PerPage), nil
+}
+
+// perPageSegments 每perPage页一个文件, 最后不足perPage页的部分单独一个文件
+func perPageSegments(numPages, perPage int) []segment {
+	var segs []segment
+	for i := 1; i <= numPages; i += perPage {
+		to := i - 1 + perPage
+		if to > numPages {
+			to = numPages
+		}
+		segs = append(segs, segment{from: i, to: to})
+	}
+	return segs
+}
+
+// rangeSegments 逗号分隔的每一段为一个文件, 例如 1-3,4,5-end; numPages为0时只校验格式
+func rangeSegments(ranges string, numPages int) ([]segment, error) {
+	var segs []segment
+	for _, part := range strings.Split(ranges, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		// numPages为0时"5-end"替换为"5-", ParsePageRanges只校验格式
+		pages, err := util.ParsePageRanges(util.ReplacePageRangeEnd(part, numPages), numPages)
+		if err != nil {
+			return nil, errors.Errorf(err, "页码范围不合法:%s", part)
+		}
+		segs = append(segs, segment{from: pages[0], to: pages[len(pages)-1]})
+	}
+	if len(segs) == 0 {
+		return nil, errors.Errorf(nil, "页码范围为空")
+	}
+	return segs, nil
+}
+
+// bookmarkSegments 指定级别的书签按页码排序, 每个书签到下一个书签之前的页面为一个文件;
+// 第一个书签之前的页面(例如封面)单独一个文件, 多个书签指向同一页时使用第一个书签的标题
+func bookmarkSegments(bookmarks []util.Bookmark, level, numPages int) []segment {
+	var starts []util.Bookmark
+	for _, b := range bookmarks {
+		if b.Level == level && b.Page >= 1 && b.Page <= numPages {
+			starts = append(starts, b)
+		}
+	}
+	if len(starts) == 0 {
+		return nil
+	}
+	sort.SliceStable(starts, func(i, j int) bool {
+		return starts[i].Page < starts[j].Page
+	})
+
+	var segs []segment
+	if starts[0].Page > 1 {
+		segs = append(segs, segment{from: 1, to: starts[0].Page - 1})
+	}
+	for i, b := range starts {
+		if i > 0 && b.Page == starts[i-1].Page {
+			continue
+		}
+		to := numPages
+		for _, next := range starts[i+1:] {
+			if next.Page > b.Page {
+				to = next.Page - 1
+				break
+			}
+		}
+		segs = append(segs, segment{from: b.Page, to: to, title: b.Title})
+	}
+	return segs
+}
+
+// blankSegments 没有文字也没有图片的页面为分隔页, 分隔页之间的页面为一个文件, 连续的分隔页不会产生空文件
+func blankSegments(contents []util.PageContent) []segment {
+	var (
+		segs []segment
+		from int
+	)
+	for i, c := range contents {
+		page := i + 1
+		if c.IsBlank() {
+			if from > 0 {
+				segs = append(segs, segment{from: from, to: page - 1})
+				from = 0
+			}
+			continue
+		}
+		if from == 0 {
+			from = page
+		}
+	}
+	if from > 0 {
+		segs = append(segs, segment{from: from, to: len(contents)})
+	}
+	return segs
+}
+
+// patternSegments 页面文字匹配正则时开始一个新文件, 第一个匹配页之前的页面单独一个文件
+func patternSegments(contents []util.PageContent, pattern *regexp.Regexp) []segment {
+	var segs []segment
+	for i, c := range contents {
+		page := i + 1
+		if page == 1 || pattern.MatchString(c.Text) {
+			if len(segs) > 0 {
+				segs[len(segs)-1].to = page - 1
+			}
+			segs = append(segs, segment{from: page})
+		}
+	}
+	if len(segs) > 0 {
+		segs[len(segs)-1].to = len(contents)
+	}
+	return segs
+}
+
+// scanPageCodes 先扫描页面中嵌入的图片, 没有识别到的页面再用pdftopng转成图片后扫描(矢量绘制的码)
+func scanPageCodes(filePath, password, codeType string, numPages int) ([]string, error) {
+	codes, err := util.NewUniPdf().ScanPageCodes(filePath, password, codeType)
+	if err != nil {
+		return nil, errors.Errorf(err, "扫描页面图片失败")
+	}
+	if len(codes) != numPages {
+		return nil, errors.Errorf(nil, "扫描的页数(%d)与总页数(%d)不一致", len(codes), numPages)
+	}
+
+	var missing bool
+	for _, code := range codes {
+		if code == "" {
+			missing = true
+			break
+		}
+	}
+	if !missing {
+		return codes, nil
+	}
+
+	tmpDir, err := ioutil.TempDir("", "pdfsplit_png_")
+	if err != nil {
+		return nil, errors.Errorf(err, "创建临时目录失败")
+	}
+	defer utils.RmAll(tmpDir)
+
+	pngFiles, err := xpdf.PdfToPngV2(filePath, tmpDir, utils.GetRequestID())
+	if err != nil {
+		// 没有安装pdftopng时只使用嵌入图片的识别结果
+		return codes, nil
+	}
+	for i, f := range pngFiles {
+		if i >= len(codes) || codes[i] != "" {
+			continue
+		}
+		if codeType == common.CodeTypeBarcode128 {
+			codes[i], _ = util.Barcode128Scan(f)
+		} else {
+			codes[i], _ = util.QrCodeScan(f)
+		}
+	}
+	return codes, nil
+}
+
+// codeValue 按正则从识别到的内容中提取文件名, 有分组时取第一个分组; 没有匹配时为空
+func codeValue(code string, pattern *regexp.Regexp) string {
+	code = strings.TrimSpace(code)
+	if code == "" || pattern == nil {
+		return code
+	}
+
+	m := pattern.FindStringSubmatch(code)
+	if m == nil {
+		return ""
+	}
+	if len(m) > 1 {
+		return strings.TrimSpace(m[1])
+	}
+	return strings.TrimSpace(m[0])
+}
+
+// codeSegments 连续的相同内容的页面为一个文件; 没有识别到内容的页面(例如凭证的背面)属于前一个文件,
+// 第一个识别到内容的页面之前的页面单独一个文件
+func codeSegments(values []string) []segment {
+	var segs []segment
+	for i, v := range values {
+		page := i + 1
+		if len(segs) > 0 && (v == "" || v == segs[len(segs)-1].name) {
+			segs[len(segs)-1].to = page
+			continue
+		}
+		segs = append(segs, segment{from: page, to: page, name: v})
+	}
+	return segs
+}
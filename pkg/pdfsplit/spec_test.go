@@ -0,0 +1,222 @@
+package pdfsplit
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+
+	"invtools/pkg/util"
+)
+
+func Test_perPageSegments(t *testing.T) {
+	tests := []struct {
+		name     string
+		numPages int
+		perPage  int
+		want     []segment
+	}{
+		{name: "even", numPages: 4, perPage: 2, want: []segment{{from: 1, to: 2}, {from: 3, to: 4}}},
+		{name: "remainder", numPages: 5, perPage: 2, want: []segment{{from: 1, to: 2}, {from: 3, to: 4}, {from: 5, to: 5}}},
+		{name: "larger than file", numPages: 3, perPage: 5, want: []segment{{from: 1, to: 3}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := perPageSegments(tt.numPages, tt.perPage); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("perPageSegments() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_rangeSegments(t *testing.T) {
+	tests := []struct {
+		name     string
+		ranges   string
+		numPages int
+		want     []segment
+		wantErr  bool
+	}{
+		{name: "with end", ranges: "1-3,4,5-end", numPages: 8, want: []segment{{from: 1, to: 3}, {from: 4, to: 4}, {from: 5, to: 8}}},
+		{name: "open end", ranges: "2-", numPages: 3, want: []segment{{from: 2, to: 3}}},
+		{name: "overlap", ranges: "1-2, 2-3", numPages: 3, want: []segment{{from: 1, to: 2}, {from: 2, to: 3}}},
+		{name: "syntax only", ranges: "1-3,4-END", numPages: 0, want: []segment{{from: 1, to: 3}, {from: 4, to: 4}}},
+		{name: "out of range", ranges: "1-3,4", numPages: 3, wantErr: true},
+		{name: "reversed", ranges: "3-1", numPages: 3, wantErr: true},
+		{name: "not number", ranges: "a-end", numPages: 3, wantErr: true},
+		{name: "empty", ranges: " , ", numPages: 3, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rangeSegments(tt.ranges, tt.numPages)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("rangeSegments() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rangeSegments() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_bookmarkSegments(t *testing.T) {
+	bookmarks := []util.Bookmark{
+		{Title: "Voucher A", Page: 2, Level: 1},
+		{Title: "Details", Page: 3, Level: 2},
+		{Title: "Voucher C", Page: 6, Level: 1},
+		{Title: "Voucher B", Page: 4, Level: 1},
+		{Title: "Same page", Page: 4, Level: 1},
+		{Title: "Broken", Page: 0, Level: 1},
+	}
+	tests := []struct {
+		name  string
+		level int
+		want  []segment
+	}{
+		{
+			name:  "top level",
+			level: 1,
+			want: []segment{
+				{from: 1, to: 1},
+				{from: 2, to: 3, title: "Voucher A"},
+				{from: 4, to: 5, title: "Voucher B"},
+				{from: 6, to: 7, title: "Voucher C"},
+			},
+		},
+		{
+			name:  "second level",
+			level: 2,
+			want:  []segment{{from: 1, to: 2}, {from: 3, to: 7, title: "Details"}},
+		},
+		{name: "missing level", level: 3, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := bookmarkSegments(bookmarks, tt.level, 7); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("bookmarkSegments() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_blankSegments(t *testing.T) {
+	text := util.PageContent{Text: "voucher"}
+	image := util.PageContent{HasImage: true}
+	blank := util.PageContent{Text: " \n"}
+	tests := []struct {
+		name     string
+		contents []util.PageContent
+		want     []segment
+	}{
+		{name: "separated", contents: []util.PageContent{text, text, blank, image, blank, text}, want: []segment{{from: 1, to: 2}, {from: 4, to: 4}, {from: 6, to: 6}}},
+		{name: "leading and repeated blanks", contents: []util.PageContent{blank, text, blank, blank, text, blank}, want: []segment{{from: 2, to: 2}, {from: 5, to: 5}}},
+		{name: "no blank", contents: []util.PageContent{text, image}, want: []segment{{from: 1, to: 2}}},
+		{name: "all blank", contents: []util.PageContent{blank, blank}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := blankSegments(tt.contents); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("blankSegments() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_patternSegments(t *testing.T) {
+	re := regexp.MustCompile(`Booking Reference`)
+	start := util.PageContent{Text: "Booking Reference: ABC123"}
+	other := util.PageContent{Text: "Terms and conditions"}
+	tests := []struct {
+		name     string
+		contents []util.PageContent
+		want     []segment
+	}{
+		{name: "vouchers", contents: []util.PageContent{start, other, start, start, other}, want: []segment{{from: 1, to: 2}, {from: 3, to: 3}, {from: 4, to: 5}}},
+		{name: "leading pages", contents: []util.PageContent{other, other, start}, want: []segment{{from: 1, to: 2}, {from: 3, to: 3}}},
+		{name: "no match", contents: []util.PageContent{other, other}, want: []segment{{from: 1, to: 2}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := patternSegments(tt.contents, re); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("patternSegments() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitSpec_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    SplitSpec
+		wantErr bool
+	}{
+		{name: "default mode", spec: SplitSpec{PerPage: 1}},
+		{name: "zero per page", spec: SplitSpec{Mode: ModePerPage}, wantErr: true},
+		{name: "ranges", spec: SplitSpec{Mode: ModeRanges, Ranges: "1-3,4,5-end"}},
+		{name: "bad ranges", spec: SplitSpec{Mode: ModeRanges, Ranges: "1-x"}, wantErr: true},
+		{name: "missing ranges", spec: SplitSpec{Mode: ModeRanges}, wantErr: true},
+		{name: "bookmark", spec: SplitSpec{Mode: ModeBookmark}},
+		{name: "blank", spec: SplitSpec{Mode: ModeBlank}},
+		{name: "missing pattern", spec: SplitSpec{Mode: ModeRegexp}, wantErr: true},
+		{name: "unknown mode", spec: SplitSpec{Mode: "size"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.spec.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_codeValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    string
+		pattern *regexp.Regexp
+		want    string
+	}{
+		{name: "whole code", code: " ABC123 ", want: "ABC123"},
+		{name: "group", code: "https://x.com/v?guest=Tom01&id=9", pattern: regexp.MustCompile(`guest=(\w+)`), want: "Tom01"},
+		{name: "whole match", code: "REF-2020-0001", pattern: regexp.MustCompile(`\d{4}-\d{4}`), want: "2020-0001"},
+		{name: "no match", code: "ABC", pattern: regexp.MustCompile(`\d+`), want: ""},
+		{name: "empty", code: "", pattern: regexp.MustCompile(`.*`), want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := codeValue(tt.code, tt.pattern); got != tt.want {
+				t.Errorf("codeValue() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_codeSegments(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   []segment
+	}{
+		{
+			name:   "consecutive pages",
+			values: []string{"A", "A", "B", "C", "C"},
+			want:   []segment{{from: 1, to: 2, name: "A"}, {from: 3, to: 3, name: "B"}, {from: 4, to: 5, name: "C"}},
+		},
+		{
+			name:   "pages without code",
+			values: []string{"", "", "A", "", "B", ""},
+			want:   []segment{{from: 1, to: 2}, {from: 3, to: 4, name: "A"}, {from: 5, to: 6, name: "B"}},
+		},
+		{
+			name:   "same code again",
+			values: []string{"A", "B", "A"},
+			want:   []segment{{from: 1, to: 1, name: "A"}, {from: 2, to: 2, name: "B"}, {from: 3, to: 3, name: "A"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := codeSegments(tt.values); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("codeSegments() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
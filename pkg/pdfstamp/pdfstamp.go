@@ -0,0 +1,295 @@
+package pdfstamp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"invtools/common"
+	"invtools/pkg/util"
+	"invtools/utils"
+	"invtools/utils/errors"
+
+	"github.com/gosuri/uiprogress"
+	"github.com/skratchdot/open-golang/open"
+	unipdf "github.com/unidoc/unipdf/v3/model"
+)
+
+const (
+	cmdName = "pdfstamp"
+
+	// FieldFile 模板中可以使用的文件名(不带后缀), 同时也是csv中用来匹配文件的列
+	FieldFile = "file"
+)
+
+type Stamper struct {
+	input, output, password string
+	stamp                   *Stamp
+	valuesCsv               string
+	concurrency             int
+
+	// rows valuesCsv中每个文件的一行, key为file列的值
+	rows map[string]map[string]string
+}
+
+// NewPdfStamper input为pdf文件或者目录, 盖章后的文件以原文件名保存在output目录下;
+// valuesCsv不为空时按file列匹配文件, 其他列可以在stamp的模板中引用, csv中没有的文件不处理
+func NewPdfStamper(input, output, password string, stamp *Stamp, valuesCsv string, concurrency int) *Stamper {
+	return &Stamper{
+		input:       input,
+		output:      output,
+		password:    password,
+		stamp:       stamp,
+		valuesCsv:   valuesCsv,
+		concurrency: concurrency,
+	}
+}
+
+func (s *Stamper) validate() error {
+	if err := s.stamp.Validate(); err != nil {
+		return err
+	}
+
+	if !utils.CheckFileIsExist(s.input) {
+		return errors.Errorf(nil, "输入路径不存在:%s", s.input)
+	}
+	if path.Clean(s.input) == path.Clean(s.output) {
+		return errors.Errorf(nil, "输出目录不能与输入目录相同")
+	}
+
+	if s.valuesCsv != "" {
+		rows, err := loadValues(s.valuesCsv)
+		if err != nil {
+			return err
+		}
+		s.rows = rows
+	}
+
+	if err := utils.CheckAndMkDir(s.output); err != nil {
+		return errors.Errorf(err, "创建目录失败,目录:%s", s.output)
+	}
+	return nil
+}
+
+func (s *Stamper) Do() error {
+	if err := s.validate(); err != nil {
+		return err
+	}
+
+	return s.execute()
+}
+
+func (s *Stamper) execute() error {
+	files := []string{s.input}
+	if utils.CheckDirIsExist(s.input) {
+		var err error
+		if files, err = util.ReadDirFiles(s.input, common.ExtPDF); err != nil {
+			return errors.Errorf(err, "读取input路径下的pdf文件失败")
+		}
+	}
+
+	fmt.Printf("[%s] 扫描路径后一共得到%d个文件,即将开始盖章...\n", cmdName, len(files))
+
+	groups := util.DivideSliceIntoGroup(files, s.concurrency)
+
+	uiprogress.Start()
+	var (
+		wg           sync.WaitGroup
+		mu           sync.Mutex
+		st           = time.Now()
+		successFiles []string
+		skippedFiles []string
+		failedFiles  []string
+	)
+
+	wg.Add(len(groups))
+	for _, value := range groups {
+		ctx := context.Background()
+		grp := value
+		cnt := len(grp)
+		go utils.HandlePanicV2(ctx, func(i interface{}) {
+			grp := *i.(*[]string)
+			defer wg.Done()
+
+			bar := uiprogress.AddBar(cnt).AppendCompleted().PrependElapsed()
+			bar.PrependFunc(func(b *uiprogress.Bar) string {
+				return fmt.Sprintf("processing: %d/%d", b.Current(), cnt)
+			})
+
+			for bar.Incr() {
+				f := grp[bar.Current()-1]
+				fields, ok := s.fields(f)
+				if !ok {
+					mu.Lock()
+					skippedFiles = append(skippedFiles, f)
+					mu.Unlock()
+					continue
+				}
+
+				err := s.process(f, path.Join(s.output, path.Base(f)), fields)
+				mu.Lock()
+				if err != nil {
+					failedFiles = append(failedFiles, fmt.Sprintf("file:%s, err:%v", f, err))
+				} else {
+					successFiles = append(successFiles, f)
+				}
+				mu.Unlock()
+			}
+		})(&grp)
+	}
+
+	wg.Wait()
+	uiprogress.Stop()
+
+	fmt.Printf("[%s] 本次盖章操作, 一共成功%d个pdf, 失败%d个, 跳过%d个, 总耗时:%s\n", cmdName, len(successFiles), len(failedFiles), len(skippedFiles), time.Since(st))
+	fmt.Printf("[%s] 盖章后的文件保存目录是: %s\n", cmdName, s.output)
+	if len(skippedFiles) > 0 {
+		fmt.Printf("[%s] 以下文件在csv中没有对应的行, 没有处理:%s\n", cmdName, skippedFiles)
+	}
+	if len(failedFiles) > 0 {
+		fmt.Printf("失败文件:%s", failedFiles)
+	}
+
+	open.Run(s.output)
+
+	return nil
+}
+
+// fields 模板中可以使用的字段: csv中该文件的一行以及file(不带后缀的文件名); 有csv但是没有该文件时返回false
+func (s *Stamper) fields(filePath string) (map[string]string, bool) {
+	name := util.GetPureFileName(filePath)
+	fields := map[string]string{FieldFile: name}
+	if s.rows == nil {
+		return fields, true
+	}
+
+	row, ok := s.rows[filePath]
+	if !ok {
+		row, ok = s.rows[path.Base(filePath)]
+	}
+	if !ok {
+		// csv中的文件名可以不带.pdf后缀
+		row, ok = s.rows[name]
+	}
+	if !ok {
+		return nil, false
+	}
+	for k, v := range row {
+		fields[k] = v
+	}
+	fields[FieldFile] = name
+	return fields, true
+}
+
+// process 在filePath选中的页面上盖章后写入output
+func (s *Stamper) process(filePath, output string, fields map[string]string) error {
+	value, err := s.stamp.value(fields)
+	if err != nil {
+		return err
+	}
+	m, err := s.stamp.newMark(value)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return errors.Errorf(err, "open file failed, file:%s", filePath)
+	}
+	// 写入完成前保持文件打开, 页面中的对象可能在写入时才读取
+	defer f.Close()
+
+	pdfReader, err := util.NewPdfReader(f, s.password)
+	if err != nil {
+		return errors.Errorf(err, "读取pdf失败")
+	}
+	numPages, err := pdfReader.GetNumPages()
+	if err != nil {
+		return errors.Errorf(err, "获取页数失败")
+	}
+
+	selected := make(map[int]bool)
+	if strings.TrimSpace(s.stamp.Pages) == "" {
+		for i := 1; i <= numPages; i++ {
+			selected[i] = true
+		}
+	} else {
+		pages, err := util.ParsePageRanges(util.ReplacePageRangeEnd(s.stamp.Pages, numPages), numPages)
+		if err != nil {
+			return errors.Errorf(err, "页码范围不合法:%s", s.stamp.Pages)
+		}
+		for _, p := range pages {
+			selected[p] = true
+		}
+	}
+
+	pdfWriter := unipdf.NewPdfWriter()
+	for i := 1; i <= numPages; i++ {
+		page, err := pdfReader.GetPage(i)
+		if err != nil {
+			return errors.Errorf(err, "pdfReader.GetPage 失败, page:%d", i)
+		}
+		if selected[i] {
+			if err := s.stamp.apply(page, m); err != nil {
+				return errors.Errorf(err, "盖章失败, page:%d", i)
+			}
+		}
+		if err := pdfWriter.AddPage(page); err != nil {
+			return errors.Errorf(err, "pdfWriter.AddPage failed, page:%d", i)
+		}
+	}
+
+	fWrite, err := os.Create(output)
+	if err != nil {
+		return errors.Errorf(err, "创建文件失败, file:%s", output)
+	}
+	defer fWrite.Close()
+
+	if err := pdfWriter.Write(fWrite); err != nil {
+		return errors.Errorf(err, "写入pdf失败, file:%s", output)
+	}
+	return nil
+}
+
+// loadValues 读取每个文件的值, 第一行为表头, 需要包含file列, 其他列可以在模板中引用
+func loadValues(file string) (map[string]map[string]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, errors.Errorf(err, "open values csv failed, file:%s", file)
+	}
+	defer f.Close()
+
+	return readValues(f)
+}
+
+func readValues(r io.Reader) (map[string]map[string]string, error) {
+	table, err := util.ReadCsvTable(r, FieldFile)
+	if err != nil {
+		return nil, errors.Errorf(err, "读取values csv失败")
+	}
+
+	values := make(map[string]map[string]string)
+	for i, row := range table.Rows {
+		name := strings.TrimSpace(table.Value(row, FieldFile))
+		if name == "" {
+			continue
+		}
+		if _, ok := values[name]; ok {
+			return nil, errors.Errorf(nil, "csv文件第%d行的文件%s重复", table.Line(i), name)
+		}
+
+		fields := make(map[string]string, len(table.Header))
+		for j, col := range table.Header {
+			if j < len(row) && col != "" {
+				fields[col] = strings.TrimSpace(row[j])
+			}
+		}
+		values[name] = fields
+	}
+	return values, nil
+}
@@ -0,0 +1,162 @@
+package pdfstamp
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+
+	"invtools/common"
+	"invtools/pkg/internal/testpdf"
+	"invtools/pkg/util"
+)
+
+// writeTestPdf 生成A4的pdf, 每个text一页
+func TestStamper_process(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pdfstamp_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	input := path.Join(dir, "voucher.pdf")
+	testpdf.Write(t, input, "page 1", "page 2")
+	fields := map[string]string{FieldFile: "voucher", "order_no": "A001", "url": "https://example.com/v/A001"}
+
+	tests := []struct {
+		name  string
+		stamp Stamp
+		check func(t *testing.T, output string)
+	}{
+		{
+			name:  "text on all pages",
+			stamp: Stamp{Value: "USED", Anchor: AnchorCenter, Rotation: 45, Opacity: 0.4},
+			check: func(t *testing.T, output string) {
+				texts, err := util.NewUniPdf().ExtractTextWithPages(output, "", nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for i, text := range texts {
+					if !strings.Contains(text, "page") || !strings.Contains(text, "USED") {
+						t.Errorf("page %d got text %q", i+1, text)
+					}
+				}
+			},
+		},
+		{
+			name:  "text from csv on selected pages",
+			stamp: Stamp{Value: "NO.{{.order_no}}", Anchor: AnchorTopRight, X: 10, Y: 10, Pages: "end"},
+			check: func(t *testing.T, output string) {
+				texts, err := util.NewUniPdf().ExtractTextWithPages(output, "", nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(texts) != 2 || strings.Contains(texts[0], "A001") || !strings.Contains(texts[1], "NO.A001") {
+					t.Errorf("got texts %q", texts)
+				}
+			},
+		},
+		{
+			name:  "qrcode",
+			stamp: Stamp{Type: TypeQRCode, Value: "{{.url}}", Anchor: AnchorBottomRight, X: 10, Y: 10, Width: 40},
+			check: func(t *testing.T, output string) {
+				codes, err := util.NewUniPdf().ScanPageCodes(output, "", common.CodeTypeQRCode)
+				if err != nil {
+					t.Fatal(err)
+				}
+				want := []string{"https://example.com/v/A001", "https://example.com/v/A001"}
+				if !reflect.DeepEqual(codes, want) {
+					t.Errorf("got codes %q, want %q", codes, want)
+				}
+			},
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stamp := tt.stamp
+			if err := stamp.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			output := path.Join(dir, "stamped_"+string(rune('a'+i))+".pdf")
+			s := NewPdfStamper(input, dir, "", &stamp, "", 1)
+			if err := s.process(input, output, fields); err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, output)
+		})
+	}
+}
+
+func TestStamper_fields(t *testing.T) {
+	rows, err := readValues(strings.NewReader("\ufefffile,order_no\nvoucher.pdf,A001\nother,B002\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Stamper{rows: rows}
+
+	tests := []struct {
+		file   string
+		want   map[string]string
+		wantOk bool
+	}{
+		{"/input/voucher.pdf", map[string]string{FieldFile: "voucher", "order_no": "A001"}, true},
+		{"/input/other.pdf", map[string]string{FieldFile: "other", "order_no": "B002"}, true},
+		{"/input/missing.pdf", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			got, ok := s.fields(tt.file)
+			if ok != tt.wantOk || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fields() got = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+
+	s = &Stamper{}
+	if got, ok := s.fields("/input/voucher.pdf"); !ok || !reflect.DeepEqual(got, map[string]string{FieldFile: "voucher"}) {
+		t.Errorf("fields() without csv got = %v, %v", got, ok)
+	}
+}
+
+func Test_readValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		csv     string
+		want    map[string]map[string]string
+		wantErr bool
+	}{
+		{
+			name: "skip empty file",
+			csv:  "order_no,File\nA001,a.pdf\nB002,\n",
+			want: map[string]map[string]string{"a.pdf": {"order_no": "A001", "File": "a.pdf"}},
+		},
+		{
+			name:    "no file column",
+			csv:     "name,order_no\na,A001\n",
+			wantErr: true,
+		},
+		{
+			name:    "duplicate file",
+			csv:     "file,order_no\na,A001\na,A002\n",
+			wantErr: true,
+		},
+		{
+			name:    "empty",
+			csv:     "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readValues(strings.NewReader(tt.csv))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readValues() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readValues() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
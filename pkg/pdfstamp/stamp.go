@@ -0,0 +1,386 @@
+package pdfstamp
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"math"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"invtools/common"
+	"invtools/pkg/util"
+	"invtools/utils/errors"
+
+	unicontentstream "github.com/unidoc/unipdf/v3/contentstream"
+	unicore "github.com/unidoc/unipdf/v3/core"
+	unipdf "github.com/unidoc/unipdf/v3/model"
+)
+
+const (
+	// TypeText 文字, 使用标准字体Helvetica-Bold, 只支持拉丁字符, 中文需要先做成图片
+	TypeText = "text"
+	// TypeImage 图片文件(png/jpg), Value为图片路径
+	TypeImage = "image"
+	// TypeQRCode 根据Value生成的二维码
+	TypeQRCode = "qrcode"
+	// TypeBarcode128 根据Value生成的code128条形码
+	TypeBarcode128 = "barcode128"
+
+	AnchorTopLeft     = "top-left"
+	AnchorTop         = "top"
+	AnchorTopRight    = "top-right"
+	AnchorLeft        = "left"
+	AnchorCenter      = "center"
+	AnchorRight       = "right"
+	AnchorBottomLeft  = "bottom-left"
+	AnchorBottom      = "bottom"
+	AnchorBottomRight = "bottom-right"
+
+	// pointsPerMillimeter 1mm = 72/25.4 point
+	pointsPerMillimeter = 72 / 25.4
+	// codeDPI 生成二维码/条形码图片的分辨率, 太低时打印出来的码边缘模糊
+	codeDPI = 300
+
+	// Helvetica的上升和下降高度, 单位为字号的1/1000, 用于计算文字框的高度
+	fontAscent  = 718
+	fontDescent = 207
+)
+
+var anchors = []string{
+	AnchorTopLeft, AnchorTop, AnchorTopRight,
+	AnchorLeft, AnchorCenter, AnchorRight,
+	AnchorBottomLeft, AnchorBottom, AnchorBottomRight,
+}
+
+// Stamp 叠加到页面上的文字、图片或二维码/条形码
+type Stamp struct {
+	Type  string // text/image/qrcode/barcode128
+	Value string // 模板, 可以引用csv中的列, 例如: USED、{{.order_no}}; 图片为文件路径
+
+	Anchor string  // 位置, 默认top-left
+	X, Y   float64 // 距锚点所在边的距离, 单位mm; 居中的方向上为偏移量, 向右/向下为正
+
+	Width, Height float64 // 图片/码的大小, 单位mm; 图片只指定一边时按比例缩放
+	FontSize      float64 // 文字字号, 单位point
+	Color         string  // 文字颜色, 例如 #FF0000
+
+	Opacity  float64 // 不透明度, 0-1
+	Rotation float64 // 绕中心逆时针旋转的角度
+	Pages    string  // 页码范围, 例如 1,3-end; 为空时为所有页面
+
+	tpl *template.Template
+}
+
+// Validate 校验参数并设置默认值
+func (s *Stamp) Validate() error {
+	switch s.Type {
+	case "":
+		s.Type = TypeText
+	case TypeText, TypeImage, TypeQRCode, TypeBarcode128:
+	default:
+		return errors.Errorf(nil, "不支持的类型:%s, 支持: text/image/qrcode/barcode128", s.Type)
+	}
+
+	if strings.TrimSpace(s.Value) == "" {
+		return errors.Errorf(nil, "需要指定文字、图片路径或者码的内容")
+	}
+	// 引用了不存在的列时报错, 避免盖上"<no value>"
+	tpl, err := template.New("stamp").Option("missingkey=error").Parse(s.Value)
+	if err != nil {
+		return errors.Errorf(err, "解析模板失败, template:%s", s.Value)
+	}
+	s.tpl = tpl
+
+	if s.Anchor == "" {
+		s.Anchor = AnchorTopLeft
+	}
+	var validAnchor bool
+	for _, a := range anchors {
+		if s.Anchor == a {
+			validAnchor = true
+			break
+		}
+	}
+	if !validAnchor {
+		return errors.Errorf(nil, "不支持的位置:%s, 支持: %s", s.Anchor, strings.Join(anchors, "/"))
+	}
+
+	if s.Width < 0 || s.Height < 0 || s.FontSize < 0 {
+		return errors.Errorf(nil, "大小不能小于0")
+	}
+	switch s.Type {
+	case TypeText:
+		if s.FontSize == 0 {
+			s.FontSize = 36
+		}
+		if s.Color == "" {
+			s.Color = "#FF0000"
+		}
+		if _, _, _, err := parseHexColor(s.Color); err != nil {
+			return err
+		}
+	case TypeQRCode:
+		if s.Width == 0 {
+			s.Width = 25
+		}
+		// 二维码为正方形
+		s.Height = s.Width
+	case TypeBarcode128:
+		if s.Width == 0 {
+			s.Width = 50
+		}
+		if s.Height == 0 {
+			s.Height = 15
+		}
+	case TypeImage:
+		if s.Width == 0 && s.Height == 0 {
+			s.Width = 40
+		}
+	}
+
+	if s.Opacity == 0 {
+		s.Opacity = 1
+	}
+	if s.Opacity < 0 || s.Opacity > 1 {
+		return errors.Errorf(nil, "不透明度需要在0到1之间:%v", s.Opacity)
+	}
+
+	if strings.TrimSpace(s.Pages) != "" {
+		if _, err := util.ParsePageRanges(util.ReplacePageRangeEnd(s.Pages, 0), 0); err != nil {
+			return errors.Errorf(err, "页码范围不合法:%s", s.Pages)
+		}
+	}
+	return nil
+}
+
+// value 使用csv中该文件的一行执行模板, 没有csv时fields只有file
+func (s *Stamp) value(fields map[string]string) (string, error) {
+	var buf bytes.Buffer
+	if err := s.tpl.Execute(&buf, fields); err != nil {
+		return "", errors.Errorf(err, "执行模板失败")
+	}
+	return buf.String(), nil
+}
+
+// mark 一个文件中要盖到每一页上的内容, 同一个文件的所有页面共用字体和图片对象
+type mark struct {
+	width, height float64 // 单位point
+
+	// 文字
+	font    *unipdf.PdfFont
+	text    []byte
+	r, g, b float64
+
+	// 图片和码
+	ximg *unipdf.XObjectImage
+}
+
+// newMark 根据value生成文字或者图片
+func (s *Stamp) newMark(value string) (*mark, error) {
+	if s.Type == TypeText {
+		return s.newTextMark(value)
+	}
+
+	var (
+		img image.Image
+		err error
+	)
+	switch s.Type {
+	case TypeImage:
+		img, err = loadImage(value)
+	case TypeQRCode:
+		img, err = util.EncodeCode(value, common.CodeTypeQRCode, mmToPixels(s.Width), mmToPixels(s.Height))
+	case TypeBarcode128:
+		img, err = util.EncodeCode(value, common.CodeTypeBarcode128, mmToPixels(s.Width), mmToPixels(s.Height))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	pdfImg, err := unipdf.ImageHandling.NewImageFromGoImage(img)
+	if err != nil {
+		return nil, errors.Errorf(err, "转换图片失败")
+	}
+	ximg, err := unipdf.NewXObjectImageFromImage(pdfImg, nil, unicore.NewFlateEncoder())
+	if err != nil {
+		return nil, errors.Errorf(err, "创建图片对象失败")
+	}
+
+	w, h := s.Width*pointsPerMillimeter, s.Height*pointsPerMillimeter
+	bounds := img.Bounds()
+	switch {
+	case w == 0:
+		w = h * float64(bounds.Dx()) / float64(bounds.Dy())
+	case h == 0:
+		h = w * float64(bounds.Dy()) / float64(bounds.Dx())
+	}
+	return &mark{width: w, height: h, ximg: ximg}, nil
+}
+
+func (s *Stamp) newTextMark(text string) (*mark, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, errors.Errorf(nil, "文字为空")
+	}
+
+	font, err := unipdf.NewStandard14Font(unipdf.HelveticaBoldName)
+	if err != nil {
+		return nil, errors.Errorf(err, "加载字体失败")
+	}
+
+	var width float64
+	for _, r := range text {
+		if _, ok := font.Encoder().RuneToCharcode(r); !ok {
+			return nil, errors.Errorf(nil, "标准字体不支持字符:%q, 中文等文字请先做成图片", r)
+		}
+		if m, ok := font.GetRuneMetrics(r); ok {
+			width += m.Wx
+		}
+	}
+
+	r, g, b, err := parseHexColor(s.Color)
+	if err != nil {
+		return nil, err
+	}
+	return &mark{
+		width:  width * s.FontSize / 1000,
+		height: (fontAscent + fontDescent) * s.FontSize / 1000,
+		font:   font,
+		text:   font.Encoder().Encode(text),
+		r:      r,
+		g:      g,
+		b:      b,
+	}, nil
+}
+
+// place 计算mark左下角在pdf坐标系中的位置, box为页面的可见区域, w/h为mark的大小, 单位point
+func (s *Stamp) place(box *unipdf.PdfRectangle, w, h float64) (x, y float64) {
+	dx, dy := s.X*pointsPerMillimeter, s.Y*pointsPerMillimeter
+
+	switch s.Anchor {
+	case AnchorTopLeft, AnchorLeft, AnchorBottomLeft:
+		x = box.Llx + dx
+	case AnchorTopRight, AnchorRight, AnchorBottomRight:
+		x = box.Urx - w - dx
+	default:
+		x = box.Llx + (box.Urx-box.Llx-w)/2 + dx
+	}
+
+	// pdf的y轴向上
+	switch s.Anchor {
+	case AnchorTopLeft, AnchorTop, AnchorTopRight:
+		y = box.Ury - h - dy
+	case AnchorBottomLeft, AnchorBottom, AnchorBottomRight:
+		y = box.Lly + dy
+	default:
+		y = box.Lly + (box.Ury-box.Lly-h)/2 - dy
+	}
+	return x, y
+}
+
+// apply 把mark盖到页面上: 原来的内容用q/Q包起来, 避免没有恢复的图形状态影响盖章的位置
+func (s *Stamp) apply(page *unipdf.PdfPage, m *mark) error {
+	box := page.CropBox
+	if box == nil {
+		var err error
+		if box, err = page.GetMediaBox(); err != nil {
+			return errors.Errorf(err, "获取页面大小失败")
+		}
+	}
+
+	gsName := uniqueName("StampGS", page.HasExtGState)
+	egs := unicore.MakeDict()
+	egs.Set("Type", unicore.MakeName("ExtGState"))
+	egs.Set("CA", unicore.MakeFloat(s.Opacity))
+	egs.Set("ca", unicore.MakeFloat(s.Opacity))
+	if err := page.AddExtGState(gsName, egs); err != nil {
+		return errors.Errorf(err, "添加图形状态失败")
+	}
+
+	// 以mark的中心为原点旋转
+	x, y := s.place(box, m.width, m.height)
+	rad := s.Rotation * math.Pi / 180
+	cos, sin := math.Cos(rad), math.Sin(rad)
+	cc := unicontentstream.NewContentCreator().
+		Add_q().
+		Add_gs(gsName).
+		Add_cm(1, 0, 0, 1, x+m.width/2, y+m.height/2).
+		Add_cm(cos, sin, -sin, cos, 0, 0)
+
+	if m.ximg != nil {
+		imgName := uniqueName("StampIm", page.HasXObjectByName)
+		if err := page.AddImageResource(imgName, m.ximg); err != nil {
+			return errors.Errorf(err, "添加图片失败")
+		}
+		cc.Add_cm(m.width, 0, 0, m.height, -m.width/2, -m.height/2).
+			Add_Do(imgName)
+	} else {
+		fontName := uniqueName("StampF", page.HasFontByName)
+		if err := page.AddFont(fontName, m.font.ToPdfObject()); err != nil {
+			return errors.Errorf(err, "添加字体失败")
+		}
+		baseline := -m.height/2 + fontDescent*s.FontSize/1000
+		cc.Add_BT().
+			Add_Tf(fontName, s.FontSize).
+			Add_rg(m.r, m.g, m.b).
+			Add_Td(-m.width/2, baseline).
+			Add_Tj(*unicore.MakeStringFromBytes(m.text)).
+			Add_ET()
+	}
+	cc.Add_Q()
+
+	content, err := page.GetAllContentStreams()
+	if err != nil {
+		return errors.Errorf(err, "读取内容流失败")
+	}
+	if err := page.SetContentStreams([]string{"q\n" + content + "\nQ", cc.String()}, unicore.NewFlateEncoder()); err != nil {
+		return errors.Errorf(err, "写入内容流失败")
+	}
+	return nil
+}
+
+// uniqueName 页面资源中没有使用的名称, 例如 StampGS1
+func uniqueName(prefix string, exists func(name unicore.PdfObjectName) bool) unicore.PdfObjectName {
+	for i := 1; ; i++ {
+		name := unicore.PdfObjectName(fmt.Sprintf("%s%d", prefix, i))
+		if !exists(name) {
+			return name
+		}
+	}
+}
+
+func loadImage(file string) (image.Image, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, errors.Errorf(err, "打开图片失败, file:%s", file)
+	}
+	defer f.Close()
+
+	img, err := util.ImgDecode(strings.ToLower(path.Ext(file)), f)
+	if err != nil {
+		return nil, errors.Errorf(err, "解码图片失败, file:%s", file)
+	}
+	return img, nil
+}
+
+// mmToPixels 按codeDPI把mm转换成像素
+func mmToPixels(mm float64) int {
+	return int(math.Round(mm / 25.4 * codeDPI))
+}
+
+// parseHexColor 解析 #RRGGBB, 返回0-1的rgb
+func parseHexColor(s string) (r, g, b float64, err error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(hex) != 6 {
+		return 0, 0, 0, errors.Errorf(nil, "颜色格式不合法, 需要为#RRGGBB:%s", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, 0, 0, errors.Errorf(err, "颜色格式不合法, 需要为#RRGGBB:%s", s)
+	}
+	return float64(v>>16&0xff) / 255, float64(v>>8&0xff) / 255, float64(v&0xff) / 255, nil
+}
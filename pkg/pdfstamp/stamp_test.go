@@ -0,0 +1,175 @@
+package pdfstamp
+
+import (
+	"math"
+	"testing"
+
+	unipdf "github.com/unidoc/unipdf/v3/model"
+)
+
+func TestStamp_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		stamp   Stamp
+		want    Stamp
+		wantErr bool
+	}{
+		{
+			name:  "text defaults",
+			stamp: Stamp{Value: "USED"},
+			want:  Stamp{Type: TypeText, Value: "USED", Anchor: AnchorTopLeft, FontSize: 36, Color: "#FF0000", Opacity: 1},
+		},
+		{
+			name:  "qrcode is square",
+			stamp: Stamp{Type: TypeQRCode, Value: "{{.url}}", Width: 30, Height: 10, Opacity: 0.5},
+			want:  Stamp{Type: TypeQRCode, Value: "{{.url}}", Anchor: AnchorTopLeft, Width: 30, Height: 30, Opacity: 0.5},
+		},
+		{
+			name:  "barcode defaults",
+			stamp: Stamp{Type: TypeBarcode128, Value: "123", Anchor: AnchorBottom},
+			want:  Stamp{Type: TypeBarcode128, Value: "123", Anchor: AnchorBottom, Width: 50, Height: 15, Opacity: 1},
+		},
+		{
+			name:    "empty value",
+			stamp:   Stamp{Value: " "},
+			wantErr: true,
+		},
+		{
+			name:    "bad template",
+			stamp:   Stamp{Value: "{{.order_no"},
+			wantErr: true,
+		},
+		{
+			name:    "bad type",
+			stamp:   Stamp{Type: "pdf417", Value: "123"},
+			wantErr: true,
+		},
+		{
+			name:    "bad anchor",
+			stamp:   Stamp{Value: "USED", Anchor: "middle"},
+			wantErr: true,
+		},
+		{
+			name:    "bad color",
+			stamp:   Stamp{Value: "USED", Color: "red"},
+			wantErr: true,
+		},
+		{
+			name:    "bad opacity",
+			stamp:   Stamp{Value: "USED", Opacity: 1.5},
+			wantErr: true,
+		},
+		{
+			name:    "bad pages",
+			stamp:   Stamp{Value: "USED", Pages: "a-b"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.stamp
+			err := s.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			s.tpl = nil
+			if s != tt.want {
+				t.Errorf("Validate() got = %+v, want %+v", s, tt.want)
+			}
+		})
+	}
+}
+
+func TestStamp_value(t *testing.T) {
+	s := &Stamp{Value: "NO.{{.order_no}} {{.file}}"}
+	if err := s.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.value(map[string]string{"order_no": "A001", FieldFile: "voucher"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "NO.A001 voucher" {
+		t.Errorf("value() got = %q", got)
+	}
+
+	if _, err := s.value(map[string]string{FieldFile: "voucher"}); err == nil {
+		t.Errorf("value() want error for missing column")
+	}
+}
+
+func TestStamp_place(t *testing.T) {
+	box := &unipdf.PdfRectangle{Llx: 0, Lly: 0, Urx: 200, Ury: 100}
+	mm := pointsPerMillimeter
+	tests := []struct {
+		anchor string
+		x, y   float64
+		wantX  float64
+		wantY  float64
+	}{
+		{AnchorTopLeft, 10, 5, 10 * mm, 100 - 20 - 5*mm},
+		{AnchorTop, 0, 0, 80, 80},
+		{AnchorTopRight, 10, 0, 200 - 40 - 10*mm, 80},
+		{AnchorCenter, 0, 0, 80, 40},
+		{AnchorCenter, 5, 5, 80 + 5*mm, 40 - 5*mm},
+		{AnchorBottomLeft, 0, 5, 0, 5 * mm},
+		{AnchorBottomRight, 0, 0, 160, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.anchor, func(t *testing.T) {
+			s := &Stamp{Anchor: tt.anchor, X: tt.x, Y: tt.y}
+			x, y := s.place(box, 40, 20)
+			if math.Abs(x-tt.wantX) > 1e-9 || math.Abs(y-tt.wantY) > 1e-9 {
+				t.Errorf("place() got = (%v, %v), want (%v, %v)", x, y, tt.wantX, tt.wantY)
+			}
+		})
+	}
+}
+
+func TestStamp_newTextMark(t *testing.T) {
+	s := &Stamp{Value: "USED"}
+	if err := s.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := s.newMark("USED")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.width <= 0 || m.height <= 0 {
+		t.Errorf("newMark() got size = (%v, %v)", m.width, m.height)
+	}
+
+	if _, err := s.newMark("已使用"); err == nil {
+		t.Errorf("newMark() want error for characters the font can not encode")
+	}
+}
+
+func Test_parseHexColor(t *testing.T) {
+	tests := []struct {
+		s       string
+		r, g, b float64
+		wantErr bool
+	}{
+		{s: "#FF0000", r: 1},
+		{s: "00ff00", g: 1},
+		{s: "#000080", b: 128.0 / 255},
+		{s: "#FFF", wantErr: true},
+		{s: "#GG0000", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			r, g, b, err := parseHexColor(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseHexColor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if r != tt.r || g != tt.g || b != tt.b {
+				t.Errorf("parseHexColor() got = (%v, %v, %v), want (%v, %v, %v)", r, g, b, tt.r, tt.g, tt.b)
+			}
+		})
+	}
+}
@@ -3,33 +3,78 @@ package util
 import (
 	"context"
 	"io/ioutil"
+	"sync"
 
 	"invtools/utils/errors"
 
-	"github.com/chromedp/cdproto/page"
 	"github.com/chromedp/chromedp"
 )
 
-func ChromedpPrintPdf(url string, to string) error {
-	ctx, cancel := chromedp.NewContext(context.Background())
+const (
+	// DefaultPoolBrowsers 浏览器池中默认的chrome进程数
+	DefaultPoolBrowsers = 1
+	// DefaultPagesPerBrowser 每个chrome打印多少页之后重启, 避免内存持续上涨
+	DefaultPagesPerBrowser = 100
+)
+
+// ChromedpPrintPdf 启动一个新的chrome打印url, opts/wait为nil时使用默认选项
+func ChromedpPrintPdf(url string, to string, opts *PrintOptions, wait *WaitStrategy) error {
+	var proxy string
+	if opts != nil && opts.Request != nil {
+		proxy = opts.Request.Proxy
+	}
+
+	ctx, cancel := newBrowserContext(proxy)
+	defer cancel()
+
+	return printInTab(ctx, url, to, opts, wait)
+}
+
+// newBrowserContext 创建启动chrome的context, chrome只能在启动时设置代理
+func newBrowserContext(proxy string) (context.Context, context.CancelFunc) {
+	if proxy == "" {
+		return chromedp.NewContext(context.Background())
+	}
+
+	opts := make([]chromedp.ExecAllocatorOption, len(chromedp.DefaultExecAllocatorOptions), len(chromedp.DefaultExecAllocatorOptions)+1)
+	copy(opts, chromedp.DefaultExecAllocatorOptions)
+	opts = append(opts, chromedp.ProxyServer(proxy))
+
+	allocCtx, allocCancel := chromedp.NewExecAllocator(context.Background(), opts...)
+	ctx, cancel := chromedp.NewContext(allocCtx)
+	return ctx, func() {
+		cancel()
+		allocCancel()
+	}
+}
+
+// printInTab 在ctx对应的tab中打印, 超时后按等待策略截图
+func printInTab(ctx context.Context, url, to string, opts *PrintOptions, wait *WaitStrategy) error {
+	if opts == nil {
+		opts = DefaultPrintOptions()
+	}
+
+	// 先用不会超时的ctx启动chrome并打开tab, 超时后还需要在这个tab中截图
+	if err := chromedp.Run(ctx); err != nil {
+		return errors.Errorf(err, "打开tab失败")
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, wait.timeout())
 	defer cancel()
 
 	var buf []byte
-	err := chromedp.Run(ctx, chromedp.Tasks{
-		chromedp.Navigate(url),
-		chromedp.WaitReady("body"),
-		chromedp.ActionFunc(func(ctx context.Context) error {
-			var err error
-			buf, _, err = page.PrintToPDF().
-				//WithDisplayHeaderFooter(false).
-				//WithLandscape(true).
-				//WithPrintBackground(true).
-				Do(ctx)
-			return err
-		}),
-	})
-	if err != nil {
-		return errors.Errorf(err, "chromedp Run failed")
+	if err := chromedp.Run(timeoutCtx, opts.chromedpTasks(url, wait, &buf)); err != nil {
+		if timeoutCtx.Err() != context.DeadlineExceeded {
+			return errors.Errorf(err, "chromedp Run failed")
+		}
+
+		err = errors.Errorf(err, "打印超时, timeout:%s", wait.timeout())
+		if file, serr := wait.screenshot(ctx, to); serr != nil {
+			err = errors.Errorf(err, "超时截图失败:%v", errors.GetInnerMostV2(serr))
+		} else if file != "" {
+			err = errors.Errorf(err, "超时截图:%s", file)
+		}
+		return err
 	}
 
 	if err := ioutil.WriteFile(to, buf, 0644); err != nil {
@@ -38,3 +83,214 @@ func ChromedpPrintPdf(url string, to string) error {
 
 	return nil
 }
+
+// pooledBrowser 浏览器池中的一个chrome进程
+type pooledBrowser struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	lost   <-chan struct{} // chrome退出或崩溃时关闭
+
+	active  int  // 正在使用的tab数
+	pages   int  // 已经打印过的页数
+	retired bool // 不再分配新的tab, 所有tab关闭后退出
+}
+
+// alive chrome进程是否还能使用
+func (b *pooledBrowser) alive() bool {
+	if b.ctx.Err() != nil {
+		return false
+	}
+	select {
+	case <-b.lost:
+		return false
+	default:
+		return true
+	}
+}
+
+// BrowserPool 维护一组常驻的chrome进程, 每个任务在其中一个chrome中新开一个tab执行.
+// 同时打开的tab数由tabs限制, 每个chrome打印maxPages页或者崩溃后会被回收并按需重新启动
+type BrowserPool struct {
+	maxBrowsers int
+	maxPages    int
+	proxy       string
+	tabs        chan struct{}
+
+	mu       sync.Mutex
+	browsers []*pooledBrowser
+	closed   bool
+
+	// start 启动一个新的chrome, 测试时替换
+	start func() (*pooledBrowser, error)
+}
+
+// NewBrowserPool 创建浏览器池, browsers: chrome进程数, tabs: 同时打开的tab数, maxPages: 每个chrome打印多少页后重启,
+// proxy: chrome使用的代理, 为空时不使用代理
+func NewBrowserPool(browsers, tabs, maxPages int, proxy string) *BrowserPool {
+	if browsers <= 0 {
+		browsers = DefaultPoolBrowsers
+	}
+	if tabs <= 0 {
+		tabs = 1
+	}
+	if maxPages <= 0 {
+		maxPages = DefaultPagesPerBrowser
+	}
+	p := &BrowserPool{
+		maxBrowsers: browsers,
+		maxPages:    maxPages,
+		proxy:       proxy,
+		tabs:        make(chan struct{}, tabs),
+	}
+	p.start = p.startBrowser
+	return p
+}
+
+func (p *BrowserPool) startBrowser() (*pooledBrowser, error) {
+	ctx, cancel := newBrowserContext(p.proxy)
+	// 不带任何action的Run只会启动chrome
+	if err := chromedp.Run(ctx); err != nil {
+		cancel()
+		return nil, errors.Errorf(err, "启动chrome失败")
+	}
+	return &pooledBrowser{
+		ctx:    ctx,
+		cancel: cancel,
+		lost:   chromedp.FromContext(ctx).Browser.LostConnection,
+	}, nil
+}
+
+// PrintPdf 在新的tab中打开url并打印为pdf, opts/wait为nil时使用默认选项
+func (p *BrowserPool) PrintPdf(url, to string, opts *PrintOptions, wait *WaitStrategy) error {
+	return p.WithTab(func(ctx context.Context) error {
+		return printInTab(ctx, url, to, opts, wait)
+	})
+}
+
+// Run 从池中取一个chrome, 新开一个tab执行actions, 执行完关闭tab
+func (p *BrowserPool) Run(actions ...chromedp.Action) error {
+	return p.WithTab(func(ctx context.Context) error {
+		return chromedp.Run(ctx, actions...)
+	})
+}
+
+// WithTab 从池中取一个chrome, 新开一个tab执行fn, 执行完关闭tab
+func (p *BrowserPool) WithTab(fn func(ctx context.Context) error) error {
+	p.tabs <- struct{}{}
+	defer func() { <-p.tabs }()
+
+	b, err := p.acquire()
+	if err != nil {
+		return errors.Errorf(err, "获取浏览器失败")
+	}
+
+	tabCtx, cancel := chromedp.NewContext(b.ctx)
+	err = fn(tabCtx)
+	cancel()
+
+	p.release(b)
+	return err
+}
+
+// acquire 优先选择打开tab最少的chrome, 所有chrome都在使用且没达到上限时启动新的chrome
+func (p *BrowserPool) acquire() (*pooledBrowser, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil, errors.Errorf(nil, "browser pool is closed")
+	}
+
+	var (
+		picked *pooledBrowser
+		dead   []*pooledBrowser
+	)
+	for _, b := range p.browsers {
+		if b.retired {
+			continue
+		}
+		if !b.alive() {
+			// chrome崩溃, 等正在使用的tab全部返回后回收
+			b.retired = true
+			dead = append(dead, b)
+			continue
+		}
+		if picked == nil || b.active < picked.active {
+			picked = b
+		}
+	}
+
+	if picked == nil || (picked.active > 0 && p.running() < p.maxBrowsers) {
+		b, err := p.start()
+		if err != nil {
+			if picked == nil {
+				return nil, err
+			}
+		} else {
+			p.browsers = append(p.browsers, b)
+			picked = b
+		}
+	}
+	for _, b := range dead {
+		if b.active == 0 {
+			p.remove(b)
+		}
+	}
+
+	picked.active++
+	picked.pages++
+	if picked.pages >= p.maxPages {
+		// 达到页数上限后不再分配新的tab
+		picked.retired = true
+	}
+	return picked, nil
+}
+
+func (p *BrowserPool) release(b *pooledBrowser) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	b.active--
+	if p.closed {
+		return
+	}
+	if !b.alive() {
+		b.retired = true
+	}
+	if b.retired && b.active == 0 {
+		p.remove(b)
+	}
+}
+
+func (p *BrowserPool) remove(b *pooledBrowser) {
+	b.cancel()
+	for i, v := range p.browsers {
+		if v == b {
+			p.browsers = append(p.browsers[:i], p.browsers[i+1:]...)
+			break
+		}
+	}
+}
+
+// running 还在分配tab的chrome数
+func (p *BrowserPool) running() int {
+	var n int
+	for _, b := range p.browsers {
+		if !b.retired {
+			n++
+		}
+	}
+	return n
+}
+
+// Close 关闭所有chrome
+func (p *BrowserPool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	for _, b := range p.browsers {
+		b.cancel()
+	}
+	p.browsers = nil
+}
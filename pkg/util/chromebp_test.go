@@ -1,6 +1,7 @@
 package util
 
 import (
+	"context"
 	"testing"
 )
 
@@ -25,9 +26,70 @@ func TestChromedpPrintPdf(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if err := ChromedpPrintPdf(tt.args.url, tt.args.to); (err != nil) != tt.wantErr {
+			if err := ChromedpPrintPdf(tt.args.url, tt.args.to, nil, nil); (err != nil) != tt.wantErr {
 				t.Errorf("ChromedpPrintPdf() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
+
+func newFakeBrowserPool(browsers, maxPages int) (*BrowserPool, *int) {
+	started := 0
+	p := NewBrowserPool(browsers, browsers*2, maxPages, "")
+	p.start = func() (*pooledBrowser, error) {
+		started++
+		ctx, cancel := context.WithCancel(context.Background())
+		return &pooledBrowser{ctx: ctx, cancel: cancel, lost: make(chan struct{})}, nil
+	}
+	return p, &started
+}
+
+func TestBrowserPoolAcquire(t *testing.T) {
+	p, started := newFakeBrowserPool(2, 3)
+	defer p.Close()
+
+	// 第一个tab启动chrome, 第二个tab在第一个chrome忙时启动第二个chrome
+	b1, _ := p.acquire()
+	b2, _ := p.acquire()
+	if b1 == b2 || *started != 2 {
+		t.Fatalf("expect 2 browsers, started:%d", *started)
+	}
+
+	// 达到进程数上限后复用tab最少的chrome
+	p.release(b1)
+	b3, _ := p.acquire()
+	if b3 != b1 || *started != 2 {
+		t.Errorf("expect reuse idle browser, started:%d", *started)
+	}
+	p.release(b2)
+	p.release(b3)
+}
+
+func TestBrowserPoolRecycle(t *testing.T) {
+	p, started := newFakeBrowserPool(1, 2)
+	defer p.Close()
+
+	// 打印2页后回收
+	for i := 0; i < 2; i++ {
+		b, _ := p.acquire()
+		p.release(b)
+	}
+	if len(p.browsers) != 0 {
+		t.Fatalf("expect browser recycled after max pages, browsers:%d", len(p.browsers))
+	}
+	b, _ := p.acquire()
+	if *started != 2 {
+		t.Errorf("expect new browser started, started:%d", *started)
+	}
+
+	// chrome崩溃后回收
+	b.cancel()
+	p.release(b)
+	if len(p.browsers) != 0 {
+		t.Errorf("expect crashed browser recycled, browsers:%d", len(p.browsers))
+	}
+	if b, _ = p.acquire(); !b.alive() || *started != 3 {
+		t.Errorf("expect new browser after crash, started:%d", *started)
+	}
+	p.release(b)
+}
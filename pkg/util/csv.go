@@ -2,6 +2,7 @@ package util
 
 import (
 	"encoding/csv"
+	"io"
 	"os"
 	"strings"
 
@@ -92,3 +93,65 @@ func WriteRecordToXlsx(sheet *xlsx.Sheet, record []string) error {
 
 	return nil
 }
+
+// CsvTable 第一行为表头的csv, 按列名读取每一行的值, 列名不区分大小写
+type CsvTable struct {
+	Header  []string   // 去掉BOM和首尾空格后的列名
+	Rows    [][]string // 表头之后的行, 列数可以与表头不一致
+	columns map[string]int
+}
+
+// ReadCsvTable 读取第一行为表头的csv, required为必须包含的列
+func ReadCsvTable(r io.Reader, required ...string) (*CsvTable, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, errors.Errorf(err, "read csv failed")
+	}
+	if len(records) == 0 {
+		return nil, errors.Errorf(nil, "csv文件为空")
+	}
+
+	t := &CsvTable{
+		Header:  make([]string, len(records[0])),
+		Rows:    records[1:],
+		columns: make(map[string]int, len(records[0])),
+	}
+	for i, col := range records[0] {
+		// 去掉excel导出的csv中的BOM
+		col = strings.TrimSpace(strings.TrimPrefix(col, "\ufeff"))
+		t.Header[i] = col
+		if _, ok := t.columns[strings.ToLower(col)]; !ok && col != "" {
+			t.columns[strings.ToLower(col)] = i
+		}
+	}
+	for _, col := range required {
+		if t.Index(col) < 0 {
+			return nil, errors.Errorf(nil, "csv文件的表头需要包含%s列", strings.Join(required, "/"))
+		}
+	}
+	return t, nil
+}
+
+// Index 列在表头中的下标, 没有这一列时返回-1
+func (t *CsvTable) Index(column string) int {
+	if i, ok := t.columns[strings.ToLower(column)]; ok {
+		return i
+	}
+	return -1
+}
+
+// Value row中column列的值, 没有这一列或者这一行的列数不够时返回空; 不去掉首尾空格
+func (t *CsvTable) Value(row []string, column string) string {
+	i := t.Index(column)
+	if i < 0 || i >= len(row) {
+		return ""
+	}
+	return row[i]
+}
+
+// Line Rows中第i行在csv文件中的行号, 表头为第1行
+func (t *CsvTable) Line(i int) int {
+	return i + 2
+}
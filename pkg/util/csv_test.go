@@ -2,6 +2,8 @@ package util
 
 import (
 	"encoding/csv"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/tealeg/xlsx"
@@ -53,3 +55,65 @@ func TestTableWriter_WriteRecord(t *testing.T) {
 		})
 	}
 }
+
+func TestReadCsvTable(t *testing.T) {
+	tests := []struct {
+		name     string
+		csv      string
+		required []string
+		header   []string
+		values   []string // 每一行File列的值
+		wantErr  bool
+	}{
+		{
+			name:     "bom and case",
+			csv:      "\ufeff File , Group\na.pdf,g1\n b.pdf \n",
+			required: []string{"file", "group"},
+			header:   []string{"File", "Group"},
+			values:   []string{"a.pdf", " b.pdf "},
+		},
+		{
+			name:     "missing column",
+			csv:      "file,name\na.pdf,x\n",
+			required: []string{"file", "group"},
+			wantErr:  true,
+		},
+		{
+			name:    "empty",
+			csv:     "",
+			wantErr: true,
+		},
+		{
+			name:    "bad quote",
+			csv:     "file\n\"a.pdf\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, err := ReadCsvTable(strings.NewReader(tt.csv), tt.required...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadCsvTable() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(table.Header, tt.header) {
+				t.Errorf("Header = %q, want %q", table.Header, tt.header)
+			}
+			var values []string
+			for _, row := range table.Rows {
+				values = append(values, table.Value(row, "FILE"))
+			}
+			if !reflect.DeepEqual(values, tt.values) {
+				t.Errorf("Value() = %q, want %q", values, tt.values)
+			}
+			if got := table.Value(table.Rows[1], "group"); got != "" {
+				t.Errorf("Value() of a short row = %q, want empty", got)
+			}
+			if got := table.Index("missing"); got != -1 {
+				t.Errorf("Index() = %d, want -1", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package util
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+
+	"invtools/utils/errors"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+var (
+	gridColor      = color.NRGBA{R: 255, G: 0, B: 0, A: 90}
+	gridLabelColor = color.NRGBA{R: 200, G: 0, B: 0, A: 255}
+)
+
+// PageScale pdf页面(point)和渲染后图片(pixel)之间的换算关系
+type PageScale struct {
+	PageWidth, PageHeight float64 // 页面大小, 单位point
+	Scale                 float64 // 1 point对应的pixel数
+}
+
+// NewPageScale 根据页面大小和渲染后的图片大小计算换算关系
+func NewPageScale(pageWidth, pageHeight float64, img image.Rectangle) (*PageScale, error) {
+	if pageWidth <= 0 || pageHeight <= 0 {
+		return nil, errors.Errorf(nil, "页面大小不合法, width:%v, height:%v", pageWidth, pageHeight)
+	}
+	return &PageScale{
+		PageWidth:  pageWidth,
+		PageHeight: pageHeight,
+		Scale:      float64(img.Dx()) / pageWidth,
+	}, nil
+}
+
+// ToPixel pdf坐标(原点在左下角)转换为图片坐标(原点在左上角)
+func (s *PageScale) ToPixel(x, y float64) (int, int) {
+	return int(x * s.Scale), int((s.PageHeight - y) * s.Scale)
+}
+
+// ToPixelRect pdf中的矩形转换为图片中的矩形, 可直接用于crop_coordinates
+func (s *PageScale) ToPixelRect(llx, lly, urx, ury float64) image.Rectangle {
+	minX, minY := s.ToPixel(llx, ury)
+	maxX, maxY := s.ToPixel(urx, lly)
+	return image.Rect(minX, minY, maxX, maxY)
+}
+
+// DrawCoordinateGrid 在渲染后的页面图片上画坐标网格, 每隔step个point画一条线,
+// 并标注 "point/pixel" 两种坐标, 分别对应tet_coordinates和crop_coordinates
+// step<=0 时只复制一份图片, 不画网格
+func DrawCoordinateGrid(src image.Image, scale *PageScale, step float64) *image.NRGBA {
+	bounds := src.Bounds()
+	dst := image.NewNRGBA(bounds)
+	draw.Draw(dst, bounds, src, bounds.Min, draw.Src)
+
+	if step <= 0 {
+		return dst
+	}
+
+	for x := 0.0; x <= scale.PageWidth; x += step {
+		px, _ := scale.ToPixel(x, 0)
+		for py := bounds.Min.Y; py < bounds.Max.Y; py++ {
+			blendPixel(dst, px, py, gridColor)
+		}
+		drawLabel(dst, px+2, bounds.Min.Y+12, fmt.Sprintf("%.0f/%d", x, px))
+	}
+
+	for y := 0.0; y <= scale.PageHeight; y += step {
+		_, py := scale.ToPixel(0, y)
+		for px := bounds.Min.X; px < bounds.Max.X; px++ {
+			blendPixel(dst, px, py, gridColor)
+		}
+		labelY := py - 2
+		if labelY < bounds.Min.Y+24 {
+			// 最上面的线, 标注放在线的下方, 避免和x轴的标注重叠或超出图片
+			labelY = py + 24
+		}
+		drawLabel(dst, bounds.Min.X+2, labelY, fmt.Sprintf("%.0f/%d", y, py))
+	}
+
+	return dst
+}
+
+// DrawRect 在图片上画矩形边框
+func DrawRect(dst *image.NRGBA, r image.Rectangle, c color.Color, thickness int) {
+	for t := 0; t < thickness; t++ {
+		for x := r.Min.X; x <= r.Max.X; x++ {
+			dst.Set(x, r.Min.Y+t, c)
+			dst.Set(x, r.Max.Y-t, c)
+		}
+		for y := r.Min.Y; y <= r.Max.Y; y++ {
+			dst.Set(r.Min.X+t, y, c)
+			dst.Set(r.Max.X-t, y, c)
+		}
+	}
+}
+
+// FillRect 半透明填充矩形区域, 用于高亮
+func FillRect(dst *image.NRGBA, r image.Rectangle, c color.NRGBA) {
+	for y := r.Min.Y; y <= r.Max.Y; y++ {
+		for x := r.Min.X; x <= r.Max.X; x++ {
+			blendPixel(dst, x, y, c)
+		}
+	}
+}
+
+// DrawText 在图片上写文字, 只支持ASCII字符
+func DrawText(dst draw.Image, x, y int, text string, c color.Color) {
+	d := &font.Drawer{
+		Dst:  dst,
+		Src:  image.NewUniform(c),
+		Face: basicfont.Face7x13,
+		Dot:  fixed.P(x, y),
+	}
+	d.DrawString(text)
+}
+
+func blendPixel(dst *image.NRGBA, x, y int, c color.NRGBA) {
+	if !(image.Point{X: x, Y: y}).In(dst.Bounds()) {
+		return
+	}
+	old := dst.NRGBAAt(x, y)
+	alpha := uint32(c.A)
+	mix := func(a, b uint8) uint8 {
+		return uint8((uint32(a)*(255-alpha) + uint32(b)*alpha) / 255)
+	}
+	dst.SetNRGBA(x, y, color.NRGBA{R: mix(old.R, c.R), G: mix(old.G, c.G), B: mix(old.B, c.B), A: 255})
+}
+
+func drawLabel(dst draw.Image, x, y int, label string) {
+	DrawText(dst, x, y, label, gridLabelColor)
+}
+
+// SaveImage 保存为png图片
+func SaveImage(filename string, img image.Image) error {
+	return saveImage(filename, img)
+}
+
+// LoadImage 根据扩展名加载png/jpeg图片
+func LoadImage(filename string) (image.Image, error) {
+	return loadImage(filename)
+}
@@ -0,0 +1,54 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// fileNameReplacer 文件名中不允许出现的字符
+var fileNameReplacer = strings.NewReplacer(
+	"/", "_", "\\", "_", ":", "_", "*", "_", "?", "_",
+	"\"", "_", "<", "_", ">", "_", "|", "_", "\n", "_", "\r", "_", "\t", "_",
+)
+
+// SanitizeFileName 把文件名中不允许出现的字符替换为_, 并去掉首尾的空格和点; 结果可能为空
+func SanitizeFileName(name string) string {
+	return strings.Trim(strings.TrimSpace(fileNameReplacer.Replace(name)), " .")
+}
+
+// NameAllocator 按调用顺序分配文件名, 重名时依次添加_2, _3后缀;
+// 不区分大小写(windows/mac的文件系统和解压工具不区分), 可以在多个goroutine中使用
+type NameAllocator struct {
+	mu   sync.Mutex
+	used map[string]struct{}
+}
+
+func NewNameAllocator() *NameAllocator {
+	return &NameAllocator{used: make(map[string]struct{})}
+}
+
+// Allocate base为不带后缀的文件名, 返回没有被使用过的 base.ext 或 base_n.ext
+func (a *NameAllocator) Allocate(base, ext string) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	candidate := base + ext
+	for i := 2; a.isUsed(candidate); i++ {
+		candidate = fmt.Sprintf("%s_%d%s", base, i, ext)
+	}
+	a.used[strings.ToLower(candidate)] = struct{}{}
+	return candidate
+}
+
+// IsUsed name(带后缀)是否已经分配过
+func (a *NameAllocator) IsUsed(name string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.isUsed(name)
+}
+
+func (a *NameAllocator) isUsed(name string) bool {
+	_, ok := a.used[strings.ToLower(name)]
+	return ok
+}
@@ -0,0 +1,62 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSanitizeFileName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "A001", want: "A001"},
+		{name: "a/b\\c:d*e?f", want: "a_b_c_d_e_f"},
+		{name: "\"<>|", want: "____"},
+		{name: " line1\nline2\t ", want: "line1_line2_"},
+		{name: "..name. ", want: "name"},
+		{name: " . ", want: ""},
+		{name: "订单 123", want: "订单 123"},
+	}
+	for _, tt := range tests {
+		if got := SanitizeFileName(tt.name); got != tt.want {
+			t.Errorf("SanitizeFileName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNameAllocator_Allocate(t *testing.T) {
+	tests := []struct {
+		name  string
+		ext   string
+		bases []string
+		want  []string
+	}{
+		{
+			name:  "with ext",
+			ext:   ".pdf",
+			bases: []string{"a", "b", "A", "a", "a_2"},
+			want:  []string{"a.pdf", "b.pdf", "A_2.pdf", "a_3.pdf", "a_2_2.pdf"},
+		},
+		{
+			name:  "without ext",
+			bases: []string{"A001", "a001", "A001", "v1.2", "V1.2"},
+			want:  []string{"A001", "a001_2", "A001_3", "v1.2", "V1.2_2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewNameAllocator()
+			var got []string
+			for _, base := range tt.bases {
+				got = append(got, a.Allocate(base, tt.ext))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Allocate() got = %q, want %q", got, tt.want)
+			}
+			if !a.IsUsed(tt.want[0]) {
+				t.Errorf("IsUsed(%q) = false, want true", tt.want[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package util
+
+import (
+	"fmt"
+	"os"
+
+	"invtools/common"
+
+	"github.com/unidoc/unipdf/v3/common/license"
+)
+
+// 所有使用unipdf(v3)的命令都通过util读写pdf, license只在这里设置一次:
+// 没有license时写入的pdf每一页都会加上水印, 提取的文字中也会带有提示信息
+func init() {
+	err := license.SetLicenseKey(common.UnidocLicenseKey, common.UnidocCustomerName)
+	if err != nil {
+		fmt.Println("PDF Error loading license, err:", err)
+		os.Exit(1)
+	}
+}
@@ -5,20 +5,46 @@
 #include <stdlib.h>
 #include <stdio.h>
 
-int pdfclean(char *infile, char *outfile)
+#include "clean.h"
+
+typedef struct
+{
+	char *buf;
+	int size;
+} message_buffer;
+
+/* append_message 追加一行到buf, 超出size的部分丢弃 */
+static void append_message(message_buffer *mb, const char *message)
+{
+	int len = strlen(mb->buf);
+	if (len + 1 >= mb->size)
+		return;
+	snprintf(mb->buf + len, mb->size - len, "%s\n", message);
+}
+
+static void warning_callback(void *user, const char *message)
+{
+	append_message((message_buffer *)user, message);
+}
+
+/* pdfclean 返回0成功, 1重写失败, 2初始化失败; mupdf的警告和错误信息按行写入warnings和errmsg */
+int pdfclean(char *infile, char *outfile, char *warnings, int warnings_size, char *errmsg, int errmsg_size)
 {
 	pdf_write_options opts = pdf_default_write_options;
 	int errors = 0;
 	fz_context *ctx;
 	char *password = "";
 	char **retainlist;
+	message_buffer warning_buf = {warnings, warnings_size};
+	message_buffer error_buf = {errmsg, errmsg_size};
 
 	ctx = fz_new_context(NULL, NULL, FZ_STORE_UNLIMITED);
 	if (!ctx)
 	{
-		fprintf(stderr, "cannot initialise context\n");
-		exit(1);
+		append_message(&error_buf, "cannot initialise context");
+		return 2;
 	}
+	fz_set_warning_callback(ctx, warning_callback, &warning_buf);
 
 	fz_try(ctx)
 	{
@@ -26,8 +52,11 @@ int pdfclean(char *infile, char *outfile)
 	}
 	fz_catch(ctx)
 	{
+		append_message(&error_buf, fz_caught_message(ctx));
 		errors++;
 	}
+	/* 重复的警告会被合并, 输出最后一条的次数 */
+	fz_flush_warnings(ctx);
 	fz_drop_context(ctx);
 
 	return errors != 0;
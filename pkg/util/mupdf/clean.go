@@ -1,6 +1,8 @@
 package mupdf
 
 import (
+	"strings"
+
 	"invtools/utils"
 
 	"invtools/utils/errors"
@@ -10,11 +12,44 @@ import (
 clean.go rewrite(repair) pdf file
 */
 
-// PdfRepair pdf修复
-func PdfRepair(infile, outfile string) error {
+// messageSize 接收mupdf警告和错误信息的缓冲区大小, 超出的部分丢弃
+const messageSize = 8192
+
+// CleanResult mupdf重写pdf的结果
+type CleanResult struct {
+	Code     int      // pdfclean的返回值: 0成功, 1重写失败, 2初始化失败
+	Warnings []string // mupdf的警告, 例如修复xref时的 trying to repair broken xref
+}
+
+// PdfRepair pdf修复, 重写失败时同时返回已经收集到的警告
+func PdfRepair(infile, outfile string) (*CleanResult, error) {
 	if ok := utils.CheckFileIsExist(infile); !ok {
-		return errors.Errorf(nil, "infile not exists")
+		return nil, errors.Errorf(nil, "infile not exists")
 	}
 
 	return pdfClean(infile, outfile)
 }
+
+// newCleanResult 根据pdfclean的返回值和按行输出的警告/错误信息生成结果
+func newCleanResult(code int, warnings, errmsg string) (*CleanResult, error) {
+	res := &CleanResult{Code: code, Warnings: splitLines(warnings)}
+	if code == 0 {
+		return res, nil
+	}
+
+	msg := strings.Join(splitLines(errmsg), "; ")
+	if msg == "" {
+		msg = "unknown error"
+	}
+	return res, errors.Errorf(nil, "mupdf clean failed, code:%d, err:%s", code, msg)
+}
+
+func splitLines(s string) []string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -1 +1 @@
-int pdfclean(char *infile, char *outfile);
+int pdfclean(char *infile, char *outfile, char *warnings, int warnings_size, char *errmsg, int errmsg_size);
@@ -25,7 +25,7 @@ func Test_pdfClean(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if err := pdfClean(tt.args.infile, tt.args.outfile); (err != nil) != tt.wantErr {
+			if _, err := pdfClean(tt.args.infile, tt.args.outfile); (err != nil) != tt.wantErr {
 				t.Errorf("pdfClean() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
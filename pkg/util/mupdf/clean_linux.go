@@ -13,15 +13,19 @@ import (
 	"unsafe"
 )
 
-func pdfClean(infile, outfile string) error {
+func pdfClean(infile, outfile string) (*CleanResult, error) {
 	in := C.CString(infile)
 	defer C.free(unsafe.Pointer(in))
 
 	out := C.CString(outfile)
 	defer C.free(unsafe.Pointer(out))
 
-	_ = C.pdfclean(in, out)
-	//defer C.free(unsafe.Pointer(resCode))
-	//fmt.Println(resCode)
-	return nil
+	warnings := (*C.char)(C.calloc(messageSize, 1))
+	defer C.free(unsafe.Pointer(warnings))
+
+	errmsg := (*C.char)(C.calloc(messageSize, 1))
+	defer C.free(unsafe.Pointer(errmsg))
+
+	code := C.pdfclean(in, out, warnings, messageSize, errmsg, messageSize)
+	return newCleanResult(int(code), C.GoString(warnings), C.GoString(errmsg))
 }
@@ -0,0 +1,52 @@
+package mupdf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_newCleanResult(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     int
+		warnings string
+		errmsg   string
+		want     *CleanResult
+		wantErr  bool
+	}{
+		{
+			name: "healthy",
+			want: &CleanResult{},
+		},
+		{
+			name:     "repaired with warnings",
+			warnings: "format error: cannot find startxref\ntrying to repair broken xref\n\n",
+			want:     &CleanResult{Warnings: []string{"format error: cannot find startxref", "trying to repair broken xref"}},
+		},
+		{
+			name:     "failed",
+			code:     1,
+			warnings: "trying to repair broken xref\n",
+			errmsg:   "cannot open document\n",
+			want:     &CleanResult{Code: 1, Warnings: []string{"trying to repair broken xref"}},
+			wantErr:  true,
+		},
+		{
+			name:    "failed without message",
+			code:    2,
+			want:    &CleanResult{Code: 2},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newCleanResult(tt.code, tt.warnings, tt.errmsg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newCleanResult() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("newCleanResult() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
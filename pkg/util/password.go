@@ -0,0 +1,83 @@
+package util
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+
+	"invtools/utils/errors"
+
+	unipdf "github.com/unidoc/unipdf/v3/model"
+)
+
+// passwordList 全局密码列表, 由命令行参数--password_list设置, 打开加密的pdf时在指定的密码之后依次尝试
+var passwordList []string
+
+// SetPasswordList 设置全局密码列表, 需要在开始处理文件之前调用
+func SetPasswordList(list []string) {
+	passwordList = list
+}
+
+// LoadPasswordList 读取密码列表文件, 每行一个密码, 忽略空行
+func LoadPasswordList(file string) ([]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, errors.Errorf(err, "open password list failed, file:%s", file)
+	}
+	defer f.Close()
+
+	return readPasswordList(f)
+}
+
+func readPasswordList(r io.Reader) ([]string, error) {
+	var list []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		// 密码中可能有空格, 只去掉windows的换行符
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		list = append(list, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Errorf(err, "read password list failed")
+	}
+	return list, nil
+}
+
+// CandidatePasswords 打开加密的pdf时依次尝试的密码: 指定的密码、全局密码列表、空密码(只有owner密码的文件), 去掉重复的密码
+func CandidatePasswords(passwords ...string) []string {
+	var (
+		candidates []string
+		seen       = make(map[string]bool)
+	)
+	all := append(append(append([]string{}, passwords...), passwordList...), "")
+	for _, p := range all {
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+		candidates = append(candidates, p)
+	}
+	return candidates
+}
+
+// DecryptPdfReader 依次尝试CandidatePasswords解密, 返回解密成功的密码; 没有加密时返回空密码
+func DecryptPdfReader(r *unipdf.PdfReader, passwords ...string) (string, error) {
+	encrypted, err := r.IsEncrypted()
+	if err != nil {
+		return "", errors.Errorf(err, "check IsEncrypted failed")
+	}
+	if !encrypted {
+		return "", nil
+	}
+
+	for _, p := range CandidatePasswords(passwords...) {
+		if ok, err := r.Decrypt([]byte(p)); err == nil && ok {
+			return p, nil
+		}
+	}
+	return "", errors.Errorf(nil, "解密失败, 请检查密码或者密码列表")
+}
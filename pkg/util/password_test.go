@@ -0,0 +1,137 @@
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+
+	"invtools/pkg/internal/testpdf"
+
+	unisecurity "github.com/unidoc/unipdf/v3/core/security"
+	unipdf "github.com/unidoc/unipdf/v3/model"
+)
+
+func Test_readPasswordList(t *testing.T) {
+	got, err := readPasswordList(strings.NewReader("abc\r\n\n pass word \n#123\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"abc", " pass word ", "#123"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("readPasswordList() = %q, want %q", got, want)
+	}
+}
+
+func TestCandidatePasswords(t *testing.T) {
+	defer SetPasswordList(nil)
+
+	tests := []struct {
+		name      string
+		list      []string
+		passwords []string
+		want      []string
+	}{
+		{name: "no password", want: []string{""}},
+		{name: "password first", list: []string{"a", "b"}, passwords: []string{"p"}, want: []string{"p", "a", "b", ""}},
+		{name: "duplicates", list: []string{"a", "", "p"}, passwords: []string{"p", "a"}, want: []string{"p", "a", ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetPasswordList(tt.list)
+			if got := CandidatePasswords(tt.passwords...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CandidatePasswords() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecryptPdfReader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "password_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer SetPasswordList(nil)
+
+	plain := path.Join(dir, "plain.pdf")
+	testpdf.Write(t, plain, "voucher")
+	encrypted := path.Join(dir, "encrypted.pdf")
+	writeEncryptedPdf(t, plain, encrypted, "user", "owner")
+
+	tests := []struct {
+		name      string
+		file      string
+		list      []string
+		passwords []string
+		want      string
+		wantErr   bool
+	}{
+		{name: "not encrypted", file: plain, passwords: []string{"user"}, want: ""},
+		{name: "user password", file: encrypted, passwords: []string{"wrong", "user"}, want: "user"},
+		{name: "password list", file: encrypted, list: []string{"wrong", "owner"}, want: "owner"},
+		{name: "wrong password", file: encrypted, passwords: []string{"wrong"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetPasswordList(tt.list)
+			f, err := os.Open(tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			r, err := unipdf.NewPdfReader(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := DecryptPdfReader(r, tt.passwords...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecryptPdfReader() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("DecryptPdfReader() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func writeEncryptedPdf(t *testing.T, input, output, userPassword, ownerPassword string) {
+	f, err := os.Open(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, err := unipdf.NewPdfReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	numPages, err := r.GetNumPages()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := unipdf.NewPdfWriter()
+	for i := 1; i <= numPages; i++ {
+		page, err := r.GetPage(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := w.AddPage(page); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Encrypt([]byte(userPassword), []byte(ownerPassword), &unipdf.EncryptOptions{Permissions: unisecurity.PermOwner, Algorithm: unipdf.AES_256bit}); err != nil {
+		t.Fatal(err)
+	}
+	fWrite, err := os.Create(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fWrite.Close()
+	if err := w.Write(fWrite); err != nil {
+		t.Fatal(err)
+	}
+}
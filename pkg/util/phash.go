@@ -0,0 +1,35 @@
+package util
+
+import (
+	"image"
+	"math/bits"
+
+	"github.com/disintegration/gift"
+)
+
+// DifferenceHash 计算图片的感知哈希(dHash): 缩小为9x8的灰度图, 比较每行相邻像素的亮度,
+// 内容相同的页面即使分辨率、压缩质量不同, 哈希也基本一致
+func DifferenceHash(img image.Image) uint64 {
+	g := gift.New(
+		gift.Grayscale(),
+		gift.Resize(9, 8, gift.BoxResampling),
+	)
+	dst := image.NewGray(g.Bounds(img.Bounds()))
+	g.Draw(dst, img)
+
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			hash <<= 1
+			if dst.GrayAt(x, y).Y > dst.GrayAt(x+1, y).Y {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// HashSimilarity 两个感知哈希的相似度, 1表示完全相同
+func HashSimilarity(a, b uint64) float64 {
+	return 1 - float64(bits.OnesCount64(a^b))/64
+}
@@ -0,0 +1,42 @@
+package util
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestDifferenceHash(t *testing.T) {
+	// 左暗右亮的渐变, 以及同样内容放大两倍的图片
+	gradient := func(w, h int) image.Image {
+		img := image.NewGray(image.Rect(0, 0, w, h))
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				img.SetGray(x, y, color.Gray{Y: uint8(255 * x / w)})
+			}
+		}
+		return img
+	}
+	reversed := image.NewGray(image.Rect(0, 0, 90, 80))
+	for y := 0; y < 80; y++ {
+		for x := 0; x < 90; x++ {
+			reversed.SetGray(x, y, color.Gray{Y: uint8(255 - 255*x/90)})
+		}
+	}
+
+	tests := []struct {
+		name string
+		a, b image.Image
+		want float64
+	}{
+		{name: "TestDifferenceHash_scaled", a: gradient(90, 80), b: gradient(180, 160), want: 1},
+		{name: "TestDifferenceHash_reversed", a: gradient(90, 80), b: reversed, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HashSimilarity(DifferenceHash(tt.a), DifferenceHash(tt.b)); got != tt.want {
+				t.Errorf("HashSimilarity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,317 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"invtools/utils/errors"
+
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+const (
+	PaperSizeA3     = "A3"
+	PaperSizeA4     = "A4"
+	PaperSizeA5     = "A5"
+	PaperSizeLetter = "Letter"
+	PaperSizeLegal  = "Legal"
+
+	MediaTypePrint  = "print"
+	MediaTypeScreen = "screen"
+
+	mmPerInch = 25.4
+)
+
+// paperSizes 纸张尺寸, 单位英寸(chrome的PrintToPDF使用英寸)
+var paperSizes = map[string][2]float64{
+	PaperSizeA3:     {11.69, 16.54},
+	PaperSizeA4:     {8.27, 11.69},
+	PaperSizeA5:     {5.83, 8.27},
+	PaperSizeLetter: {8.5, 11},
+	PaperSizeLegal:  {8.5, 14},
+}
+
+// PrintOptions html打印为pdf的选项, chromedp和wkhtmltopdf共用
+type PrintOptions struct {
+	PaperSize       string  // A3/A4/A5/Letter/Legal
+	Landscape       bool    // 横向
+	MarginTop       float64 // 页边距, 单位毫米
+	MarginBottom    float64
+	MarginLeft      float64
+	MarginRight     float64
+	Scale           float64 // 缩放比例
+	PrintBackground bool    // 打印背景图片和颜色
+	HeaderTemplate  string  // 页眉html模板, 支持class: pageNumber, totalPages, date, title, url
+	FooterTemplate  string  // 页脚html模板, 同页眉
+	PageRanges      string  // 打印的页码范围, 例如: 1-3,5
+	MediaType       string  // css media类型, print/screen, 为空时使用打印工具的默认值
+
+	Request *RequestOptions // 访问页面时携带的cookie、header、basic auth和代理
+}
+
+// DefaultPrintOptions 与之前wkhtmltopdf写死的参数一致: A4纵向, 无边距
+func DefaultPrintOptions() *PrintOptions {
+	return &PrintOptions{
+		PaperSize:       PaperSizeA4,
+		Scale:           1,
+		PrintBackground: true,
+	}
+}
+
+// Validate 校验选项, 纸张名称忽略大小写
+func (o *PrintOptions) Validate() error {
+	if o == nil {
+		return errors.Errorf(nil, "print options is nil")
+	}
+
+	var found bool
+	for name := range paperSizes {
+		if strings.EqualFold(name, o.PaperSize) {
+			o.PaperSize = name
+			found = true
+			break
+		}
+	}
+	if !found {
+		return errors.Errorf(nil, "不支持的纸张大小:%s, 支持: A3/A4/A5/Letter/Legal", o.PaperSize)
+	}
+
+	for _, m := range []float64{o.MarginTop, o.MarginBottom, o.MarginLeft, o.MarginRight} {
+		if m < 0 {
+			return errors.Errorf(nil, "页边距不能小于0, margin:%v", m)
+		}
+	}
+
+	// chrome支持的缩放范围
+	if o.Scale < 0.1 || o.Scale > 2 {
+		return errors.Errorf(nil, "缩放比例需要在[0.1, 2]之间, scale:%v", o.Scale)
+	}
+
+	switch o.MediaType {
+	case "", MediaTypePrint, MediaTypeScreen:
+	default:
+		return errors.Errorf(nil, "不支持的media类型:%s, 支持: print/screen", o.MediaType)
+	}
+
+	if o.PageRanges != "" {
+		if _, err := ParsePageRanges(o.PageRanges, 0); err != nil {
+			return errors.Errorf(err, "页码范围不合法")
+		}
+	}
+	return nil
+}
+
+func (o *PrintOptions) hasHeaderFooter() bool {
+	return o.HeaderTemplate != "" || o.FooterTemplate != ""
+}
+
+// chromedpTasks 设置media类型, 打开url, 按等待策略等待页面渲染完成后打印
+func (o *PrintOptions) chromedpTasks(url string, wait *WaitStrategy, buf *[]byte) chromedp.Tasks {
+	var (
+		size    = paperSizes[o.PaperSize]
+		tracker = newNetworkTracker()
+		tasks   chromedp.Tasks
+	)
+	if o.MediaType != "" {
+		tasks = append(tasks, emulation.SetEmulatedMedia(o.MediaType))
+	}
+	tasks = append(tasks, o.Request.chromedpTasks(url)...)
+	tasks = append(tasks, wait.beforeNavigate(tracker)...)
+	tasks = append(tasks,
+		chromedp.Navigate(url),
+		chromedp.WaitReady("body"),
+	)
+	tasks = append(tasks, wait.afterNavigate(tracker)...)
+	return append(tasks,
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			params := page.PrintToPDF().
+				WithPaperWidth(size[0]).
+				WithPaperHeight(size[1]).
+				WithLandscape(o.Landscape).
+				WithMarginTop(o.MarginTop / mmPerInch).
+				WithMarginBottom(o.MarginBottom / mmPerInch).
+				WithMarginLeft(o.MarginLeft / mmPerInch).
+				WithMarginRight(o.MarginRight / mmPerInch).
+				WithScale(o.Scale).
+				WithPrintBackground(o.PrintBackground).
+				WithPageRanges(o.PageRanges)
+			if o.hasHeaderFooter() {
+				// chrome在只设置其中一个模板时, 另一个会使用默认的标题和页码, 这里用空白占位
+				params = params.WithDisplayHeaderFooter(true).
+					WithHeaderTemplate(templateOrBlank(o.HeaderTemplate)).
+					WithFooterTemplate(templateOrBlank(o.FooterTemplate))
+			}
+
+			var err error
+			*buf, _, err = params.Do(ctx)
+			return err
+		}),
+	)
+}
+
+func templateOrBlank(tpl string) string {
+	if tpl == "" {
+		return "<span></span>"
+	}
+	return tpl
+}
+
+// wkhtmltopdfSubstTpl wkhtmltopdf通过url参数传递页码等信息, 用脚本填充到chrome风格的class中
+const wkhtmltopdfSubstTpl = `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><script>
+function subst() {
+  var vars = {};
+  var kv = document.location.search.substring(1).split('&');
+  for (var i = 0; i < kv.length; i++) {
+    var p = kv[i].split('=', 2);
+    vars[p[0]] = decodeURIComponent(p[1] || '');
+  }
+  var m = {pageNumber: 'page', totalPages: 'topage', date: 'date', title: 'doctitle', url: 'webpage'};
+  for (var cls in m) {
+    var els = document.getElementsByClassName(cls);
+    for (var j = 0; j < els.length; j++) {
+      els[j].textContent = vars[m[cls]] || '';
+    }
+  }
+}
+</script></head><body style="margin:0" onload="subst()">%s</body></html>`
+
+// wkhtmltopdfArgs 转换为wkhtmltopdf的参数, 页眉页脚模板写入临时文件, 使用完需要调用cleanup删除
+func (o *PrintOptions) wkhtmltopdfArgs(u *url.URL) (args []string, cleanup func(), err error) {
+	var tmpFiles []string
+	cleanup = func() {
+		for _, f := range tmpFiles {
+			os.Remove(f)
+		}
+	}
+
+	orientation := "Portrait"
+	if o.Landscape {
+		orientation = "Landscape"
+	}
+	args = []string{
+		"--orientation", orientation,
+		"--page-size", o.PaperSize,
+		"--encoding", "utf-8",
+		"-T", formatMillimeter(o.MarginTop),
+		"-B", formatMillimeter(o.MarginBottom),
+		"-L", formatMillimeter(o.MarginLeft),
+		"-R", formatMillimeter(o.MarginRight),
+		"--zoom", strconv.FormatFloat(o.Scale, 'f', -1, 64),
+		"--quiet",
+	}
+	if o.PrintBackground {
+		args = append(args, "--background")
+	} else {
+		args = append(args, "--no-background")
+	}
+	switch o.MediaType {
+	case MediaTypePrint:
+		args = append(args, "--print-media-type")
+	case MediaTypeScreen:
+		args = append(args, "--no-print-media-type")
+	}
+
+	for _, hf := range []struct{ flag, tpl string }{
+		{"--header-html", o.HeaderTemplate},
+		{"--footer-html", o.FooterTemplate},
+	} {
+		if hf.tpl == "" {
+			continue
+		}
+		f, err := ioutil.TempFile("", "wkhtmltopdf_*.html")
+		if err != nil {
+			cleanup()
+			return nil, nil, errors.Errorf(err, "创建页眉页脚模板文件失败")
+		}
+		tmpFiles = append(tmpFiles, f.Name())
+		_, err = fmt.Fprintf(f, wkhtmltopdfSubstTpl, hf.tpl)
+		f.Close()
+		if err != nil {
+			cleanup()
+			return nil, nil, errors.Errorf(err, "写入页眉页脚模板文件失败")
+		}
+		args = append(args, hf.flag, f.Name())
+	}
+
+	args = append(args, o.Request.wkhtmltopdfArgs(u)...)
+	return args, cleanup, nil
+}
+
+func formatMillimeter(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64) + "mm"
+}
+
+// ParsePageRanges 解析页码范围, 例如: "1-3, 5, 8-", 页码从1开始.
+// count大于0时校验页码不超过总页数, "8-"表示到最后一页; count为0时只校验格式
+func ParsePageRanges(ranges string, count int) ([]int, error) {
+	var pages []int
+	for _, part := range strings.Split(ranges, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		var (
+			start, end int
+			err        error
+		)
+		bounds := strings.SplitN(part, "-", 2)
+		if start, err = strconv.Atoi(strings.TrimSpace(bounds[0])); err != nil || start < 1 {
+			return nil, errors.Errorf(err, "页码不合法:%s", part)
+		}
+		end = start
+		if len(bounds) == 2 {
+			if e := strings.TrimSpace(bounds[1]); e == "" {
+				end = count
+				if count == 0 {
+					end = start
+				}
+			} else if end, err = strconv.Atoi(e); err != nil || end < start {
+				return nil, errors.Errorf(err, "页码范围不合法:%s", part)
+			}
+		}
+		if count > 0 && (start > count || end > count) {
+			return nil, errors.Errorf(nil, "页码超出总页数, range:%s, count:%d", part, count)
+		}
+
+		for i := start; i <= end; i++ {
+			pages = append(pages, i)
+		}
+	}
+	if len(pages) == 0 {
+		return nil, errors.Errorf(nil, "页码范围为空")
+	}
+	return pages, nil
+}
+
+// ReplacePageRangeEnd 把页码范围中的end替换为最后一页, 例如 "5-end" -> "5-8", "end" -> "8";
+// count为0时"5-end"替换为"5-", "end"替换为"1", 只用于校验格式
+func ReplacePageRangeEnd(ranges string, count int) string {
+	last := "1"
+	if count > 0 {
+		last = strconv.Itoa(count)
+	}
+
+	parts := strings.Split(ranges, ",")
+	for i, part := range parts {
+		bounds := strings.SplitN(part, "-", 2)
+		switch {
+		case len(bounds) == 1 && strings.EqualFold(strings.TrimSpace(part), "end"):
+			parts[i] = last
+		case len(bounds) == 2 && strings.EqualFold(strings.TrimSpace(bounds[1]), "end"):
+			parts[i] = bounds[0] + "-"
+			if count > 0 {
+				parts[i] += last
+			}
+		}
+	}
+	return strings.Join(parts, ",")
+}
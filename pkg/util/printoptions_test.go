@@ -0,0 +1,119 @@
+package util
+
+import (
+	"io/ioutil"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePageRanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		ranges  string
+		count   int
+		want    []int
+		wantErr bool
+	}{
+		{name: "single", ranges: "3", count: 5, want: []int{3}},
+		{name: "mixed", ranges: "1-3, 5", count: 5, want: []int{1, 2, 3, 5}},
+		{name: "open end", ranges: "4-", count: 5, want: []int{4, 5}},
+		{name: "syntax only", ranges: "2-4", count: 0, want: []int{2, 3, 4}},
+		{name: "out of range", ranges: "4-6", count: 5, wantErr: true},
+		{name: "reversed", ranges: "3-1", count: 5, wantErr: true},
+		{name: "zero", ranges: "0", count: 5, wantErr: true},
+		{name: "not number", ranges: "a-b", count: 5, wantErr: true},
+		{name: "empty", ranges: " , ", count: 5, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePageRanges(tt.ranges, tt.count)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePageRanges() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParsePageRanges() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplacePageRangeEnd(t *testing.T) {
+	tests := []struct {
+		name   string
+		ranges string
+		count  int
+		want   string
+	}{
+		{name: "range end", ranges: "1-3,5-end", count: 8, want: "1-3,5-8"},
+		{name: "single end", ranges: "end, 1-2", count: 8, want: "8, 1-2"},
+		{name: "upper case", ranges: "2-END", count: 3, want: "2-3"},
+		{name: "syntax only", ranges: "5-end,end", count: 0, want: "5-,1"},
+		{name: "no end", ranges: "1-3,4", count: 8, want: "1-3,4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ReplacePageRangeEnd(tt.ranges, tt.count); got != tt.want {
+				t.Errorf("ReplacePageRangeEnd() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(o *PrintOptions)
+		wantErr bool
+	}{
+		{name: "default", modify: func(o *PrintOptions) {}},
+		{name: "case insensitive paper", modify: func(o *PrintOptions) { o.PaperSize = "letter" }},
+		{name: "unknown paper", modify: func(o *PrintOptions) { o.PaperSize = "B5" }, wantErr: true},
+		{name: "negative margin", modify: func(o *PrintOptions) { o.MarginLeft = -1 }, wantErr: true},
+		{name: "scale too large", modify: func(o *PrintOptions) { o.Scale = 3 }, wantErr: true},
+		{name: "media type", modify: func(o *PrintOptions) { o.MediaType = "tv" }, wantErr: true},
+		{name: "page ranges", modify: func(o *PrintOptions) { o.PageRanges = "1-x" }, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := DefaultPrintOptions()
+			tt.modify(o)
+			if err := o.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPrintOptionsWkhtmltopdfArgs(t *testing.T) {
+	o := DefaultPrintOptions()
+	o.Landscape = true
+	o.MarginTop = 12.5
+	o.MediaType = MediaTypePrint
+	o.FooterTemplate = `<span class="pageNumber"></span>`
+
+	args, cleanup, err := o.wkhtmltopdfArgs(&url.URL{Scheme: "https", Host: "www.example.com"})
+	if err != nil {
+		t.Fatalf("wkhtmltopdfArgs() error = %v", err)
+	}
+	defer cleanup()
+
+	cmd := strings.Join(args, " ")
+	for _, want := range []string{"--orientation Landscape", "--page-size A4", "-T 12.5mm", "-B 0mm", "--background", "--print-media-type", "--footer-html "} {
+		if !strings.Contains(cmd, want) {
+			t.Errorf("args %q missing %q", cmd, want)
+		}
+	}
+	if strings.Contains(cmd, "--header-html") {
+		t.Errorf("args %q should not contain header", cmd)
+	}
+
+	footer, err := ioutil.ReadFile(args[len(args)-1])
+	if err != nil {
+		t.Fatalf("read footer template failed: %v", err)
+	}
+	if !strings.Contains(string(footer), o.FooterTemplate) || !strings.Contains(string(footer), "subst()") {
+		t.Errorf("unexpected footer template: %s", footer)
+	}
+}
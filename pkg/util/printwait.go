@@ -0,0 +1,242 @@
+package util
+
+import (
+	"context"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"invtools/utils/errors"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// DefaultPageTimeout 单个页面从打开到打印完成的超时时间
+	DefaultPageTimeout = 60 * time.Second
+
+	// networkIdleTime 没有进行中的请求持续多久算网络空闲
+	networkIdleTime = 500 * time.Millisecond
+	// waitPollInterval 轮询js表达式和网络状态的间隔
+	waitPollInterval = 100 * time.Millisecond
+	// screenshotTimeout 超时后截图的超时时间
+	screenshotTimeout = 10 * time.Second
+)
+
+// WaitStrategy 打印之前等待页面渲染完成的策略, 按 selector -> js -> network_idle -> delay 的顺序依次等待
+type WaitStrategy struct {
+	Pattern     string        `yaml:"pattern"`      // url正则, 只在规则文件中使用
+	Selector    string        `yaml:"selector"`     // 等待元素可见
+	JS          string        `yaml:"js"`           // 等待js表达式返回true
+	NetworkIdle bool          `yaml:"network_idle"` // 等待网络空闲
+	Delay       time.Duration `yaml:"delay"`        // 最后固定等待的时间
+	Timeout     time.Duration `yaml:"timeout"`      // 单个页面的超时时间
+
+	ScreenshotDir string `yaml:"-"` // 超时后截图保存的目录, 为空时不截图
+
+	re *regexp.Regexp
+}
+
+// WaitRules 按url匹配等待策略, 都不匹配时使用Default
+type WaitRules struct {
+	Default *WaitStrategy   `yaml:"-"`
+	Rules   []*WaitStrategy `yaml:"rules"`
+}
+
+// LoadWaitRules 读取规则文件, 规则中没有设置超时时间的使用def的超时时间.
+// 规则文件格式:
+//   rules:
+//     - pattern: "puroland\\.jp"
+//       selector: "#qrcode canvas"
+//       timeout: 90s
+//     - pattern: "klook"
+//       network_idle: true
+//       delay: 2s
+func LoadWaitRules(file string, def *WaitStrategy) (*WaitRules, error) {
+	if def == nil {
+		def = &WaitStrategy{}
+	}
+	if def.Timeout <= 0 {
+		def.Timeout = DefaultPageTimeout
+	}
+	rules := &WaitRules{Default: def}
+	if file == "" {
+		return rules, nil
+	}
+
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.Errorf(err, "读取等待规则文件失败, file:%s", file)
+	}
+	if err := yaml.Unmarshal(b, rules); err != nil {
+		return nil, errors.Errorf(err, "解析等待规则文件失败, file:%s", file)
+	}
+
+	for i, r := range rules.Rules {
+		if r.Pattern == "" {
+			return nil, errors.Errorf(nil, "第%d条等待规则没有设置pattern", i+1)
+		}
+		if r.re, err = regexp.Compile(r.Pattern); err != nil {
+			return nil, errors.Errorf(err, "第%d条等待规则的pattern不合法:%s", i+1, r.Pattern)
+		}
+		if r.Timeout <= 0 {
+			r.Timeout = def.Timeout
+		}
+		r.ScreenshotDir = def.ScreenshotDir
+	}
+	return rules, nil
+}
+
+// Match 返回第一条匹配url的规则
+func (w *WaitRules) Match(url string) *WaitStrategy {
+	if w == nil {
+		return nil
+	}
+	for _, r := range w.Rules {
+		if r.re != nil && r.re.MatchString(url) {
+			return r
+		}
+	}
+	return w.Default
+}
+
+func (s *WaitStrategy) timeout() time.Duration {
+	if s == nil || s.Timeout <= 0 {
+		return DefaultPageTimeout
+	}
+	return s.Timeout
+}
+
+// beforeNavigate 需要在打开页面之前执行的action, 例如监听网络请求
+func (s *WaitStrategy) beforeNavigate(tracker *networkTracker) chromedp.Tasks {
+	if s == nil || !s.NetworkIdle {
+		return nil
+	}
+	return chromedp.Tasks{
+		network.Enable(),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			chromedp.ListenTarget(ctx, tracker.onEvent)
+			return nil
+		}),
+	}
+}
+
+// afterNavigate 页面打开之后按顺序等待
+func (s *WaitStrategy) afterNavigate(tracker *networkTracker) chromedp.Tasks {
+	if s == nil {
+		return nil
+	}
+
+	var tasks chromedp.Tasks
+	if s.Selector != "" {
+		tasks = append(tasks, chromedp.WaitVisible(s.Selector, chromedp.ByQuery))
+	}
+	if s.JS != "" {
+		tasks = append(tasks, waitJS(s.JS))
+	}
+	if s.NetworkIdle {
+		tasks = append(tasks, chromedp.ActionFunc(func(ctx context.Context) error {
+			return poll(ctx, func(ctx context.Context) bool {
+				return tracker.idle(networkIdleTime)
+			})
+		}))
+	}
+	if s.Delay > 0 {
+		tasks = append(tasks, chromedp.Sleep(s.Delay))
+	}
+	return tasks
+}
+
+// waitJS 轮询js表达式直到返回true, 表达式执行出错(例如变量还没有定义)时继续等待
+func waitJS(expression string) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		return poll(ctx, func(ctx context.Context) bool {
+			var ok bool
+			if err := chromedp.Evaluate(expression, &ok).Do(ctx); err != nil {
+				return false
+			}
+			return ok
+		})
+	})
+}
+
+// poll 每隔waitPollInterval检查一次, 直到done返回true或者ctx超时
+func poll(ctx context.Context, done func(ctx context.Context) bool) error {
+	ticker := time.NewTicker(waitPollInterval)
+	defer ticker.Stop()
+	for {
+		if done(ctx) {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// screenshot 超时后截取当前页面, 保存为与pdf同名的png
+func (s *WaitStrategy) screenshot(ctx context.Context, to string) (string, error) {
+	if s == nil || s.ScreenshotDir == "" {
+		return "", nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, screenshotTimeout)
+	defer cancel()
+
+	var buf []byte
+	if err := chromedp.Run(ctx, chromedp.CaptureScreenshot(&buf)); err != nil {
+		return "", errors.Errorf(err, "截图失败")
+	}
+
+	name := strings.TrimSuffix(path.Base(to), path.Ext(to)) + ".png"
+	file := path.Join(s.ScreenshotDir, name)
+	if err := ioutil.WriteFile(file, buf, 0644); err != nil {
+		return "", errors.Errorf(err, "保存截图失败, file:%s", file)
+	}
+	return file, nil
+}
+
+// networkTracker 统计进行中的请求, 用于判断网络是否空闲
+type networkTracker struct {
+	mu       sync.Mutex
+	inflight map[network.RequestID]struct{}
+	last     time.Time // 最后一次请求开始或结束的时间
+}
+
+func newNetworkTracker() *networkTracker {
+	return &networkTracker{
+		inflight: make(map[network.RequestID]struct{}),
+		last:     time.Now(),
+	}
+}
+
+func (t *networkTracker) onEvent(ev interface{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch ev := ev.(type) {
+	case *network.EventRequestWillBeSent:
+		t.inflight[ev.RequestID] = struct{}{}
+	case *network.EventLoadingFinished:
+		delete(t.inflight, ev.RequestID)
+	case *network.EventLoadingFailed:
+		delete(t.inflight, ev.RequestID)
+	default:
+		return
+	}
+	t.last = time.Now()
+}
+
+// idle 没有进行中的请求, 并且已经持续了d
+func (t *networkTracker) idle(d time.Duration) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.inflight) == 0 && time.Since(t.last) >= d
+}
@@ -0,0 +1,96 @@
+package util
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+func TestLoadWaitRules(t *testing.T) {
+	f, err := ioutil.TempFile("", "wait_rules_*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString(`rules:
+  - pattern: "puroland\\.jp"
+    selector: "#qrcode canvas"
+    timeout: 90s
+  - pattern: "klook"
+    network_idle: true
+    delay: 2s
+`)
+	f.Close()
+
+	def := &WaitStrategy{Delay: time.Second, ScreenshotDir: "/tmp/shots"}
+	rules, err := LoadWaitRules(f.Name(), def)
+	if err != nil {
+		t.Fatalf("LoadWaitRules() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		url         string
+		wantDelay   time.Duration
+		wantTimeout time.Duration
+		wantIdle    bool
+	}{
+		{name: "puroland", url: "https://www.puroland.jp/qrticket/e/?p=1", wantTimeout: 90 * time.Second},
+		{name: "klook", url: "https://www.klook.com/voucher/KLK1", wantDelay: 2 * time.Second, wantTimeout: DefaultPageTimeout, wantIdle: true},
+		{name: "default", url: "https://www.example.com", wantDelay: time.Second, wantTimeout: DefaultPageTimeout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rules.Match(tt.url)
+			if got.Delay != tt.wantDelay || got.timeout() != tt.wantTimeout || got.NetworkIdle != tt.wantIdle {
+				t.Errorf("Match() = %+v", got)
+			}
+			if got.ScreenshotDir != def.ScreenshotDir {
+				t.Errorf("Match() screenshot dir = %s, want %s", got.ScreenshotDir, def.ScreenshotDir)
+			}
+		})
+	}
+}
+
+func TestLoadWaitRulesInvalidPattern(t *testing.T) {
+	f, err := ioutil.TempFile("", "wait_rules_*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("rules:\n  - pattern: \"(\"\n")
+	f.Close()
+
+	if _, err := LoadWaitRules(f.Name(), nil); err == nil {
+		t.Errorf("LoadWaitRules() expect error for invalid pattern")
+	}
+}
+
+func TestNetworkTrackerIdle(t *testing.T) {
+	tracker := newNetworkTracker()
+	tracker.onEvent(&network.EventRequestWillBeSent{RequestID: "1"})
+	tracker.onEvent(&network.EventRequestWillBeSent{RequestID: "2"})
+	tracker.onEvent(&network.EventLoadingFinished{RequestID: "1"})
+	if tracker.idle(0) {
+		t.Fatalf("expect busy with request in flight")
+	}
+
+	tracker.onEvent(&network.EventLoadingFailed{RequestID: "2"})
+	if !tracker.idle(0) {
+		t.Errorf("expect idle after all requests finished")
+	}
+	if tracker.idle(time.Hour) {
+		t.Errorf("expect not idle before idle time passed")
+	}
+
+	// 等待超时
+	ctx, cancel := context.WithTimeout(context.Background(), 3*waitPollInterval)
+	defer cancel()
+	if err := poll(ctx, func(ctx context.Context) bool { return tracker.idle(time.Hour) }); err != context.DeadlineExceeded {
+		t.Errorf("poll() error = %v, want deadline exceeded", err)
+	}
+}
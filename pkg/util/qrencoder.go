@@ -0,0 +1,233 @@
+package util
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+
+	"invtools/common"
+
+	"invtools/utils/errors"
+
+	"github.com/boombuler/barcode/pdf417"
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/datamatrix"
+	dmencoder "github.com/makiuchi-d/gozxing/datamatrix/encoder"
+	"github.com/makiuchi-d/gozxing/oned"
+	"github.com/makiuchi-d/gozxing/qrcode"
+)
+
+// DefaultCodeMargin 使用各类型默认的空白: 二维码4个模块, DataMatrix 1个模块, PDF417 2个模块, 条形码10个模块
+const DefaultCodeMargin = -1
+
+// 码下方文字的字体大小, 与DrawText使用的basicfont.Face7x13一致
+const (
+	captionCharWidth  = 7
+	captionCharHeight = 13
+	captionAscent     = 11
+)
+
+// CodeOptions 生成码图片的参数
+type CodeOptions struct {
+	Width, Height   int    // 码的像素大小, 二维码/DataMatrix为正方形, 取较小的边
+	Margin          int    // 码四周的空白, 单位为模块(最窄的条或者点)的宽度, DefaultCodeMargin时使用默认值
+	ErrorCorrection string // 二维码纠错等级: L/M/Q/H, 为空时为L; PDF417对应安全等级2/3/4/5
+	Text            string // 码下方显示的文字, 只支持ASCII字符, 为空时不显示
+}
+
+// EncodeCode 生成二维码/条形码图片, width/height为图片的像素大小; 二维码为正方形, 取较小的边
+func EncodeCode(content, codeType string, width, height int) (image.Image, error) {
+	return EncodeCodeWithOptions(content, codeType, CodeOptions{Width: width, Height: height, Margin: DefaultCodeMargin})
+}
+
+// EncodeCodeWithOptions 生成码图片, 码按整数倍放大后居中, 图片不够大时按码的实际大小生成;
+// 有文字时在码的下方增加一行文字
+func EncodeCodeWithOptions(content, codeType string, opts CodeOptions) (image.Image, error) {
+	if content == "" {
+		return nil, errors.Errorf(nil, "code内容为空")
+	}
+	for _, r := range opts.Text {
+		if r > 127 {
+			return nil, errors.Errorf(nil, "码下方的文字只支持ASCII字符:%q", opts.Text)
+		}
+	}
+
+	matrix, margin, twoD, err := encodeModules(content, codeType, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	width, height := opts.Width, opts.Height
+	if twoD && matrix.GetWidth() == matrix.GetHeight() {
+		if height < width {
+			width = height
+		}
+		height = width
+	}
+
+	// 每个模块的像素数, 2D码四周都有空白, 条形码只有左右两侧有空白
+	modulesX, modulesY := matrix.GetWidth()+2*margin, matrix.GetHeight()
+	if twoD {
+		modulesY += 2 * margin
+	}
+	scale := width / modulesX
+	if twoD && height/modulesY < scale {
+		scale = height / modulesY
+	}
+	if scale < 1 {
+		scale = 1
+	}
+	if width < modulesX*scale {
+		width = modulesX * scale
+	}
+	if height < modulesY*scale {
+		height = modulesY * scale
+	}
+
+	var caption *image.Gray
+	if text := strings.TrimSpace(opts.Text); text != "" {
+		caption = renderCaption(text, width)
+	}
+	canvasHeight := height
+	if caption != nil {
+		canvasHeight += caption.Bounds().Dy()
+	}
+
+	img := image.NewGray(image.Rect(0, 0, width, canvasHeight))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.Gray{Y: 255}), image.Point{}, draw.Src)
+
+	left := (width - matrix.GetWidth()*scale) / 2
+	top := (height - matrix.GetHeight()*scale) / 2
+	for y := 0; y < height; y++ {
+		my := (y - top) / scale
+		if !twoD {
+			// 条形码只有一行, 条的高度为整个图片的高度
+			my = 0
+		} else if y < top || my >= matrix.GetHeight() {
+			continue
+		}
+		for x := left; x < left+matrix.GetWidth()*scale; x++ {
+			if matrix.Get((x-left)/scale, my) {
+				img.SetGray(x, y, color.Gray{Y: 0})
+			}
+		}
+	}
+
+	if caption != nil {
+		r := caption.Bounds().Add(image.Pt((width-caption.Bounds().Dx())/2, height))
+		draw.Draw(img, r, caption, image.Point{}, draw.Src)
+	}
+	return img, nil
+}
+
+// encodeModules 生成每个模块一个像素、不带空白的码, 返回码四周的空白模块数和是否为2D码
+func encodeModules(content, codeType string, opts CodeOptions) (*gozxing.BitMatrix, int, bool, error) {
+	var (
+		matrix *gozxing.BitMatrix
+		margin int
+		twoD   bool
+		err    error
+	)
+	switch codeType {
+	case common.CodeTypeQRCode:
+		margin, twoD = 4, true
+		hints := map[gozxing.EncodeHintType]interface{}{
+			gozxing.EncodeHintType_CHARACTER_SET: "UTF-8",
+			gozxing.EncodeHintType_MARGIN:        0,
+		}
+		if opts.ErrorCorrection != "" {
+			hints[gozxing.EncodeHintType_ERROR_CORRECTION] = strings.ToUpper(opts.ErrorCorrection)
+		}
+		matrix, err = qrcode.NewQRCodeWriter().Encode(content, gozxing.BarcodeFormat_QR_CODE, 0, 0, hints)
+	case common.CodeTypeDataMatrix:
+		margin, twoD = 1, true
+		hints := map[gozxing.EncodeHintType]interface{}{
+			gozxing.EncodeHintType_DATA_MATRIX_SHAPE: dmencoder.SymbolShapeHint_FORCE_SQUARE,
+		}
+		matrix, err = datamatrix.NewDataMatrixWriter().Encode(content, gozxing.BarcodeFormat_DATA_MATRIX, 0, 0, hints)
+	case common.CodeTypeBarcode128, common.CodeTypeEAN13, common.CodeTypeEAN8:
+		margin = 10
+		hints := map[gozxing.EncodeHintType]interface{}{
+			gozxing.EncodeHintType_MARGIN: 0,
+		}
+		switch codeType {
+		case common.CodeTypeBarcode128:
+			matrix, err = oned.NewCode128Writer().Encode(content, gozxing.BarcodeFormat_CODE_128, 0, 0, hints)
+		case common.CodeTypeEAN13:
+			matrix, err = oned.NewEAN13Writer().Encode(content, gozxing.BarcodeFormat_EAN_13, 0, 0, hints)
+		default:
+			matrix, err = oned.NewEAN8Writer().Encode(content, gozxing.BarcodeFormat_EAN_8, 0, 0, hints)
+		}
+	case common.CodeTypePDF417:
+		margin, twoD = 2, true
+		matrix, err = encodePdf417(content, opts.ErrorCorrection)
+	default:
+		return nil, 0, false, errors.Errorf(nil, "暂不支持的code类型:%s", codeType)
+	}
+	if err != nil {
+		return nil, 0, false, errors.Errorf(err, "encode %s failed", codeType)
+	}
+
+	if opts.Margin >= 0 {
+		margin = opts.Margin
+	}
+	return matrix, margin, twoD, nil
+}
+
+// pdf417SecurityLevels 二维码纠错等级对应的PDF417安全等级
+var pdf417SecurityLevels = map[string]byte{"": 2, "L": 2, "M": 3, "Q": 4, "H": 5}
+
+// encodePdf417 gozxing没有pdf417的编码器, 用boombuler/barcode生成后转换为BitMatrix;
+// 生成的图片每个模块宽1个像素、高2个像素, 保留原来的宽高比
+func encodePdf417(content, errorCorrection string) (*gozxing.BitMatrix, error) {
+	level, ok := pdf417SecurityLevels[strings.ToUpper(errorCorrection)]
+	if !ok {
+		return nil, errors.Errorf(nil, "不支持的纠错等级:%s", errorCorrection)
+	}
+	code, err := pdf417.Encode(content, level)
+	if err != nil {
+		return nil, err
+	}
+
+	b := code.Bounds()
+	matrix, err := gozxing.NewBitMatrix(b.Dx(), b.Dy())
+	if err != nil {
+		return nil, err
+	}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if r, _, _, _ := code.At(x, y).RGBA(); r == 0 {
+				matrix.Set(x-b.Min.X, y-b.Min.Y)
+			}
+		}
+	}
+	return matrix, nil
+}
+
+// renderCaption 把文字按整数倍放大到不超过码宽度的90%, 文字高度不超过码宽度的1/8
+func renderCaption(text string, width int) *image.Gray {
+	textWidth := len(text) * captionCharWidth
+	scale := width * 9 / 10 / textWidth
+	if max := width / 8 / captionCharHeight; scale > max {
+		scale = max
+	}
+	if scale < 1 {
+		scale = 1
+	}
+
+	small := image.NewGray(image.Rect(0, 0, textWidth, captionCharHeight))
+	draw.Draw(small, small.Bounds(), image.NewUniform(color.Gray{Y: 255}), image.Point{}, draw.Src)
+	DrawText(small, 0, captionAscent, text, color.Gray{Y: 0})
+
+	// 上下各留出半行的空白
+	pad := captionCharHeight * scale / 2
+	caption := image.NewGray(image.Rect(0, 0, textWidth*scale, captionCharHeight*scale+pad))
+	draw.Draw(caption, caption.Bounds(), image.NewUniform(color.Gray{Y: 255}), image.Point{}, draw.Src)
+	for y := 0; y < captionCharHeight*scale; y++ {
+		for x := 0; x < textWidth*scale; x++ {
+			caption.SetGray(x, y, small.GrayAt(x/scale, y/scale))
+		}
+	}
+	return caption
+}
@@ -0,0 +1,177 @@
+package util
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"invtools/common"
+)
+
+func TestEncodeCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		codeType string
+		width    int
+		height   int
+		wantErr  bool
+	}{
+		{name: "qrcode", content: "https://example.com/voucher?id=123", codeType: common.CodeTypeQRCode, width: 300, height: 400},
+		{name: "qrcode utf8", content: "订单 123", codeType: common.CodeTypeQRCode, width: 200, height: 200},
+		{name: "barcode128", content: "ORDER-2020-0001", codeType: common.CodeTypeBarcode128, width: 400, height: 100},
+		{name: "datamatrix", content: "ORDER-2020-0001", codeType: common.CodeTypeDataMatrix, width: 200, height: 200},
+		{name: "ean13", content: "6901234567892", codeType: common.CodeTypeEAN13, width: 300, height: 100},
+		{name: "ean8", content: "96385074", codeType: common.CodeTypeEAN8, width: 200, height: 80},
+		{name: "ean13 bad checksum", content: "6901234567890", codeType: common.CodeTypeEAN13, width: 300, height: 100, wantErr: true},
+		{name: "empty", content: "", codeType: common.CodeTypeQRCode, width: 200, height: 200, wantErr: true},
+		{name: "unknown type", content: "abc", codeType: "aztec", width: 200, height: 200, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := EncodeCode(tt.content, tt.codeType, tt.width, tt.height)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EncodeCode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got, _, err := LocateCode(img, tt.codeType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.content {
+				t.Errorf("decoded = %q, want %q", got, tt.content)
+			}
+		})
+	}
+}
+
+func TestEncodeCodeWithOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		codeType string
+		opts     CodeOptions
+		want     image.Rectangle
+		wantErr  bool
+	}{
+		{
+			name:     "qrcode is square",
+			codeType: common.CodeTypeQRCode,
+			opts:     CodeOptions{Width: 300, Height: 200, Margin: DefaultCodeMargin, ErrorCorrection: "h"},
+			want:     image.Rect(0, 0, 200, 200),
+		},
+		{
+			name:     "too small grows to modules",
+			codeType: common.CodeTypeQRCode,
+			opts:     CodeOptions{Width: 10, Height: 10, Margin: 0},
+			want:     image.Rect(0, 0, 21, 21),
+		},
+		{
+			name:     "text below barcode",
+			codeType: common.CodeTypeBarcode128,
+			opts:     CodeOptions{Width: 400, Height: 100, Margin: DefaultCodeMargin, Text: "A001"},
+			want:     image.Rect(0, 0, 400, 100+13*3+13*3/2),
+		},
+		{
+			name:     "bad error correction",
+			codeType: common.CodeTypeQRCode,
+			opts:     CodeOptions{Width: 200, Height: 200, ErrorCorrection: "X"},
+			wantErr:  true,
+		},
+		{
+			name:     "non ascii text",
+			codeType: common.CodeTypeQRCode,
+			opts:     CodeOptions{Width: 200, Height: 200, Text: "订单"},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := EncodeCodeWithOptions("A001", tt.codeType, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EncodeCodeWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if img.Bounds() != tt.want {
+				t.Errorf("bounds = %v, want %v", img.Bounds(), tt.want)
+			}
+			got, _, err := LocateCode(img, tt.codeType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != "A001" {
+				t.Errorf("decoded = %q, want %q", got, "A001")
+			}
+		})
+	}
+}
+
+// gozxing没有pdf417的解码器, 只检查图片大小以及码是否按模块整数倍放大后居中
+func TestEncodeCodePdf417(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		opts    CodeOptions
+		want    image.Rectangle
+		wantErr bool
+	}{
+		{name: "keeps requested size", content: "ORDER-2020-0001", opts: CodeOptions{Width: 600, Height: 300, Margin: DefaultCodeMargin}, want: image.Rect(0, 0, 600, 300)},
+		{name: "high security", content: "ORDER-2020-0001", opts: CodeOptions{Width: 600, Height: 300, Margin: 0, ErrorCorrection: "H"}, want: image.Rect(0, 0, 600, 300)},
+		{name: "bad error correction", content: "ORDER-2020-0001", opts: CodeOptions{Width: 600, Height: 300, ErrorCorrection: "X"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := EncodeCodeWithOptions(tt.content, common.CodeTypePDF417, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EncodeCodeWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if img.Bounds() != tt.want {
+				t.Fatalf("bounds = %v, want %v", img.Bounds(), tt.want)
+			}
+
+			matrix, _, _, err := encodeModules(tt.content, common.CodeTypePDF417, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// 第一列是起始符的条, 一定是黑色
+			if !matrix.Get(0, 0) {
+				t.Fatal("start pattern missing")
+			}
+			gray := img.(*image.Gray)
+			black := color.Gray{Y: 0}
+			var minX, minY, maxX, maxY = tt.want.Dx(), tt.want.Dy(), -1, -1
+			for y := 0; y < tt.want.Dy(); y++ {
+				for x := 0; x < tt.want.Dx(); x++ {
+					if gray.GrayAt(x, y) != black {
+						continue
+					}
+					if x < minX {
+						minX = x
+					}
+					if y < minY {
+						minY = y
+					}
+					if x > maxX {
+						maxX = x
+					}
+					if y > maxY {
+						maxY = y
+					}
+				}
+			}
+			w, h := maxX-minX+1, maxY-minY+1
+			if w%matrix.GetWidth() != 0 || h%matrix.GetHeight() != 0 || w/matrix.GetWidth() != h/matrix.GetHeight() {
+				t.Errorf("code size = %dx%d, modules = %dx%d", w, h, matrix.GetWidth(), matrix.GetHeight())
+			}
+			if d := (tt.want.Dx() - w) - 2*minX; d < -1 || d > 1 {
+				t.Errorf("code is not centered, left = %d, width = %d", minX, w)
+			}
+		})
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"invtools/utils/errors"
 
 	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/datamatrix"
 	"github.com/makiuchi-d/gozxing/oned"
 	"github.com/makiuchi-d/gozxing/qrcode"
 	multiqrcode "github.com/makiuchi-d/gozxing/multi/qrcode"
@@ -108,3 +109,44 @@ func Barcode128Scan(input string) (code string, err error) {
 
 	return result.String(), nil
 }
+
+// LocateCode 扫描图片中的二维码/条形码, 同时返回码在图片中的位置
+func LocateCode(img image.Image, codeType string) (string, image.Rectangle, error) {
+	bmp, err := gozxing.NewBinaryBitmapFromImage(img)
+	if err != nil {
+		return "", image.Rectangle{}, errors.Errorf(err, "gozxing NewBinaryBitmapFromImage failed ")
+	}
+
+	var reader gozxing.Reader
+	switch codeType {
+	case common.CodeTypeQRCode:
+		reader = qrcode.NewQRCodeReader()
+	case common.CodeTypeBarcode128:
+		reader = oned.NewCode128Reader()
+	case common.CodeTypeDataMatrix:
+		reader = datamatrix.NewDataMatrixReader()
+	case common.CodeTypeEAN13:
+		reader = oned.NewEAN13Reader()
+	case common.CodeTypeEAN8:
+		reader = oned.NewEAN8Reader()
+	default:
+		return "", image.Rectangle{}, errors.Errorf(nil, "暂不支持的code类型:%s", codeType)
+	}
+
+	result, err := reader.Decode(bmp, nil)
+	if err != nil {
+		return "", image.Rectangle{}, errors.Errorf(err, "gozxing decode %s failed", codeType)
+	}
+
+	var box image.Rectangle
+	for _, p := range result.GetResultPoints() {
+		pt := image.Pt(int(p.GetX()), int(p.GetY()))
+		box = box.Union(image.Rectangle{Min: pt, Max: pt.Add(image.Pt(1, 1))})
+	}
+	// 条形码只返回两端的点, 上下留出一些高度便于查看
+	if box.Dy() < 10 {
+		box.Min.Y -= 10
+		box.Max.Y += 10
+	}
+	return result.String(), box.Add(img.Bounds().Min).Intersect(img.Bounds()), nil
+}
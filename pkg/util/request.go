@@ -0,0 +1,344 @@
+package util
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"invtools/utils/errors"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// netscapeHttpOnlyPrefix curl等工具导出的cookie文件中, httpOnly的cookie以此为前缀
+const netscapeHttpOnlyPrefix = "#HttpOnly_"
+
+// RequestOptions 访问url时携带的cookie、header、basic auth和代理, 下载和打印共用
+type RequestOptions struct {
+	Cookies  []*http.Cookie
+	Headers  map[string]string
+	Username string // basic auth
+	Password string
+	Proxy    string // 例如: http://127.0.0.1:8080
+
+	jar http.CookieJar
+}
+
+// NewRequestOptions cookieFile为Netscape格式的cookie文件, headers每一项为"Key: Value", basicAuth为"user:password"
+func NewRequestOptions(cookieFile string, headers []string, basicAuth, proxy string) (*RequestOptions, error) {
+	o := &RequestOptions{
+		Headers: make(map[string]string),
+		Proxy:   proxy,
+	}
+
+	if cookieFile != "" {
+		cookies, err := LoadNetscapeCookies(cookieFile)
+		if err != nil {
+			return nil, errors.Errorf(err, "读取cookie文件失败")
+		}
+		o.Cookies = cookies
+	}
+
+	for _, h := range headers {
+		kv := strings.SplitN(h, ":", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, errors.Errorf(nil, "header格式不合法, 需要为\"Key: Value\", header:%s", h)
+		}
+		o.Headers[http.CanonicalHeaderKey(strings.TrimSpace(kv[0]))] = strings.TrimSpace(kv[1])
+	}
+
+	if basicAuth != "" {
+		kv := strings.SplitN(basicAuth, ":", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, errors.Errorf(nil, "basic auth格式不合法, 需要为\"user:password\"")
+		}
+		o.Username, o.Password = kv[0], kv[1]
+	}
+
+	if proxy != "" {
+		if u, err := url.Parse(proxy); err != nil || u.Host == "" {
+			return nil, errors.Errorf(err, "代理地址不合法, proxy:%s", proxy)
+		}
+	}
+
+	if err := o.initJar(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// initJar 按cookie的domain放入cookie jar, 请求时由jar决定发送哪些cookie
+func (o *RequestOptions) initJar() error {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return errors.Errorf(err, "create cookie jar failed")
+	}
+	for _, c := range o.Cookies {
+		scheme := "http"
+		if c.Secure {
+			scheme = "https"
+		}
+		jar.SetCookies(&url.URL{Scheme: scheme, Host: strings.TrimPrefix(c.Domain, "."), Path: c.Path}, []*http.Cookie{c})
+	}
+	o.jar = jar
+	return nil
+}
+
+// LoadNetscapeCookies 读取Netscape格式的cookie文件(浏览器插件、curl -c导出的格式), 每行以tab分隔:
+// domain include_subdomains path secure expires name value
+func LoadNetscapeCookies(file string) ([]*http.Cookie, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, errors.Errorf(err, "open cookie file failed, file:%s", file)
+	}
+	defer f.Close()
+
+	var (
+		cookies []*http.Cookie
+		lineNum int
+		scanner = bufio.NewScanner(f)
+	)
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+
+		httpOnly := strings.HasPrefix(line, netscapeHttpOnlyPrefix)
+		if httpOnly {
+			line = strings.TrimPrefix(line, netscapeHttpOnlyPrefix)
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			return nil, errors.Errorf(nil, "cookie文件第%d行格式不合法, 需要7列以tab分隔", lineNum)
+		}
+
+		c := &http.Cookie{
+			Domain:   fields[0],
+			Path:     fields[2],
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			Name:     fields[5],
+			Value:    fields[6],
+			HttpOnly: httpOnly,
+		}
+		// include_subdomains为TRUE时domain以.开头
+		if strings.EqualFold(fields[1], "TRUE") && !strings.HasPrefix(c.Domain, ".") {
+			c.Domain = "." + c.Domain
+		}
+		expires, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return nil, errors.Errorf(err, "cookie文件第%d行过期时间不合法", lineNum)
+		}
+		// 0表示会话cookie
+		if expires > 0 {
+			c.Expires = time.Unix(expires, 0)
+		}
+		cookies = append(cookies, c)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Errorf(err, "read cookie file failed")
+	}
+	return cookies, nil
+}
+
+// HTTPClient 带cookie jar和代理的http client
+func (o *RequestOptions) HTTPClient(timeout time.Duration) (*http.Client, error) {
+	transport := &http.Transport{Proxy: http.ProxyFromEnvironment}
+	if o != nil && o.Proxy != "" {
+		u, err := url.Parse(o.Proxy)
+		if err != nil {
+			return nil, errors.Errorf(err, "parse proxy failed")
+		}
+		transport.Proxy = http.ProxyURL(u)
+	}
+
+	client := &http.Client{Transport: transport, Timeout: timeout}
+	if o != nil {
+		client.Jar = o.jar
+	}
+	return client, nil
+}
+
+// Apply 设置请求的header和basic auth
+func (o *RequestOptions) Apply(req *http.Request) {
+	if o == nil {
+		return
+	}
+	for k, v := range o.Headers {
+		req.Header.Set(k, v)
+	}
+	if o.Username != "" {
+		req.SetBasicAuth(o.Username, o.Password)
+	}
+}
+
+// cookiesFor 返回访问u时需要携带的cookie
+func (o *RequestOptions) cookiesFor(u *url.URL) []*http.Cookie {
+	if o == nil || o.jar == nil {
+		return nil
+	}
+	return o.jar.Cookies(u)
+}
+
+// extraHeaders 所有需要额外发送的header, 包括basic auth
+func (o *RequestOptions) extraHeaders() map[string]string {
+	headers := make(map[string]string)
+	if o == nil {
+		return headers
+	}
+	for k, v := range o.Headers {
+		headers[k] = v
+	}
+	if o.Username != "" {
+		req := &http.Request{Header: make(http.Header)}
+		req.SetBasicAuth(o.Username, o.Password)
+		headers["Authorization"] = req.Header.Get("Authorization")
+	}
+	return headers
+}
+
+// chromedpTasks 在打开页面之前设置cookie和header, header和basic auth只在请求target的host时携带,
+// 不能用network.SetExtraHTTPHeaders, 它会把凭证发给页面引用的所有域名(cdn、统计等)
+func (o *RequestOptions) chromedpTasks(target string) chromedp.Tasks {
+	if o == nil {
+		return nil
+	}
+
+	tasks := chromedp.Tasks{network.Enable()}
+	if headers := o.extraHeaders(); len(headers) > 0 {
+		tasks = append(tasks,
+			chromedp.ActionFunc(func(ctx context.Context) error {
+				chromedp.ListenTarget(ctx, func(ev interface{}) {
+					if ev, ok := ev.(*fetch.EventRequestPaused); ok {
+						// 事件回调中不能阻塞, 需要在新的goroutine中放行请求
+						go continueRequest(ctx, ev, target, headers)
+					}
+				})
+				return nil
+			}),
+			// 拦截所有请求, 由continueRequest决定是否加上header
+			fetch.Enable(),
+		)
+	}
+	for _, c := range o.Cookies {
+		params := network.SetCookie(c.Name, c.Value).
+			WithDomain(c.Domain).
+			WithPath(c.Path).
+			WithSecure(c.Secure).
+			WithHTTPOnly(c.HttpOnly)
+		if !c.Expires.IsZero() {
+			expires := cdp.TimeSinceEpoch(c.Expires)
+			params = params.WithExpires(&expires)
+		}
+		tasks = append(tasks, chromedp.ActionFunc(func(ctx context.Context) error {
+			ok, err := params.Do(ctx)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return errors.Errorf(nil, "set cookie failed, name:%s, domain:%s", params.Name, params.Domain)
+			}
+			return nil
+		}))
+	}
+	return tasks
+}
+
+// continueRequest 放行被拦截的请求, 只有与target同host的请求才加上额外的header
+func continueRequest(ctx context.Context, ev *fetch.EventRequestPaused, target string, headers map[string]string) {
+	params := fetch.ContinueRequest(ev.RequestID)
+	if entries := headersFor(ev.Request, target, headers); entries != nil {
+		params = params.WithHeaders(entries)
+	}
+	// tab关闭或超时后放行失败, 不影响打印结果
+	_ = params.Do(ctx)
+}
+
+// headersFor 返回请求与target同host时需要发送的全部header(原有header加上额外的header), 不同host返回nil
+func headersFor(req *network.Request, target string, headers map[string]string) []*fetch.HeaderEntry {
+	if req == nil || !sameHost(req.URL, target) {
+		return nil
+	}
+
+	merged := make(map[string]string)
+	for k, v := range req.Headers {
+		merged[http.CanonicalHeaderKey(k)] = fmt.Sprint(v)
+	}
+	for k, v := range headers {
+		merged[http.CanonicalHeaderKey(k)] = v
+	}
+
+	entries := make([]*fetch.HeaderEntry, 0, len(merged))
+	for k, v := range merged {
+		entries = append(entries, &fetch.HeaderEntry{Name: k, Value: v})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries
+}
+
+// sameHost a和b的host(包括端口)是否相同, 解析失败时视为不同
+func sameHost(a, b string) bool {
+	ua, err := url.Parse(a)
+	if err != nil || ua.Host == "" {
+		return false
+	}
+	ub, err := url.Parse(b)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(ua.Host, ub.Host)
+}
+
+// isCredentialHeader header是否携带凭证, 例如Authorization、Cookie、X-Auth-Token
+func isCredentialHeader(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range []string{"auth", "cookie", "token", "session", "secret", "api-key", "apikey"} {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// wkhtmltopdfArgs 转换为wkhtmltopdf的参数, cookie只携带与u匹配的
+func (o *RequestOptions) wkhtmltopdfArgs(u *url.URL) []string {
+	if o == nil {
+		return nil
+	}
+
+	var args []string
+	propagation := len(o.Headers) > 0
+	for k, v := range o.Headers {
+		args = append(args, "--custom-header", k, v)
+		if isCredentialHeader(k) {
+			propagation = false
+		}
+	}
+	if propagation {
+		// 页面中的资源请求也携带header, 带凭证的header不传播, 避免发给第三方域名
+		args = append(args, "--custom-header-propagation")
+	}
+	for _, c := range o.cookiesFor(u) {
+		args = append(args, "--cookie", c.Name, c.Value)
+	}
+	if o.Username != "" {
+		args = append(args, "--username", o.Username, "--password", o.Password)
+	}
+	if o.Proxy != "" {
+		args = append(args, "--proxy", o.Proxy)
+	}
+	return args
+}
@@ -0,0 +1,194 @@
+package util
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+const testCookieFile = `# Netscape HTTP Cookie File
+.example.com	TRUE	/	FALSE	0	session	abc
+#HttpOnly_www.example.com	FALSE	/	TRUE	2000000000	token	xyz
+other.com	FALSE	/	FALSE	0	foo	bar
+`
+
+func writeTempFile(t *testing.T, content string) string {
+	f, err := ioutil.TempFile("", "request_*.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(content)
+	f.Close()
+	return f.Name()
+}
+
+func TestLoadNetscapeCookies(t *testing.T) {
+	file := writeTempFile(t, testCookieFile)
+	defer os.Remove(file)
+
+	cookies, err := LoadNetscapeCookies(file)
+	if err != nil {
+		t.Fatalf("LoadNetscapeCookies() error = %v", err)
+	}
+	if len(cookies) != 3 {
+		t.Fatalf("LoadNetscapeCookies() got %d cookies, want 3", len(cookies))
+	}
+
+	token := cookies[1]
+	if token.Name != "token" || token.Domain != "www.example.com" || !token.HttpOnly || !token.Secure || token.Expires.Unix() != 2000000000 {
+		t.Errorf("unexpected cookie: %+v", token)
+	}
+	if !cookies[0].Expires.IsZero() {
+		t.Errorf("session cookie should not expire: %+v", cookies[0])
+	}
+
+	bad := writeTempFile(t, "example.com\tTRUE\t/\n")
+	defer os.Remove(bad)
+	if _, err := LoadNetscapeCookies(bad); err == nil {
+		t.Errorf("LoadNetscapeCookies() expect error for invalid line")
+	}
+}
+
+func TestNewRequestOptions(t *testing.T) {
+	tests := []struct {
+		name      string
+		headers   []string
+		basicAuth string
+		proxy     string
+		wantErr   bool
+	}{
+		{name: "ok", headers: []string{"x-token: a,b", "Accept: */*"}, basicAuth: "user:pa:ss", proxy: "http://127.0.0.1:8080"},
+		{name: "bad header", headers: []string{"no colon"}, wantErr: true},
+		{name: "bad basic auth", basicAuth: "user", wantErr: true},
+		{name: "bad proxy", proxy: "127.0.0.1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := NewRequestOptions("", tt.headers, tt.basicAuth, tt.proxy)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewRequestOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if o.Headers["X-Token"] != "a,b" || o.Password != "pa:ss" {
+				t.Errorf("unexpected options: %+v", o)
+			}
+		})
+	}
+}
+
+func TestRequestOptionsHTTP(t *testing.T) {
+	file := writeTempFile(t, testCookieFile)
+	defer os.Remove(file)
+
+	var got *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+	}))
+	defer srv.Close()
+
+	o, err := NewRequestOptions(file, []string{"X-Token: abc"}, "user:pass", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 把cookie的域名换成测试服务器
+	srvURL, _ := url.Parse(srv.URL)
+	o.Cookies[0].Domain = srvURL.Hostname()
+	if err := o.initJar(); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := o.HTTPClient(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	o.Apply(req)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if got.Header.Get("X-Token") != "abc" {
+		t.Errorf("header not sent: %v", got.Header)
+	}
+	if user, pass, ok := got.BasicAuth(); !ok || user != "user" || pass != "pass" {
+		t.Errorf("basic auth not sent: %v", got.Header)
+	}
+	if c, err := got.Cookie("session"); err != nil || c.Value != "abc" {
+		t.Errorf("cookie not sent: %v", got.Header)
+	}
+	if _, err := got.Cookie("foo"); err == nil {
+		t.Errorf("cookie of other domain should not be sent")
+	}
+
+	// wkhtmltopdf只携带匹配的cookie
+	args := strings.Join(o.wkhtmltopdfArgs(srvURL), " ")
+	if !strings.Contains(args, "--cookie session abc") || strings.Contains(args, "foo") {
+		t.Errorf("unexpected wkhtmltopdf args: %s", args)
+	}
+}
+
+func TestHeadersFor(t *testing.T) {
+	headers := map[string]string{"Authorization": "Basic dXNlcjpwYXNz", "X-Token": "abc"}
+	tests := []struct {
+		name   string
+		url    string
+		target string
+		want   string
+	}{
+		{name: "same host", url: "https://www.example.com/a.css", target: "https://www.example.com/page", want: "Authorization=Basic dXNlcjpwYXNz Referer=https://www.example.com/page X-Token=abc"},
+		{name: "host case insensitive", url: "https://WWW.example.com/a.js", target: "https://www.example.com/page", want: "Authorization=Basic dXNlcjpwYXNz Referer=https://www.example.com/page X-Token=abc"},
+		{name: "third party", url: "https://cdn.other.com/a.js", target: "https://www.example.com/page"},
+		{name: "subdomain", url: "https://static.example.com/a.js", target: "https://www.example.com/page"},
+		{name: "other port", url: "https://www.example.com:8443/a.js", target: "https://www.example.com/page"},
+		{name: "data url", url: "data:image/png;base64,AAAA", target: "https://www.example.com/page"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &network.Request{URL: tt.url, Headers: network.Headers{"referer": "https://www.example.com/page"}}
+			var got []string
+			for _, e := range headersFor(req, tt.target, headers) {
+				got = append(got, e.Name+"="+e.Value)
+			}
+			if strings.Join(got, " ") != tt.want {
+				t.Errorf("headersFor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequestOptions_wkhtmltopdfArgs(t *testing.T) {
+	u := &url.URL{Scheme: "https", Host: "www.example.com"}
+	tests := []struct {
+		name            string
+		headers         []string
+		wantPropagation bool
+	}{
+		{name: "no header"},
+		{name: "plain header", headers: []string{"Accept-Language: zh-CN"}, wantPropagation: true},
+		{name: "authorization", headers: []string{"Accept-Language: zh-CN", "Authorization: Bearer abc"}},
+		{name: "cookie", headers: []string{"Cookie: a=b"}},
+		{name: "token", headers: []string{"X-Auth-Token: abc"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := NewRequestOptions("", tt.headers, "", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			args := strings.Join(o.wkhtmltopdfArgs(u), " ")
+			if got := strings.Contains(args, "--custom-header-propagation"); got != tt.wantPropagation {
+				t.Errorf("wkhtmltopdfArgs() = %s, wantPropagation %v", args, tt.wantPropagation)
+			}
+		})
+	}
+}
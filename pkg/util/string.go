@@ -36,3 +36,52 @@ func StringPurify(src string)string{
 	ret = strings.NewReplacer("\n", "").Replace(src)
 	return ret
 }
+
+// NaturalLess 自然排序, 连续的数字按数值比较, 例如 voucher_2.pdf 排在 voucher_10.pdf 之前, 字母不区分大小写
+func NaturalLess(a, b string) bool {
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		ca, cb := a[i], b[j]
+		if isDigit(ca) && isDigit(cb) {
+			si, sj := i, j
+			for i < len(a) && isDigit(a[i]) {
+				i++
+			}
+			for j < len(b) && isDigit(b[j]) {
+				j++
+			}
+			na := strings.TrimLeft(a[si:i], "0")
+			nb := strings.TrimLeft(b[sj:j], "0")
+			if len(na) != len(nb) {
+				return len(na) < len(nb)
+			}
+			if na != nb {
+				return na < nb
+			}
+			continue
+		}
+
+		la, lb := toLowerASCII(ca), toLowerASCII(cb)
+		if la != lb {
+			return la < lb
+		}
+		i++
+		j++
+	}
+	if len(a)-i != len(b)-j {
+		return len(a)-i < len(b)-j
+	}
+	// 只有大小写或者数字前面的0不同时按原字符串比较, 保证排序稳定
+	return a < b
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func toLowerASCII(c byte) byte {
+	if c >= 'A' && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
@@ -32,3 +32,24 @@ func BenchmarkStr2Byte(b *testing.B) {
 		_ = []byte(ts)
 	}
 }
+
+func TestNaturalLess(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{a: "voucher_2.pdf", b: "voucher_10.pdf", want: true},
+		{a: "voucher_10.pdf", b: "voucher_2.pdf", want: false},
+		{a: "page1", b: "Page2", want: true},
+		{a: "a", b: "ab", want: true},
+		{a: "a01", b: "a1", want: true},
+		{a: "a1", b: "a01", want: false},
+		{a: "b", b: "a10", want: false},
+		{a: "same", b: "same", want: false},
+	}
+	for _, tt := range tests {
+		if got := NaturalLess(tt.a, tt.b); got != tt.want {
+			t.Errorf("NaturalLess(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
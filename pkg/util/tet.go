@@ -2,9 +2,14 @@ package util
 
 import (
 	"context"
+	"encoding/xml"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"math"
+	"os"
 	"os/exec"
+	"strconv"
 	"time"
 
 	"invtools/utils/errors"
@@ -36,3 +41,120 @@ func ExtractTextByCoordinate(input, coordinate, output string) (string, error) {
 	ret := StringPurify(string(b))
 	return ret, nil
 }
+
+// TetWord tet解析出来的单词及其坐标, 坐标系与tet的includebox一致(原点在页面左下角,单位point)
+type TetWord struct {
+	Text string  `json:"text"`
+	Llx  float64 `json:"llx"`
+	Lly  float64 `json:"lly"`
+	Urx  float64 `json:"urx"`
+	Ury  float64 `json:"ury"`
+}
+
+// TetPage tet解析出来的单页信息
+type TetPage struct {
+	Number int        `json:"number"`
+	Width  float64    `json:"width"`
+	Height float64    `json:"height"`
+	Words  []*TetWord `json:"words"`
+}
+
+// ExtractWordsWithBox 使用tet输出tetml(word模式), 得到每一页的单词及其坐标
+func ExtractWordsWithBox(input, output string) ([]*TetPage, error) {
+	cmdTpl := fmt.Sprintf(`/usr/local/bin/tet --tetml word -o %s %s`, output, input)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 180*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", cmdTpl)
+	err := cmd.Run()
+	if err != nil {
+		return nil, errors.Errorf(err, "tet extract tetml failed, cmd:%s", cmdTpl)
+	}
+
+	f, err := os.Open(output)
+	if err != nil {
+		return nil, errors.Errorf(err, "open tet output file failed, file:%s", output)
+	}
+	defer f.Close()
+
+	return ParseTETML(f)
+}
+
+// ParseTETML 解析tetml, 只关心Page和Word节点
+func ParseTETML(r io.Reader) ([]*TetPage, error) {
+	type tetmlBox struct {
+		Llx float64 `xml:"llx,attr"`
+		Lly float64 `xml:"lly,attr"`
+		Urx float64 `xml:"urx,attr"`
+		Ury float64 `xml:"ury,attr"`
+	}
+	type tetmlWord struct {
+		Text  string     `xml:"Text"`
+		Boxes []tetmlBox `xml:"Box"`
+	}
+
+	var (
+		pages []*TetPage
+		page  *TetPage
+		dec   = xml.NewDecoder(r)
+	)
+	for {
+		token, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Errorf(err, "decode tetml failed")
+		}
+
+		se, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch se.Name.Local {
+		case "Page":
+			page = &TetPage{}
+			for _, attr := range se.Attr {
+				switch attr.Name.Local {
+				case "number":
+					page.Number, _ = strconv.Atoi(attr.Value)
+				case "width":
+					page.Width, _ = strconv.ParseFloat(attr.Value, 64)
+				case "height":
+					page.Height, _ = strconv.ParseFloat(attr.Value, 64)
+				}
+			}
+			pages = append(pages, page)
+		case "Word":
+			if page == nil {
+				return nil, errors.Errorf(nil, "tetml中的Word节点不在Page节点中")
+			}
+			var w tetmlWord
+			if err := dec.DecodeElement(&w, &se); err != nil {
+				return nil, errors.Errorf(err, "decode tetml word failed")
+			}
+			if len(w.Boxes) == 0 {
+				continue
+			}
+
+			// 一个单词可能被拆成多个box(比如跨行的连字符), 取并集
+			word := &TetWord{
+				Text: w.Text,
+				Llx:  w.Boxes[0].Llx,
+				Lly:  w.Boxes[0].Lly,
+				Urx:  w.Boxes[0].Urx,
+				Ury:  w.Boxes[0].Ury,
+			}
+			for _, b := range w.Boxes[1:] {
+				word.Llx = math.Min(word.Llx, b.Llx)
+				word.Lly = math.Min(word.Lly, b.Lly)
+				word.Urx = math.Max(word.Urx, b.Urx)
+				word.Ury = math.Max(word.Ury, b.Ury)
+			}
+			page.Words = append(page.Words, word)
+		}
+	}
+
+	return pages, nil
+}
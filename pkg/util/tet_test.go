@@ -52,3 +52,43 @@ func TestExtractTextByCoordinate(t *testing.T) {
 		})
 	}
 }
+
+func TestParseTETML(t *testing.T) {
+	tetml := `<?xml version="1.0" encoding="UTF-8"?>
+<TET xmlns="http://www.pdflib.com/XML/TET5/TET-5.0">
+<Document filename="voucher.pdf">
+<Pages>
+<Page number="1" width="595.32" height="841.92">
+<Content granularity="word">
+<Para>
+<Word><Text>Booking</Text><Box llx="40.00" lly="700.00" urx="80.50" ury="710.00"/></Word>
+<Word><Text>No.:</Text><Box llx="83.00" lly="700.00" urx="101.00" ury="710.00"/></Word>
+<Word><Text>ABC-</Text><Box llx="110.00" lly="700.00" urx="140.00" ury="710.00"/><Box llx="40.00" lly="688.00" urx="50.00" ury="698.00"/></Word>
+</Para>
+</Content>
+</Page>
+</Pages>
+</Document>
+</TET>`
+
+	pages, err := ParseTETML(strings.NewReader(tetml))
+	if err != nil {
+		t.Fatalf("ParseTETML() error = %v", err)
+	}
+	if len(pages) != 1 {
+		t.Fatalf("ParseTETML() got %d pages, want 1", len(pages))
+	}
+
+	page := pages[0]
+	if page.Number != 1 || page.Width != 595.32 || page.Height != 841.92 {
+		t.Errorf("ParseTETML() got page = %+v", page)
+	}
+	if len(page.Words) != 3 {
+		t.Fatalf("ParseTETML() got %d words, want 3", len(page.Words))
+	}
+
+	want := TetWord{Text: "ABC-", Llx: 40, Lly: 688, Urx: 140, Ury: 710}
+	if got := *page.Words[2]; got != want {
+		t.Errorf("ParseTETML() got word = %+v, want %+v", got, want)
+	}
+}
@@ -3,7 +3,10 @@ package util
 import (
 	"bytes"
 	"fmt"
+	"image"
 	"image/jpeg"
+	"io"
+	"io/ioutil"
 	"os"
 	"path"
 	"strings"
@@ -12,7 +15,10 @@ import (
 
 	rscPdf "github.com/rsc.io/pdf"
 	//"github.com/unidoc/unidoc/common/license"
+	unicontentstream "github.com/unidoc/unipdf/v3/contentstream"
+	unicore "github.com/unidoc/unipdf/v3/core"
 	unisecurity "github.com/unidoc/unipdf/v3/core/security"
+	unicreator "github.com/unidoc/unipdf/v3/creator"
 	uniextractor "github.com/unidoc/unipdf/v3/extractor"
 	unipdf "github.com/unidoc/unipdf/v3/model"
 )
@@ -106,6 +112,11 @@ func readPDF(filename, password string) (*unipdf.PdfReader, int, bool, unisecuri
 	}
 	defer f.Close()
 
+	return newPDFReader(f, password)
+}
+
+// newPDFReader 读取并解密pdf, 密码的尝试顺序见CandidatePasswords; 需要延迟解析对象(例如书签指向的命名目标)时调用方需要保持f打开
+func newPDFReader(f io.ReadSeeker, password string) (*unipdf.PdfReader, int, bool, unisecurity.Permissions, error) {
 	// Read input file.
 	r, err := unipdf.NewPdfReader(f)
 	if err != nil {
@@ -118,33 +129,19 @@ func readPDF(filename, password string) (*unipdf.PdfReader, int, bool, unisecuri
 		return nil, 0, false, 0, err
 	}
 
-	// Decrypt using the specified password, if necessary.
+	// Decrypt using the specified password or the password list, if necessary.
 	perms := unisecurity.PermOwner
 	if encrypted {
-		passwords := []string{password}
-		if password != "" {
-			passwords = append(passwords, "")
+		p, err := DecryptPdfReader(r, password)
+		if err != nil {
+			return nil, 0, false, 0, errors.New("could not decrypt file with the provided password")
 		}
 
 		// Extract use permissions
-		_, perms, err = r.CheckAccessRights([]byte(password))
+		_, perms, err = r.CheckAccessRights([]byte(p))
 		if err != nil {
 			perms = unisecurity.Permissions(0)
 		}
-
-		var decrypted bool
-		for _, p := range passwords {
-			if auth, err := r.Decrypt([]byte(p)); err != nil || !auth {
-				continue
-			}
-
-			decrypted = true
-			break
-		}
-
-		if !decrypted {
-			return nil, 0, false, 0, errors.New("could not decrypt file with the provided password")
-		}
 	}
 
 	// Get number of pages.
@@ -156,6 +153,13 @@ func readPDF(filename, password string) (*unipdf.PdfReader, int, bool, unisecuri
 	return r, pages, encrypted, perms, nil
 }
 
+// NewPdfReader 读取并解密pdf, 依次尝试password、全局密码列表和空密码(只有owner密码的文件);
+// 写入完成前调用方需要保持f打开, 页面中的对象可能在写入时才读取
+func NewPdfReader(f io.ReadSeeker, password string) (*unipdf.PdfReader, error) {
+	r, _, _, _, err := newPDFReader(f, password)
+	return r, err
+}
+
 func createPageRange(count int) []int {
 	if count <= 0 {
 		return []int{}
@@ -193,8 +197,7 @@ func (u *UniPdf) SplitIntoFiles(filePath string, outputDir string) ([]string, er
 	}
 
 	if isEncrypted {
-		_, err = pdfReader.Decrypt([]byte(""))
-		if err != nil {
+		if _, err = DecryptPdfReader(pdfReader); err != nil {
 			return nil, errors.Errorf(err, "unidoc Decrypt failed")
 		}
 	}
@@ -282,8 +285,7 @@ func (u *UniPdf) SplitIntoBytes(filePath string) (map[int][]byte, error) {
 	}
 
 	if isEncrypted {
-		_, err = pdfReader.Decrypt([]byte(""))
-		if err != nil {
+		if _, err = DecryptPdfReader(pdfReader); err != nil {
 			return nil, errors.Errorf(err, "unidoc Decrypt failed")
 		}
 	}
@@ -366,8 +368,7 @@ func (u *UniPdf) ExtractImagesIntoFiles(filePath, outputDir string) ([]string, e
 	}
 
 	if isEncrypted {
-		_, err = pdfReader.Decrypt([]byte(""))
-		if err != nil {
+		if _, err = DecryptPdfReader(pdfReader); err != nil {
 			return nil, errors.Errorf(err, "unidoc Decrypt failed")
 		}
 	}
@@ -460,8 +461,7 @@ func (u *UniPdf) ExtractImagesIntoJpegBytes(filePath string) ([][]byte, error) {
 	}
 
 	if isEncrypted {
-		_, err = pdfReader.Decrypt([]byte(""))
-		if err != nil {
+		if _, err = DecryptPdfReader(pdfReader); err != nil {
 			return nil, errors.Errorf(err, "unidoc Decrypt failed")
 		}
 	}
@@ -528,3 +528,307 @@ func (u *UniPdf) ExtractImagesIntoJpegBytes(filePath string) ([][]byte, error) {
 
 	return imageBytes, nil
 }
+
+// ImagePage 一页图片, Width/Height为pdf页面大小, 单位point
+type ImagePage struct {
+	Image         image.Image
+	Width, Height float64
+}
+
+// ImagesToPdf 每张图片铺满一页, 生成pdf文件
+func (u *UniPdf) ImagesToPdf(pages []*ImagePage, output string) error {
+	c := unicreator.New()
+	for i, p := range pages {
+		img, err := c.NewImageFromGoImage(p.Image)
+		if err != nil {
+			return errors.Errorf(err, "create pdf image failed, page:%d", i+1)
+		}
+		img.SetEncoder(unicore.NewDCTEncoder())
+		img.SetPos(0, 0)
+		img.SetWidth(p.Width)
+		img.SetHeight(p.Height)
+
+		c.SetPageSize(unicreator.PageSize{p.Width, p.Height})
+		c.NewPage()
+		if err := c.Draw(img); err != nil {
+			return errors.Errorf(err, "draw image failed, page:%d", i+1)
+		}
+	}
+
+	if err := c.WriteToFile(output); err != nil {
+		return errors.Errorf(err, "write pdf file failed, file:%s", output)
+	}
+	return nil
+}
+
+// SelectPages 按页码范围(例如: 1-3,5)选出页面写入output, output可以与input相同
+func (u *UniPdf) SelectPages(input, output, ranges string) error {
+	r, count, _, _, err := readPDF(input, "")
+	if err != nil {
+		return errors.Errorf(err, "读取pdf失败, file:%s", input)
+	}
+
+	pages, err := ParsePageRanges(ranges, count)
+	if err != nil {
+		return errors.Errorf(err, "解析页码范围失败")
+	}
+
+	w := unipdf.NewPdfWriter()
+	for _, num := range pages {
+		page, err := r.GetPage(num)
+		if err != nil {
+			return errors.Errorf(err, "pdfReader.GetPage 失败, page:%d", num)
+		}
+		if err := w.AddPage(page); err != nil {
+			return errors.Errorf(err, "pdfWriter.AddPage failed, page:%d", num)
+		}
+	}
+
+	// 先写入内存, input和output相同时不会在读取完成前覆盖原文件
+	var buf bytes.Buffer
+	if err := w.Write(&buf); err != nil {
+		return errors.Errorf(err, "写入pdf失败")
+	}
+	if err := ioutil.WriteFile(output, buf.Bytes(), 0644); err != nil {
+		return errors.Errorf(err, "写入文件失败, file:%s", output)
+	}
+	return nil
+}
+
+// Bookmark pdf书签(大纲)中的一项
+type Bookmark struct {
+	Title string
+	Page  int // 从1开始, 找不到指向的页面时为0
+	Level int // 顶层书签为1
+}
+
+// Bookmarks 按书签在大纲中的顺序返回所有书签, 没有书签时返回空
+func (u *UniPdf) Bookmarks(inputPath, password string) ([]Bookmark, error) {
+	f, err := os.Open(inputPath)
+	if err != nil {
+		return nil, errors.Errorf(err, "open file failed, file:%s", inputPath)
+	}
+	defer f.Close()
+
+	r, _, _, _, err := newPDFReader(f, password)
+	if err != nil {
+		return nil, errors.Errorf(err, "读取pdf失败, file:%s", inputPath)
+	}
+
+	trailer, err := r.GetTrailer()
+	if err != nil {
+		return nil, errors.Errorf(err, "读取pdf trailer失败")
+	}
+	catalog, ok := unicore.GetDict(trailer.Get("Root"))
+	if !ok {
+		return nil, errors.Errorf(nil, "pdf中没有catalog")
+	}
+	outlines, ok := unicore.GetDict(catalog.Get("Outlines"))
+	if !ok {
+		return nil, nil
+	}
+
+	// 书签指向的是页面对象, 按对象编号找到页码
+	pageNums := make(map[int64]int)
+	for i, page := range r.PageList {
+		if ind, ok := page.GetContainingPdfObject().(*unicore.PdfIndirectObject); ok {
+			pageNums[ind.ObjectNumber] = i + 1
+		}
+	}
+
+	var (
+		bookmarks []Bookmark
+		visited   = make(map[unicore.PdfObject]struct{})
+		walk      func(obj unicore.PdfObject, level int)
+	)
+	walk = func(obj unicore.PdfObject, level int) {
+		for obj != nil {
+			obj = unicore.ResolveReference(obj)
+			// 防止损坏的文件中First/Next循环引用
+			if _, ok := visited[obj]; ok {
+				return
+			}
+			visited[obj] = struct{}{}
+
+			item, ok := unicore.GetDict(obj)
+			if !ok {
+				return
+			}
+
+			var title string
+			if s, ok := unicore.GetString(item.Get("Title")); ok {
+				title = strings.TrimSpace(s.Decoded())
+			}
+
+			dest := item.Get("Dest")
+			if dest == nil {
+				if action, ok := unicore.GetDict(item.Get("A")); ok {
+					if s, _ := unicore.GetNameVal(action.Get("S")); s == "GoTo" {
+						dest = action.Get("D")
+					}
+				}
+			}
+
+			bookmarks = append(bookmarks, Bookmark{Title: title, Page: destPage(catalog, dest, pageNums), Level: level})
+			walk(item.Get("First"), level+1)
+			obj = item.Get("Next")
+		}
+	}
+	walk(outlines.Get("First"), 1)
+
+	return bookmarks, nil
+}
+
+// destPage 解析跳转目标指向的页码, 支持直接目标、/Dests中的命名目标和/Names中的命名目标
+func destPage(catalog *unicore.PdfObjectDictionary, dest unicore.PdfObject, pageNums map[int64]int) int {
+	switch d := unicore.TraceToDirectObject(dest).(type) {
+	case *unicore.PdfObjectName:
+		if dests, ok := unicore.GetDict(catalog.Get("Dests")); ok {
+			dest = dests.Get(*d)
+		}
+	case *unicore.PdfObjectString:
+		dest = nil
+		if names, ok := unicore.GetDict(catalog.Get("Names")); ok {
+			dest = lookupNameTree(names.Get("Dests"), d.Str(), 0)
+		}
+	}
+
+	// 命名目标的值可以是数组, 也可以是包含/D的字典
+	if dict, ok := unicore.GetDict(dest); ok {
+		dest = dict.Get("D")
+	}
+	arr, ok := unicore.GetArray(dest)
+	if !ok || arr.Len() == 0 {
+		return 0
+	}
+
+	switch p := arr.Get(0).(type) {
+	case *unicore.PdfObjectReference:
+		return pageNums[p.ObjectNumber]
+	case *unicore.PdfIndirectObject:
+		return pageNums[p.ObjectNumber]
+	case *unicore.PdfObjectInteger:
+		// 部分生成工具直接写页码, 从0开始
+		return int(*p) + 1
+	}
+	return 0
+}
+
+// lookupNameTree 在名称树中查找name对应的值
+func lookupNameTree(node unicore.PdfObject, name string, depth int) unicore.PdfObject {
+	dict, ok := unicore.GetDict(node)
+	if !ok || depth > 32 {
+		return nil
+	}
+
+	if names, ok := unicore.GetArray(dict.Get("Names")); ok {
+		for i := 0; i+1 < names.Len(); i += 2 {
+			if s, ok := unicore.GetStringVal(names.Get(i)); ok && s == name {
+				return names.Get(i + 1)
+			}
+		}
+	}
+	if kids, ok := unicore.GetArray(dict.Get("Kids")); ok {
+		for _, kid := range kids.Elements() {
+			if v := lookupNameTree(kid, name, depth+1); v != nil {
+				return v
+			}
+		}
+	}
+	return nil
+}
+
+// PageContent 页面的文字以及是否包含图片
+type PageContent struct {
+	Text     string
+	HasImage bool
+}
+
+// IsBlank 没有文字也没有图片的页面
+func (c PageContent) IsBlank() bool {
+	return !c.HasImage && strings.TrimSpace(c.Text) == ""
+}
+
+// PageContents 提取每一页的文字, 并检查内容流中是否绘制了图片(Do/BI), 用于判断空白页
+func (u *UniPdf) PageContents(inputPath, password string) ([]PageContent, error) {
+	r, pageCount, _, _, err := readPDF(inputPath, password)
+	if err != nil {
+		return nil, errors.Errorf(err, "读取pdf失败, file:%s", inputPath)
+	}
+
+	contents := make([]PageContent, 0, pageCount)
+	for _, numPage := range createPageRange(pageCount) {
+		page, err := r.GetPage(numPage)
+		if err != nil {
+			return nil, errors.Errorf(err, "pdfReader.GetPage 失败, page:%d", numPage)
+		}
+
+		extractor, err := uniextractor.New(page)
+		if err != nil {
+			return nil, errors.Errorf(err, "创建文字提取器失败, page:%d", numPage)
+		}
+		text, err := extractor.ExtractText()
+		if err != nil {
+			return nil, errors.Errorf(err, "提取文字失败, page:%d", numPage)
+		}
+
+		cs, err := page.GetAllContentStreams()
+		if err != nil {
+			return nil, errors.Errorf(err, "读取内容流失败, page:%d", numPage)
+		}
+		ops, err := unicontentstream.NewContentStreamParser(cs).Parse()
+		if err != nil {
+			return nil, errors.Errorf(err, "解析内容流失败, page:%d", numPage)
+		}
+
+		var hasImage bool
+		for _, op := range *ops {
+			// Do也可能是表单对象, 这里不区分, 当作有内容处理
+			if op.Operand == "Do" || op.Operand == "BI" {
+				hasImage = true
+				break
+			}
+		}
+		contents = append(contents, PageContent{Text: text, HasImage: hasImage})
+	}
+	return contents, nil
+}
+
+// ScanPageCodes 扫描每一页中嵌入的图片, 返回每一页识别到的二维码/条形码内容, 没有识别到时为空.
+// 只扫描嵌入的图片, 矢量绘制的码需要调用方把页面转成图片后再扫描
+func (u *UniPdf) ScanPageCodes(inputPath, password, codeType string) ([]string, error) {
+	r, pageCount, _, _, err := readPDF(inputPath, password)
+	if err != nil {
+		return nil, errors.Errorf(err, "读取pdf失败, file:%s", inputPath)
+	}
+
+	codes := make([]string, pageCount)
+	for i, numPage := range createPageRange(pageCount) {
+		page, err := r.GetPage(numPage)
+		if err != nil {
+			return nil, errors.Errorf(err, "pdfReader.GetPage 失败, page:%d", numPage)
+		}
+
+		extractor, err := uniextractor.New(page)
+		if err != nil {
+			return nil, errors.Errorf(err, "new pdf image extractor failed, page:%d", numPage)
+		}
+		images, err := extractor.ExtractPageImages(nil)
+		if err != nil {
+			return nil, errors.Errorf(err, "extractor.ExtractPageImages failed, page:%d", numPage)
+		}
+
+		for _, img := range images.Images {
+			gimg, err := img.Image.ToGoImage()
+			if err != nil {
+				continue
+			}
+			// 识别失败继续扫描下一张图片
+			if code, _, err := LocateCode(gimg, codeType); err == nil && code != "" {
+				codes[i] = code
+				break
+			}
+		}
+	}
+	return codes, nil
+}
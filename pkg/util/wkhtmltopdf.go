@@ -5,13 +5,17 @@ import (
 	"fmt"
 	"net/url"
 	"os/exec"
-	"strings"
 	"time"
 
 	"invtools/utils/errors"
 )
 
-func WkHtmlToPDf(reqURL, pdfFile string) error {
+// WkHtmlToPDf 使用wkhtmltopdf打印url, opts为nil时使用默认选项
+func WkHtmlToPDf(reqURL, pdfFile string, opts *PrintOptions) error {
+	if opts == nil {
+		opts = DefaultPrintOptions()
+	}
+
 	if reqURL == "" || pdfFile == "" {
 		return errors.Errorf(nil, "[WkHtmlToPDF] reqURL(%s)或pdfFile(%s)为空.", reqURL, pdfFile)
 	}
@@ -21,23 +25,30 @@ func WkHtmlToPDf(reqURL, pdfFile string) error {
 		return errors.Errorf(err, "parse reqURL failed")
 	}
 
-	rawQuery := URL.RawQuery
-	if strings.Contains(rawQuery, "&") {
-		rawQuery = strings.Replace(rawQuery, "&", "\\&", -1)
-	}
-	reqURL = URL.Scheme + "://" + URL.Host + URL.Path + "?" + rawQuery
-
 	//cmdTpl := `wkhtmltopdf --orientation Portrait --page-size A4 --encoding utf-8 -R 0 -L 0 -T 0 -B 0 --quiet page %s %s`
 
-	cmdTpl := `/usr/local/bin/wkhtmltopdf --orientation Portrait --page-size A4 --encoding utf-8 -R 0 -L 0 -T 0 -B 0 --quiet page %s %s`
+	args, cleanup, err := opts.wkhtmltopdfArgs(URL)
+	if err != nil {
+		return errors.Errorf(err, "生成wkhtmltopdf参数失败")
+	}
+	defer cleanup()
 
-	cmdCreate := fmt.Sprintf(cmdTpl, reqURL, pdfFile)
+	// 不经过shell执行, header和cookie中的特殊字符不需要转义
+	args = append(args, "page", URL.String(), pdfFile)
 	ctx, cancel := context.WithTimeout(context.Background(), 180*time.Second)
 	defer cancel()
 
-	err = exec.CommandContext(ctx, "sh", "-c", cmdCreate).Run()
+	err = exec.CommandContext(ctx, "/usr/local/bin/wkhtmltopdf", args...).Run()
 	if err != nil {
-		return fmt.Errorf(" err:%s cmd:%s", err, cmdCreate)
+		// 参数中可能有密码和cookie, 只输出url
+		return fmt.Errorf(" err:%s url:%s", err, URL)
+	}
+
+	// wkhtmltopdf不支持指定页码, 打印完成后再选出需要的页
+	if opts.PageRanges != "" {
+		if err := NewUniPdf().SelectPages(pdfFile, pdfFile, opts.PageRanges); err != nil {
+			return errors.Errorf(err, "按页码范围选取页面失败")
+		}
 	}
 	return nil
 }
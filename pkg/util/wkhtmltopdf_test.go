@@ -25,7 +25,7 @@ func TestWkHtmlToPDf(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if err := WkHtmlToPDf(tt.args.reqURL, tt.args.pdfFile); (err != nil) != tt.wantErr {
+			if err := WkHtmlToPDf(tt.args.reqURL, tt.args.pdfFile, nil); (err != nil) != tt.wantErr {
 				t.Errorf("WkHtmlToPDf() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})